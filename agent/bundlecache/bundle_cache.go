@@ -0,0 +1,195 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundlecache
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer"
+)
+
+// Bundle is a bundle downloaded in the cache
+type Bundle struct {
+	// Addr is the address of the bundle, relative to the cache root
+	Addr string
+	// Digest is the digest the bundle address resolved to when it was downloaded
+	Digest string
+	// SizeBytes is the disk space used by the bundle
+	SizeBytes int64
+	// LastUsed is the last time the bundle was downloaded or used
+	LastUsed time.Time
+}
+
+// Cache manages the bundles downloaded under a root directory.
+// A bundle is part of the cache once the install script has written its marker file,
+// the last used time of a bundle is the modification time of its marker file.
+type Cache struct {
+	root         string
+	maxAge       time.Duration
+	maxSizeBytes int64
+	now          func() time.Time
+}
+
+// New returns a Cache for the bundles under root. Bundles not used for longer than maxAge
+// are garbage collected, as well as the least recently used bundles while the cache is
+// larger than maxSizeBytes. A zero value disables the corresponding limit.
+func New(root string, maxAge time.Duration, maxSizeBytes int64) *Cache {
+	return &Cache{
+		root:         root,
+		maxAge:       maxAge,
+		maxSizeBytes: maxSizeBytes,
+		now:          time.Now,
+	}
+}
+
+// List returns the bundles in the cache, least recently used first
+func (c *Cache) List() ([]Bundle, error) {
+	bundles := []Bundle{}
+	err := filepath.WalkDir(c.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == c.root {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		marker, err := os.Stat(filepath.Join(path, installer.BundleCacheMarkerFile))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		bundle, err := c.readBundle(path, marker)
+		if err != nil {
+			return err
+		}
+		bundles = append(bundles, bundle)
+		return fs.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(bundles, func(i, j int) bool {
+		return bundles[i].LastUsed.Before(bundles[j].LastUsed)
+	})
+	return bundles, nil
+}
+
+// Touch marks the bundle as used now. It is a no-op if the bundle is not in the cache.
+func (c *Cache) Touch(addr string) error {
+	now := c.now()
+	err := os.Chtimes(filepath.Join(c.path(addr), installer.BundleCacheMarkerFile), now, now)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// RemoveIncomplete removes the bundle directory if the bundle was not completely downloaded
+func (c *Cache) RemoveIncomplete(addr string) error {
+	_, err := os.Stat(filepath.Join(c.path(addr), installer.BundleCacheMarkerFile))
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return c.remove(addr)
+}
+
+// Remove removes the bundle from the cache, so that it is downloaded again on next use
+func (c *Cache) Remove(addr string) error {
+	return c.remove(addr)
+}
+
+// GarbageCollect removes the bundles exceeding the cache limits, except the bundles inUse.
+// It returns the bundles left in the cache.
+func (c *Cache) GarbageCollect(inUse ...string) ([]Bundle, error) {
+	bundles, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var totalSize int64
+	for _, bundle := range bundles {
+		totalSize += bundle.SizeBytes
+	}
+
+	kept := []Bundle{}
+	for _, bundle := range bundles {
+		expired := c.maxAge > 0 && c.now().Sub(bundle.LastUsed) > c.maxAge
+		oversized := c.maxSizeBytes > 0 && totalSize > c.maxSizeBytes
+		if slices.Contains(inUse, bundle.Addr) || (!expired && !oversized) {
+			kept = append(kept, bundle)
+			continue
+		}
+		if err := c.remove(bundle.Addr); err != nil {
+			return nil, err
+		}
+		totalSize -= bundle.SizeBytes
+	}
+	return kept, nil
+}
+
+func (c *Cache) readBundle(path string, marker fs.FileInfo) (Bundle, error) {
+	addr, err := filepath.Rel(c.root, path)
+	if err != nil {
+		return Bundle{}, err
+	}
+	digest, err := os.ReadFile(filepath.Join(path, installer.BundleCacheMarkerFile))
+	if err != nil {
+		return Bundle{}, err
+	}
+	var size int64
+	err = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return Bundle{}, err
+	}
+	return Bundle{
+		Addr:      filepath.ToSlash(addr),
+		Digest:    strings.TrimSpace(string(digest)),
+		SizeBytes: size,
+		LastUsed:  marker.ModTime(),
+	}, nil
+}
+
+func (c *Cache) path(addr string) string {
+	return filepath.Join(c.root, filepath.FromSlash(addr))
+}
+
+// remove deletes the bundle directory and the parent directories left empty, up to the cache root
+func (c *Cache) remove(addr string) error {
+	path := c.path(addr)
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	root := filepath.Clean(c.root)
+	for dir := filepath.Dir(path); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			// the directory is not empty or already removed
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundlecache_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/bundlecache"
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bundle cache", func() {
+	var (
+		root       string
+		oldBundle  = "registry.local/byoh/byoh-bundle-ubuntu_24.04_x86-64_k8s:v1.31.0"
+		newBundle  = "registry.local/byoh/byoh-bundle-ubuntu_24.04_x86-64_k8s:v1.32.0"
+		testDigest = "sha256:0123456789abcdef"
+	)
+
+	// writeBundle creates a downloaded bundle of the given size, last used at the given time
+	writeBundle := func(addr string, size int, lastUsed time.Time, complete bool) {
+		dir := filepath.Join(root, addr)
+		Expect(os.MkdirAll(dir, 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "containerd.tar"), make([]byte, size), 0o600)).To(Succeed())
+		if complete {
+			marker := filepath.Join(dir, installer.BundleCacheMarkerFile)
+			Expect(os.WriteFile(marker, []byte(testDigest+"\n"), 0o600)).To(Succeed())
+			Expect(os.Chtimes(marker, lastUsed, lastUsed)).To(Succeed())
+		}
+	}
+
+	BeforeEach(func() {
+		root = GinkgoT().TempDir()
+	})
+
+	It("should be empty if the download path does not exist", func() {
+		cache := bundlecache.New(filepath.Join(root, "non-existent"), 0, 0)
+		Expect(cache.List()).To(BeEmpty())
+	})
+
+	It("should list the completely downloaded bundles, least recently used first", func() {
+		writeBundle(newBundle, 10, time.Now(), true)
+		writeBundle(oldBundle, 20, time.Now().Add(-time.Hour), true)
+		writeBundle("registry.local/byoh/partial:v1.32.0", 30, time.Now(), false)
+
+		bundles, err := bundlecache.New(root, 0, 0).List()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundles).To(HaveLen(2))
+		Expect(bundles[0].Addr).To(Equal(oldBundle))
+		Expect(bundles[0].Digest).To(Equal(testDigest))
+		Expect(bundles[0].SizeBytes).To(BeNumerically(">=", 20))
+		Expect(bundles[1].Addr).To(Equal(newBundle))
+	})
+
+	It("should update the last used time of a bundle", func() {
+		writeBundle(oldBundle, 10, time.Now().Add(-time.Hour), true)
+		cache := bundlecache.New(root, 0, 0)
+		Expect(cache.Touch(oldBundle)).To(Succeed())
+		Expect(cache.Touch("registry.local/byoh/non-existent:v1.32.0")).To(Succeed())
+
+		bundles, err := cache.List()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundles[0].LastUsed).To(BeTemporally("~", time.Now(), time.Minute))
+	})
+
+	It("should remove incompletely downloaded bundles only", func() {
+		writeBundle(oldBundle, 10, time.Now(), true)
+		writeBundle(newBundle, 10, time.Now(), false)
+		cache := bundlecache.New(root, 0, 0)
+
+		Expect(cache.RemoveIncomplete(oldBundle)).To(Succeed())
+		Expect(cache.RemoveIncomplete(newBundle)).To(Succeed())
+		Expect(filepath.Join(root, oldBundle)).To(BeADirectory())
+		Expect(filepath.Join(root, newBundle)).NotTo(BeADirectory())
+	})
+
+	It("should remove a downloaded bundle", func() {
+		writeBundle(oldBundle, 10, time.Now(), true)
+		cache := bundlecache.New(root, 0, 0)
+
+		Expect(cache.Remove(oldBundle)).To(Succeed())
+		Expect(cache.List()).To(BeEmpty())
+	})
+
+	It("should garbage collect the bundles not used for longer than the max age", func() {
+		writeBundle(oldBundle, 10, time.Now().Add(-48*time.Hour), true)
+		writeBundle(newBundle, 10, time.Now(), true)
+
+		bundles, err := bundlecache.New(root, 24*time.Hour, 0).GarbageCollect("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundles).To(HaveLen(1))
+		Expect(bundles[0].Addr).To(Equal(newBundle))
+		Expect(filepath.Join(root, oldBundle)).NotTo(BeADirectory())
+	})
+
+	It("should garbage collect the least recently used bundles above the max size", func() {
+		writeBundle(oldBundle, 100, time.Now().Add(-time.Hour), true)
+		writeBundle(newBundle, 100, time.Now(), true)
+
+		bundles, err := bundlecache.New(root, 0, 150).GarbageCollect("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundles).To(HaveLen(1))
+		Expect(bundles[0].Addr).To(Equal(newBundle))
+	})
+
+	It("should never garbage collect the bundle in use", func() {
+		writeBundle(oldBundle, 100, time.Now().Add(-48*time.Hour), true)
+
+		bundles, err := bundlecache.New(root, time.Hour, 10).GarbageCollect(oldBundle)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundles).To(HaveLen(1))
+		Expect(filepath.Join(root, oldBundle)).To(BeADirectory())
+	})
+
+	It("should remove the directories left empty by garbage collection", func() {
+		writeBundle(oldBundle, 10, time.Now().Add(-48*time.Hour), true)
+
+		_, err := bundlecache.New(root, time.Hour, 0).GarbageCollect("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filepath.Join(root, "registry.local")).NotTo(BeADirectory())
+		Expect(root).To(BeADirectory())
+	})
+})
@@ -0,0 +1,16 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundlecache_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestBundlecache(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Bundlecache Suite")
+}
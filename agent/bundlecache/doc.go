@@ -0,0 +1,6 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package bundlecache tracks the bundles downloaded on the host by the install scripts
+// and garbage collects the ones no longer used
+package bundlecache
@@ -17,8 +17,12 @@ var _ = Describe("Help flag for host agent", func() {
 	Context("When the help flag is provided", func() {
 		expectedOptions := []string{
 			"--bootstrap-kubeconfig string",
+			"--bundle-cache-max-age duration",
+			"--bundle-cache-max-size string",
 			"--certExpiryDuration int",
+			"--data-dir string",
 			"--downloadpath string",
+			"--key-algorithm string",
 			"--kubeconfig string",
 			"--label labelFlags",
 			"--metricsbindaddress string",
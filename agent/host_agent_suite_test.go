@@ -6,9 +6,11 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"go/build"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -19,6 +21,7 @@ import (
 	"github.com/onsi/gomega/gexec"
 	certv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientset "k8s.io/client-go/kubernetes"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -27,6 +30,7 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/registration"
 	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/test/e2e"
 )
@@ -41,7 +45,6 @@ var (
 	agentLogFile           = "/tmp/agent-integration.log"
 	execLogFile            = "/tmp/agent-exec.log"
 	fakeDownloadPath       = "fake-download-path"
-	fakeBootstrapSecret    = "fake-bootstrap-secret"
 	fakeInstallationSecret = "fake-installation-secret"
 	testEnv                *envtest.Environment
 	dockerClient           *dClient.Client
@@ -184,3 +187,17 @@ func cleanup(ctx context.Context, byoHostContainer *container.CreateResponse, na
 		}
 	}
 }
+
+// getHostCSR returns the first CSR requested by the host
+func getHostCSR(cs clientset.Interface, hostname string) (*certv1.CertificateSigningRequest, error) {
+	csrList, err := cs.CertificatesV1().CertificateSigningRequests().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range csrList.Items {
+		if strings.HasPrefix(csrList.Items[i].Name, fmt.Sprintf(registration.ByohCSRNameFormat, hostname, "")) {
+			return &csrList.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no csr of host %s", hostname)
+}
@@ -0,0 +1,6 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package hoststate snapshots the host state changed by the k8s installation,
+// so that uninstall restores the host as it was rather than to fixed defaults
+package hoststate
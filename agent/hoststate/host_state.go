@@ -0,0 +1,375 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package hoststate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SnapshotFileName is the name of the snapshot file in the agent data directory
+const SnapshotFileName = "host-state.json"
+
+var (
+	// trackedModules are the kernel modules loaded by the installer
+	trackedModules = []string{"overlay", "br_netfilter"}
+	// sysctlPaths are the sysctl and module loading configurations the installer may change
+	sysctlPaths = []string{"/etc/sysctl.conf", "/etc/sysctl.d", "/etc/modules-load.d"}
+)
+
+// CmdRunner runs a bash command on the host
+type CmdRunner interface {
+	RunCmd(ctx context.Context, cmd string) error
+}
+
+// State is the host state changed by the k8s installation
+type State struct {
+	// CapturedAt is the time the snapshot was taken
+	CapturedAt time.Time `json:"capturedAt"`
+	// SwapActive is true if a swap device was in use
+	SwapActive bool `json:"swapActive"`
+	// SwapEntries are the swap entries of /etc/fstab that were not commented out
+	SwapEntries []string `json:"swapEntries"`
+	// FirewallActive is true if ufw was enabled
+	FirewallActive bool `json:"firewallActive"`
+	// Modules are the loaded kernel modules among the ones loaded by the installer
+	Modules []string `json:"modules"`
+	// HeldPackages are the packages held at their version
+	HeldPackages []string `json:"heldPackages"`
+	// SysctlFiles is the content of the sysctl and module loading configuration files, by path
+	SysctlFiles map[string]string `json:"sysctlFiles"`
+	// InstalledSysctlFiles is the content of the sysctl and module loading configuration files
+	// as the installation left them, by path. It is nil until the installation succeeds.
+	InstalledSysctlFiles map[string]string `json:"installedSysctlFiles,omitempty"`
+}
+
+// Snapshotter captures the host state before the k8s installation and restores it on uninstall
+type Snapshotter struct {
+	// Path is the snapshot file
+	Path string
+	// Root is the root of the host filesystem
+	Root string
+	// Runner runs the commands restoring the host state
+	Runner CmdRunner
+	// Output runs a bash command and returns its standard output
+	Output func(ctx context.Context, cmd string) ([]byte, error)
+}
+
+// New returns a Snapshotter keeping the snapshot in dataDir
+func New(dataDir string, runner CmdRunner) *Snapshotter {
+	return &Snapshotter{
+		Path:   filepath.Join(dataDir, SnapshotFileName),
+		Root:   "/",
+		Runner: runner,
+		Output: func(ctx context.Context, cmd string) ([]byte, error) {
+			return exec.CommandContext(ctx, "/bin/bash", "-c", cmd).Output()
+		},
+	}
+}
+
+// Capture snapshots the host state. An existing snapshot is kept, as the host may have been
+// partially changed by a previous installation attempt.
+func (s *Snapshotter) Capture(ctx context.Context) error {
+	if _, err := os.Stat(s.Path); err == nil {
+		return nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	state, err := s.current(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to capture host state: %w", err)
+	}
+	state.CapturedAt = time.Now()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.Path, data, 0o600)
+}
+
+// RecordInstalled records in the snapshot the sysctl and module loading configuration files
+// as the installation left them, so that Restore keeps the changes made to them afterwards.
+// Nothing is recorded if there is no snapshot.
+func (s *Snapshotter) RecordInstalled(ctx context.Context) error {
+	snapshot, err := s.read()
+	if snapshot == nil || err != nil {
+		return err
+	}
+	current, err := s.current(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read host state: %w", err)
+	}
+	snapshot.InstalledSysctlFiles = current.SysctlFiles
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.Path, data, 0o600)
+}
+
+// Restore reverts the host to the snapshot state and removes the snapshot.
+// Only the swap entries of /etc/fstab and the configuration files changed by the installation are restored.
+// It returns the changes made to the host, or nil if there is no snapshot.
+func (s *Snapshotter) Restore(ctx context.Context) ([]string, error) {
+	snapshot, err := s.read()
+	if snapshot == nil || err != nil {
+		return nil, err
+	}
+	current, err := s.current(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read host state: %w", err)
+	}
+
+	changes := []string{}
+	var errs []error
+	run := func(change, cmd string) {
+		if err := s.Runner.RunCmd(ctx, cmd); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", change, err))
+			return
+		}
+		changes = append(changes, change)
+	}
+
+	if restored, err := s.restoreSwapEntries(snapshot.SwapEntries); err != nil {
+		errs = append(errs, err)
+	} else if restored {
+		changes = append(changes, "restored swap entries of /etc/fstab")
+	}
+	switch {
+	case snapshot.SwapActive && !current.SwapActive:
+		run("enabled swap", "swapon -a")
+	case !snapshot.SwapActive && current.SwapActive:
+		run("disabled swap", "swapoff -a")
+	}
+	switch {
+	case snapshot.FirewallActive && !current.FirewallActive:
+		run("enabled firewall", "ufw --force enable")
+	case !snapshot.FirewallActive && current.FirewallActive:
+		run("disabled firewall", "ufw disable")
+	}
+	for _, module := range trackedModules {
+		wasLoaded, isLoaded := slices.Contains(snapshot.Modules, module), slices.Contains(current.Modules, module)
+		switch {
+		case wasLoaded && !isLoaded:
+			run("loaded module "+module, "modprobe "+module)
+		case !wasLoaded && isLoaded:
+			run("unloaded module "+module, "modprobe -r "+module)
+		}
+	}
+	for _, pkg := range current.HeldPackages {
+		if !slices.Contains(snapshot.HeldPackages, pkg) {
+			run("unheld package "+pkg, "apt-mark unhold "+pkg)
+		}
+	}
+	for _, pkg := range snapshot.HeldPackages {
+		if !slices.Contains(current.HeldPackages, pkg) {
+			run("held package "+pkg, fmt.Sprintf("if dpkg -s %s >>/dev/null 2>&1; then apt-mark hold %s; fi", pkg, pkg))
+		}
+	}
+	if sysctlChanges, err := s.restoreSysctlFiles(snapshot, current.SysctlFiles); err != nil {
+		errs = append(errs, err)
+	} else if len(sysctlChanges) > 0 {
+		changes = append(changes, sysctlChanges...)
+		run("reloaded sysctl settings", "sysctl --system")
+	}
+
+	if len(errs) > 0 {
+		// keep the snapshot to retry the restore
+		return changes, errors.Join(errs...)
+	}
+	return changes, os.Remove(s.Path)
+}
+
+// read returns the snapshot, or nil if there is none
+func (s *Snapshotter) read() (*State, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &State{}
+	if err = json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("invalid host state snapshot %s: %w", s.Path, err)
+	}
+	return snapshot, nil
+}
+
+// current reads the host state
+func (s *Snapshotter) current(ctx context.Context) (*State, error) {
+	state := &State{SysctlFiles: map[string]string{}}
+
+	swaps, err := os.ReadFile(s.hostPath("/proc/swaps"))
+	if err != nil {
+		return nil, err
+	}
+	// the first line is the header
+	state.SwapActive = len(nonEmptyLines(swaps)) > 1
+
+	fstab, err := os.ReadFile(s.hostPath("/etc/fstab"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, line := range nonEmptyLines(fstab) {
+		if isSwapEntry(line) {
+			state.SwapEntries = append(state.SwapEntries, line)
+		}
+	}
+
+	// ufw may not be installed, it is then considered disabled
+	firewall, _ := s.Output(ctx, "if command -v ufw >>/dev/null; then ufw status; fi")
+	state.FirewallActive = bytes.Contains(firewall, []byte("Status: active"))
+
+	modules, err := os.ReadFile(s.hostPath("/proc/modules"))
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range nonEmptyLines(modules) {
+		if module := strings.Fields(line)[0]; slices.Contains(trackedModules, module) {
+			state.Modules = append(state.Modules, module)
+		}
+	}
+
+	held, err := s.Output(ctx, "if command -v apt-mark >>/dev/null; then apt-mark showhold; fi")
+	if err != nil {
+		return nil, err
+	}
+	state.HeldPackages = nonEmptyLines(held)
+	sort.Strings(state.HeldPackages)
+
+	for _, path := range sysctlPaths {
+		err := filepath.WalkDir(s.hostPath(path), func(hostPath string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			content, err := os.ReadFile(hostPath)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(s.Root, hostPath)
+			if err != nil {
+				return err
+			}
+			state.SysctlFiles["/"+filepath.ToSlash(rel)] = string(content)
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// restoreSwapEntries comments out the swap entries of /etc/fstab that were commented out
+// in the snapshot and uncomments the ones that were not, leaving the other lines untouched.
+// It returns true if /etc/fstab was changed.
+func (s *Snapshotter) restoreSwapEntries(snapshot []string) (bool, error) {
+	fstab, err := os.ReadFile(s.hostPath("/etc/fstab"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	lines := strings.Split(string(fstab), "\n")
+	changed := false
+	for i, line := range lines {
+		entry := strings.TrimSpace(line)
+		commented := strings.HasPrefix(entry, "#")
+		entry = strings.TrimSpace(strings.TrimLeft(entry, "#"))
+		if !isSwapEntry(entry) {
+			continue
+		}
+		switch active := slices.Contains(snapshot, entry); {
+		case active && commented:
+			lines[i], changed = entry, true
+		case !active && !commented:
+			lines[i], changed = "#"+line, true
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+	return true, os.WriteFile(s.hostPath("/etc/fstab"), []byte(strings.Join(lines, "\n")), 0o644)
+}
+
+// restoreSysctlFiles rewrites the changed and removed files and removes the added ones.
+// Once the installation succeeded, only the files it changed are restored, unless they were changed since.
+func (s *Snapshotter) restoreSysctlFiles(snapshot *State, current map[string]string) ([]string, error) {
+	changes := []string{}
+	restorable := func(path string) bool {
+		if snapshot.InstalledSysctlFiles == nil {
+			return true
+		}
+		before, existed := snapshot.SysctlFiles[path]
+		installed, wasInstalled := snapshot.InstalledSysctlFiles[path]
+		if existed == wasInstalled && before == installed {
+			// not changed by the installation
+			return false
+		}
+		// the uninstallation removes the files of the installation
+		now, exists := current[path]
+		return !exists || !wasInstalled || now == installed
+	}
+	for path, content := range snapshot.SysctlFiles {
+		if currentContent, exists := current[path]; (exists && currentContent == content) || !restorable(path) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(s.hostPath(path)), 0o755); err != nil {
+			return changes, err
+		}
+		if err := os.WriteFile(s.hostPath(path), []byte(content), 0o644); err != nil {
+			return changes, err
+		}
+		changes = append(changes, "restored "+path)
+	}
+	for path := range current {
+		if _, exists := snapshot.SysctlFiles[path]; exists || !restorable(path) {
+			continue
+		}
+		if err := os.Remove(s.hostPath(path)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return changes, err
+		}
+		changes = append(changes, "removed "+path)
+	}
+	sort.Strings(changes)
+	return changes, nil
+}
+
+func (s *Snapshotter) hostPath(path string) string {
+	return filepath.Join(s.Root, filepath.FromSlash(path))
+}
+
+// isSwapEntry returns true if the fstab line mounts a swap device
+func isSwapEntry(line string) bool {
+	fields := strings.Fields(line)
+	return len(fields) >= 3 && !strings.HasPrefix(fields[0], "#") && fields[2] == "swap"
+}
+
+func nonEmptyLines(data []byte) []string {
+	lines := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
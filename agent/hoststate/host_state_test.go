@@ -0,0 +1,158 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package hoststate_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/hoststate"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeRunner records the commands it runs
+type fakeRunner struct {
+	commands []string
+}
+
+func (f *fakeRunner) RunCmd(_ context.Context, cmd string) error {
+	f.commands = append(f.commands, cmd)
+	return nil
+}
+
+var _ = Describe("Host state", func() {
+	var (
+		root        string
+		runner      *fakeRunner
+		snapshotter *hoststate.Snapshotter
+		ufwStatus   string
+		heldPkgs    string
+	)
+
+	writeHostFile := func(path, content string) {
+		Expect(os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, path), []byte(content), 0o644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		root = GinkgoT().TempDir()
+		runner = &fakeRunner{}
+		ufwStatus = "Status: inactive"
+		heldPkgs = "docker-ce\n"
+		snapshotter = hoststate.New(filepath.Join(root, "var/lib/byoh"), runner)
+		snapshotter.Root = root
+		snapshotter.Output = func(_ context.Context, cmd string) ([]byte, error) {
+			if strings.Contains(cmd, "ufw status") {
+				return []byte(ufwStatus), nil
+			}
+			return []byte(heldPkgs), nil
+		}
+
+		// swap and firewall off, no module loaded, one sysctl file
+		writeHostFile("/proc/swaps", "Filename Type Size Used Priority\n")
+		writeHostFile("/etc/fstab", "UUID=root / ext4 defaults 0 1\n#/swap.img none swap sw 0 0\n")
+		writeHostFile("/proc/modules", "ext4 1 0 - Live 0x0\n")
+		writeHostFile("/etc/sysctl.d/10-network.conf", "net.ipv4.ip_forward = 0\n")
+	})
+
+	It("should not restore anything without a snapshot", func() {
+		changes, err := snapshotter.Restore(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(BeNil())
+		Expect(runner.commands).To(BeEmpty())
+	})
+
+	It("should keep the first snapshot", func() {
+		Expect(snapshotter.Capture(context.TODO())).To(Succeed())
+		ufwStatus = "Status: active"
+		Expect(snapshotter.Capture(context.TODO())).To(Succeed())
+
+		changes, err := snapshotter.Restore(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(ConsistOf("disabled firewall"))
+	})
+
+	It("should restore the state the host had before installation", func() {
+		Expect(snapshotter.Capture(context.TODO())).To(Succeed())
+		info, err := os.Stat(filepath.Join(root, "var/lib/byoh", hoststate.SnapshotFileName))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+
+		// installation and uninstallation left the host changed
+		writeHostFile("/etc/fstab", "UUID=root / ext4 defaults 0 1\n/swap.img none swap sw 0 0\n")
+		writeHostFile("/proc/swaps", "Filename Type Size Used Priority\n/swap.img file 1024 0 -2\n")
+		writeHostFile("/proc/modules", "ext4 1 0 - Live 0x0\noverlay 1 0 - Live 0x0\n")
+		writeHostFile("/etc/sysctl.d/10-network.conf", "net.ipv4.ip_forward = 1\n")
+		writeHostFile("/etc/sysctl.d/99-kubernetes-cri.conf", "net.bridge.bridge-nf-call-iptables = 1\n")
+		ufwStatus = "Status: active"
+		heldPkgs = "kubelet\n"
+
+		changes, err := snapshotter.Restore(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(ConsistOf(
+			"restored swap entries of /etc/fstab",
+			"disabled swap",
+			"disabled firewall",
+			"unloaded module overlay",
+			"unheld package kubelet",
+			"held package docker-ce",
+			"restored /etc/sysctl.d/10-network.conf",
+			"removed /etc/sysctl.d/99-kubernetes-cri.conf",
+			"reloaded sysctl settings",
+		))
+		Expect(runner.commands).To(ContainElements("swapoff -a", "ufw disable", "modprobe -r overlay", "apt-mark unhold kubelet", "sysctl --system"))
+		Expect(runner.commands).NotTo(ContainElement("swapon -a"))
+		Expect(os.ReadFile(filepath.Join(root, "/etc/fstab"))).To(Equal([]byte("UUID=root / ext4 defaults 0 1\n#/swap.img none swap sw 0 0\n")))
+		Expect(os.ReadFile(filepath.Join(root, "/etc/sysctl.d/10-network.conf"))).To(Equal([]byte("net.ipv4.ip_forward = 0\n")))
+		Expect(filepath.Join(root, "/etc/sysctl.d/99-kubernetes-cri.conf")).NotTo(BeAnExistingFile())
+		Expect(filepath.Join(root, "var/lib/byoh", hoststate.SnapshotFileName)).NotTo(BeAnExistingFile())
+	})
+
+	It("should only restore what the installation changed", func() {
+		writeHostFile("/etc/fstab", "UUID=root / ext4 defaults 0 1\n/swap.img none swap sw 0 0\n")
+		Expect(snapshotter.Capture(context.TODO())).To(Succeed())
+
+		// the installation comments out the swap entry and adds its sysctl settings
+		writeHostFile("/etc/fstab", "UUID=root / ext4 defaults 0 1\n#/swap.img none swap sw 0 0\n")
+		writeHostFile("/etc/sysctl.d/99-kubernetes-cri.conf", "net.bridge.bridge-nf-call-iptables = 1\n")
+		Expect(snapshotter.RecordInstalled(context.TODO())).To(Succeed())
+
+		// the administrator changes the host once installed
+		writeHostFile("/etc/fstab", "UUID=root / ext4 defaults 0 1\nUUID=data /data ext4 defaults 0 2\n#/swap.img none swap sw 0 0\n")
+		writeHostFile("/etc/sysctl.d/10-network.conf", "net.ipv4.ip_forward = 1\n")
+
+		changes, err := snapshotter.Restore(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(ConsistOf(
+			"restored swap entries of /etc/fstab",
+			"removed /etc/sysctl.d/99-kubernetes-cri.conf",
+			"reloaded sysctl settings",
+		))
+		Expect(os.ReadFile(filepath.Join(root, "/etc/fstab"))).To(Equal([]byte("UUID=root / ext4 defaults 0 1\nUUID=data /data ext4 defaults 0 2\n/swap.img none swap sw 0 0\n")))
+		Expect(os.ReadFile(filepath.Join(root, "/etc/sysctl.d/10-network.conf"))).To(Equal([]byte("net.ipv4.ip_forward = 1\n")))
+	})
+
+	It("should keep the files changed since the installation", func() {
+		Expect(snapshotter.Capture(context.TODO())).To(Succeed())
+		writeHostFile("/etc/sysctl.d/99-kubernetes-cri.conf", "net.bridge.bridge-nf-call-iptables = 1\n")
+		Expect(snapshotter.RecordInstalled(context.TODO())).To(Succeed())
+		writeHostFile("/etc/sysctl.d/99-kubernetes-cri.conf", "net.bridge.bridge-nf-call-iptables = 0\n")
+
+		changes, err := snapshotter.Restore(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(BeEmpty())
+		Expect(filepath.Join(root, "/etc/sysctl.d/99-kubernetes-cri.conf")).To(BeAnExistingFile())
+	})
+
+	It("should report no change if the host is already in the snapshot state", func() {
+		Expect(snapshotter.Capture(context.TODO())).To(Succeed())
+		changes, err := snapshotter.Restore(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(BeEmpty())
+		Expect(runner.commands).To(BeEmpty())
+	})
+})
@@ -0,0 +1,16 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package hoststate_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestHostState(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Host State Suite")
+}
@@ -6,16 +6,17 @@ package main
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
-	"encoding/pem"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/bundlecache"
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/cloudinit"
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/hoststate"
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/reconciler"
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/registration"
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/version"
@@ -25,6 +26,7 @@ import (
 	pflag "github.com/spf13/pflag"
 	certv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
@@ -99,6 +101,10 @@ func setupflags() {
 	flag.StringVar(&bootstrapKubeConfig, "bootstrap-kubeconfig", "", "Provide bootstrap kubeconfig for bootstrap token workflow")
 	flag.BoolVar(&secureMetrics, "metrics-secure", false, "If set the metrics endpoint is served securely")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false, "If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.DurationVar(&bundleCacheMaxAge, "bundle-cache-max-age", 7*24*time.Hour, "Downloaded bundles not used for longer than this duration are garbage collected, 0 disables the age limit")
+	flag.StringVar(&bundleCacheMaxSize, "bundle-cache-max-size", "0", "Least recently used bundles are garbage collected while the downloaded bundles use more disk space than this quantity (e.g. 10Gi), 0 disables the size limit")
+	flag.StringVar(&dataDir, "data-dir", "/var/lib/byoh", "File System path to keep the agent state, such as the host state captured before installing the kubernetes components and the host credentials")
+	flag.StringVar(&keyAlgorithm, "key-algorithm", string(registration.KeyAlgorithmECDSA), "Algorithm of the private keys of the host certificates, ecdsa (P-256) or rsa")
 
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 	hiddenFlags := []string{
@@ -137,6 +143,10 @@ var (
 	certExpiryDuration  int64
 	secureMetrics       bool
 	enableHTTP2         bool
+	bundleCacheMaxAge   time.Duration
+	bundleCacheMaxSize  string
+	dataDir             string
+	keyAlgorithm        string
 )
 
 // TODO - fix logging
@@ -156,6 +166,20 @@ func main() {
 
 	logger := klogr.New()
 	ctrl.SetLogger(logger)
+	cacheMaxSize, err := resource.ParseQuantity(bundleCacheMaxSize)
+	if err != nil {
+		logger.Error(err, "invalid bundle-cache-max-size", "value", bundleCacheMaxSize)
+		return
+	}
+	switch registration.KeyAlgorithm(keyAlgorithm) {
+	case registration.KeyAlgorithmECDSA, registration.KeyAlgorithmRSA:
+		registration.PrivateKeyAlgorithm = registration.KeyAlgorithm(keyAlgorithm)
+	default:
+		logger.Error(fmt.Errorf("unsupported key algorithm %q", keyAlgorithm), "invalid key-algorithm, must be ecdsa or rsa")
+		return
+	}
+	// the certificates and private keys of the host are kept in the agent state
+	registration.CredentialsDir = filepath.Join(dataDir, "pki")
 	hostName, err := os.Hostname()
 	if err != nil {
 		logger.Error(err, "could not determine hostname")
@@ -174,6 +198,17 @@ func main() {
 	}
 	// Handle restart flow or if the ~/.byoh/config already exists
 	config := getConfig(logger)
+	// renew the client certificate of the host with itself, the clients created from the
+	// config of the renewer present the renewed certificate without restarting the agent
+	var certRenewer *registration.CertificateRenewer
+	if config.CertFile != "" || len(config.CertData) > 0 {
+		certRenewer, err = registration.NewCertificateRenewer(config, logger, hostName, certExpiryDuration)
+		if err != nil {
+			logger.Error(err, "certificate renewal initialization failed")
+			os.Exit(1)
+		}
+		config = certRenewer.Config()
+	}
 	k8sClient := getClient(logger, config)
 	registration.LocalHostRegistrar = &registration.HostRegistrar{K8sClient: k8sClient}
 	err = registration.LocalHostRegistrar.Register(hostName, namespace, labels)
@@ -182,18 +217,6 @@ func main() {
 		return
 	}
 
-	// Start certificate rotation goroutine.
-	// This is behind a feature flag for now. Set 'CERTIFICATE_ROTATION=true' to enable it.
-	if os.Getenv("CERTIFICATE_ROTATION") == "true" {
-		go func() {
-			err = certificateRotation(logger, hostName, config)
-			if err != nil {
-				logger.Error(err, "certificate rotation failed")
-				return
-			}
-		}()
-	}
-
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancelation and
@@ -255,11 +278,21 @@ func main() {
 		Recorder:            mgr.GetEventRecorderFor("hostagent-controller"),
 		SkipK8sInstallation: skipInstallation,
 		DownloadPath:        downloadpath,
+		BundleCache:         bundlecache.New(downloadpath, bundleCacheMaxAge, cacheMaxSize.Value()),
+		HostState:           hoststate.New(dataDir, cloudinit.CmdRunner{}),
 	}
 	if err = hostReconciler.SetupWithManager(context.TODO(), mgr); err != nil {
 		logger.Error(err, "unable to create controller")
 		return
 	}
+	if certRenewer != nil {
+		certRenewer.K8sClient = k8sClient
+		certRenewer.Namespace = namespace
+		if err = mgr.Add(certRenewer); err != nil {
+			logger.Error(err, "unable to add certificate renewal")
+			return
+		}
+	}
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		logger.Error(err, "problem running manager")
 		return
@@ -276,6 +309,9 @@ func handleBootstrapFlow(logger logr.Logger, hostName string) error {
 	if err != nil {
 		return fmt.Errorf("ByohCSR intialization failed: %v", err)
 	}
+	// the host certificate binds the host to its namespace and labels
+	byohCSR.Namespace = namespace
+	byohCSR.Labels = labels
 	err = byohCSR.BootstrapKubeconfig(hostName)
 	if err != nil {
 		return fmt.Errorf("kubeconfig generation failed: %v", err)
@@ -283,45 +319,6 @@ func handleBootstrapFlow(logger logr.Logger, hostName string) error {
 	return nil
 }
 
-func certificateRotation(logger logr.Logger, hostName string, config *rest.Config) error {
-	pollDuration := 5 * time.Second
-	for {
-		if err := certRotation(logger, hostName, config); err != nil {
-			return err
-		}
-		// Poll after every few seconds
-		time.Sleep(pollDuration)
-	}
-}
-
-func certRotation(logger logr.Logger, hostName string, config *rest.Config) error {
-	block, _ := pem.Decode(config.CertData)
-	if block == nil || block.Type != "CERTIFICATE" {
-		logger.Info("failed to decode PEM block containing certificate")
-		return nil
-	}
-
-	cert, err := x509.ParseCertificate(block.Bytes)
-	if err != nil {
-		logger.Error(err, "Certificate parse failed")
-		return err
-	}
-
-	totalTimeCert := cert.NotAfter.Sub(cert.NotBefore)
-
-	// if less than 20% time left, renew the certs.
-	// https://github.com/kubernetes-sigs/cluster-api/blob/main/docs/proposals/20210222-kubelet-authentication.md#kubelet-authenticator-flow
-	if time.Now().After(cert.NotAfter.Add(totalTimeCert / -5)) {
-		logger.Info("certificate expiration time left is less than 20%, renewing")
-		if err = handleBootstrapFlow(logger, hostName); err != nil {
-			logger.Error(err, "bootstrap flow failed")
-		}
-	} else {
-		logger.V(1).Info("certificate are valid", "will be renewed after", cert.NotAfter.Add(totalTimeCert/-5))
-	}
-	return nil
-}
-
 func getConfig(logger logr.Logger) *rest.Config {
 	config, err := registration.LoadRESTClientConfig(registration.GetBYOHConfigPath())
 	if err != nil {
@@ -5,16 +5,31 @@ package reconciler
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/fs"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/bundlecache"
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/cloudinit"
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/hoststate"
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/registration"
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/common"
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/bundlemanifest"
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/hooks"
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/steps"
 	"github.com/pkg/errors"
 	"golang.org/x/sys/unix"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/conditions"
@@ -37,8 +52,13 @@ type HostReconciler struct {
 	TemplateParser      cloudinit.ITemplateParser
 	Recorder            record.EventRecorder
 	ContainerRuntime    byohruntime.ContainerRuntime
+	BundleCache         *bundlecache.Cache
+	HostState           *hoststate.Snapshotter
 	DownloadPath        string
 	SkipK8sInstallation bool
+
+	// bundleCacheCollectedAt is the last garbage collection of the bundle cache, zero once the bundles in use changed
+	bundleCacheCollectedAt time.Time
 }
 
 const (
@@ -50,6 +70,10 @@ const (
 	removeKubeletBinaryCmd       = "rm -f /usr/bin/kubelet"
 	checkKubeletServiceActiveCmd = "systemctl is-active --quiet kubelet"
 	stopKubeletServiceCmd        = "systemctl stop kubelet"
+
+	// bundleCacheGCInterval limits how often the bundle cache is walked, the cache is garbage collected
+	// right away once the k8s components are installed, upgraded or uninstalled
+	bundleCacheGCInterval = 10 * time.Minute
 )
 
 // errContainerRuntimeNil is returned when container runtime is nil
@@ -69,6 +93,7 @@ func (r *HostReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctr
 	}
 	helper, _ := patch.NewHelper(byoHost, r.Client)
 	defer func() {
+		r.reconcileBundleCache(ctx, byoHost)
 		err = helper.Patch(ctx, byoHost)
 		if err != nil && reterr == nil {
 			logger.Error(err, "failed to patch byohost")
@@ -111,10 +136,10 @@ func (r *HostReconciler) reconcileNormal(ctx context.Context, byoHost *infrastru
 	}
 
 	if !conditions.IsTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded) {
-		bootstrapScript, err := r.getBootstrapScript(ctx, byoHost.Spec.BootstrapSecret.Name, byoHost.Spec.BootstrapSecret.Namespace)
+		bootstrapScript, err := r.getBootstrapScript(ctx, byoHost)
 		if err != nil {
 			logger.Error(err, "error getting bootstrap script")
-			r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "ReadBootstrapSecretFailed", "bootstrap secret %s not found", byoHost.Spec.BootstrapSecret.Name)
+			r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "ReadBootstrapSecretFailed", "bootstrap secret %s not found", byoHost.BootstrapSecretName())
 			return ctrl.Result{}, err
 		}
 
@@ -157,6 +182,9 @@ func (r *HostReconciler) reconcileNormal(ctx context.Context, byoHost *infrastru
 		conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)
 	}
 
+	if !r.SkipK8sInstallation && k8sUpgradeRequested(byoHost) {
+		return ctrl.Result{}, r.upgradeK8sComponents(ctx, byoHost)
+	}
 	return ctrl.Result{}, nil
 }
 
@@ -171,43 +199,539 @@ func (r *HostReconciler) executeInstallerController(ctx context.Context, byoHost
 	}
 	installScript := string(secret.Data["install"])
 	uninstallScript := string(secret.Data["uninstall"])
+	bundleAddr := string(secret.Data[infrastructurev1beta1.BundleAddrSecretKey])
+	components, err := parseInstallationComponents(secret)
+	if err != nil {
+		logger.Error(err, "error parsing installation components")
+		return err
+	}
 
 	byoHost.Spec.UninstallationScript = &uninstallScript
-	installScript, err = r.parseScript(ctx, installScript)
+	registryConfigDir, err := r.writeRegistryCredentials(ctx, secret)
 	if err != nil {
+		logger.Error(err, "error writing registry credentials")
+		r.Recorder.Event(byoHost, corev1.EventTypeWarning, "WriteRegistryCredentialsFailed", "failed to write registry credentials")
 		return err
 	}
-	logger.Info("executing install script on byohost", "name", byoHost.Name)
-	err = r.CmdRunner.RunCmd(ctx, installScript)
+	if registryConfigDir != "" {
+		// the credentials are only needed while the install script pulls the bundle
+		defer os.RemoveAll(registryConfigDir)
+	}
+	plan, err := r.parseInstallationPlan(ctx, secret, registryConfigDir)
 	if err != nil {
+		logger.Error(err, "error parsing installation steps")
+		return err
+	}
+	if r.HostState != nil {
+		if err = r.HostState.Capture(ctx); err != nil {
+			logger.Error(err, "error capturing host state")
+			r.Recorder.Event(byoHost, corev1.EventTypeWarning, "CaptureHostStateFailed", "failed to capture host state")
+			return err
+		}
+	}
+	installerHooks, err := parseInstallationHooks(secret)
+	if err != nil {
+		logger.Error(err, "error parsing installation hooks")
+		return err
+	}
+	if err = r.runHooks(ctx, byoHost, installerHooks, hooks.PreInstall); err != nil {
+		logger.Error(err, "error running pre-install hooks")
+		errR := r.restoreHostState(ctx, byoHost)
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded, infrastructurev1beta1.K8sComponentsInstallationFailedReason, clusterv1.ConditionSeverityInfo, "")
+		return kerrors.NewAggregate([]error{err, errR})
+	}
+	byoHost.Status.Installation = &infrastructurev1beta1.InstallationStatus{}
+	engine := r.stepEngine(byoHost)
+	if plan != nil {
+		logger.Info("executing installation steps on byohost", "name", byoHost.Name)
+		err = engine.Install(ctx, plan)
+	} else {
+		// installation secrets without steps only provide the install script
+		installScript, err = r.parseScript(ctx, installScript, registryConfigDir, "")
+		if err != nil {
+			return err
+		}
+		logger.Info("executing install script on byohost", "name", byoHost.Name)
+		err = r.CmdRunner.RunCmd(ctx, installScript)
+	}
+	if err != nil {
+		if r.BundleCache != nil && bundleAddr != "" {
+			// do not leave a partially downloaded bundle behind
+			if errR := r.BundleCache.RemoveIncomplete(bundleAddr); errR != nil {
+				logger.Error(errR, "error removing incomplete bundle", "bundle", bundleAddr)
+			}
+		}
 		logger.Error(err, "error executing installation script")
 		r.Recorder.Event(byoHost, corev1.EventTypeWarning, "InstallScriptExecutionFailed", "install script execution failed")
+		errR := r.restoreHostState(ctx, byoHost)
 		conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded, infrastructurev1beta1.K8sComponentsInstallationFailedReason, clusterv1.ConditionSeverityInfo, "")
-		return err
+		return kerrors.NewAggregate([]error{err, errR})
+	}
+	if err = r.installBundleComponents(ctx, engine, bundleAddr, components); err != nil {
+		logger.Error(err, "error installing bundle components")
+		r.Recorder.Event(byoHost, corev1.EventTypeWarning, "InstallBundleComponentsFailed", "bundle components installation failed")
+		errR := r.rollbackInstallation(ctx, byoHost, engine, plan, bundleAddr, components)
+		if r.BundleCache != nil && errors.Is(err, bundlemanifest.ErrChecksumMismatch) {
+			// download the bundle and the component artifacts again on next reconcile instead of reusing the corrupted ones
+			for _, addr := range append([]string{bundleAddr}, slices.Collect(maps.Values(components))...) {
+				if errR := r.BundleCache.Remove(addr); errR != nil {
+					logger.Error(errR, "error removing corrupted bundle", "bundle", addr)
+				}
+			}
+		}
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded, infrastructurev1beta1.K8sComponentsInstallationFailedReason, clusterv1.ConditionSeverityInfo, "")
+		return kerrors.NewAggregate([]error{err, errR})
+	}
+	if err = r.runHooks(ctx, byoHost, installerHooks, hooks.PostInstall); err != nil {
+		logger.Error(err, "error running post-install hooks")
+		errR := r.rollbackInstallation(ctx, byoHost, engine, plan, bundleAddr, components)
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded, infrastructurev1beta1.K8sComponentsInstallationFailedReason, clusterv1.ConditionSeverityInfo, "")
+		return kerrors.NewAggregate([]error{err, errR})
 	}
 	logger.Info("Successfully executed install script on byohost", "name", byoHost.Name)
+	if r.HostState != nil {
+		// the host state is then fully restored on uninstall, reverting the changes made since the installation
+		if err = r.HostState.RecordInstalled(ctx); err != nil {
+			logger.Error(err, "error recording installed host state")
+		}
+	}
+	byoHost.Status.Installation.K8sVersion = byoHost.Annotations[infrastructurev1beta1.K8sVersionAnnotation]
+	byoHost.Status.Installation.Plan = string(secret.Data[infrastructurev1beta1.InstallationStepsSecretKey])
+	r.setBundleInUse(ctx, byoHost, bundleAddr, components)
 	return nil
 }
 
+// k8sUpgradeRequested returns true if the k8s version of the host changed since its k8s components were installed,
+// to another version than the one the upgrade to failed
+func k8sUpgradeRequested(byoHost *infrastructurev1beta1.ByoHost) bool {
+	k8sVersion := byoHost.Annotations[infrastructurev1beta1.K8sVersionAnnotation]
+	installation := byoHost.Status.Installation
+	return installation != nil && installation.K8sVersion != "" && k8sVersion != "" && k8sVersion != installation.K8sVersion &&
+		k8sVersion != installation.FailedUpgradeK8sVersion
+}
+
+// upgradeK8sComponents upgrades in place the k8s components of the bootstrapped node to the version of the
+// K8sVersionAnnotation, once the installation secret is regenerated for the bundle of the new version.
+// The upgrade steps are rolled back to the components of the previous bundle if they fail,
+// and the upgrade is then only retried once the k8s version changes again.
+// The installation plan and the uninstall script of the previous version are kept until the upgrade succeeds.
+func (r *HostReconciler) upgradeK8sComponents(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) error {
+	logger := ctrl.LoggerFrom(ctx)
+	k8sVersion := byoHost.Annotations[infrastructurev1beta1.K8sVersionAnnotation]
+	if byoHost.Spec.InstallationSecret == nil {
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sUpgradeSucceeded, infrastructurev1beta1.K8sUpgradePendingReason, clusterv1.ConditionSeverityInfo,
+			"waiting for the installation secret of k8s version %s", k8sVersion)
+		return nil
+	}
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: byoHost.Spec.InstallationSecret.Name, Namespace: byoHost.Spec.InstallationSecret.Namespace}, secret)
+	if err != nil {
+		logger.Error(err, "error getting installation secret")
+		r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "ReadInstallationSecretFailed", "install and uninstall script %s not found", byoHost.Spec.InstallationSecret.Name)
+		return err
+	}
+	bundleAddr := string(secret.Data[infrastructurev1beta1.BundleAddrSecretKey])
+	var previousBundleAddr string
+	if byoHost.Status.BundleCache != nil {
+		previousBundleAddr = byoHost.Status.BundleCache.InUse
+	}
+	upgradeSteps, ok := secret.Data[infrastructurev1beta1.InstallationUpgradeStepsSecretKey]
+	if !ok || bundleAddr == "" || bundleAddr == previousBundleAddr {
+		logger.Info("waiting for the installation secret of the new k8s version", "k8sVersion", k8sVersion)
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sUpgradeSucceeded, infrastructurev1beta1.K8sUpgradePendingReason, clusterv1.ConditionSeverityInfo,
+			"waiting for the installation secret of k8s version %s", k8sVersion)
+		return nil
+	}
+	if previousBundleAddr == "" {
+		// the upgrade could not be rolled back
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sUpgradeSucceeded, infrastructurev1beta1.K8sUpgradeFailedReason, clusterv1.ConditionSeverityError,
+			"the bundle the k8s components were installed from is unknown")
+		return nil
+	}
+
+	registryConfigDir, err := r.writeRegistryCredentials(ctx, secret)
+	if err != nil {
+		logger.Error(err, "error writing registry credentials")
+		r.Recorder.Event(byoHost, corev1.EventTypeWarning, "WriteRegistryCredentialsFailed", "failed to write registry credentials")
+		return err
+	}
+	if registryConfigDir != "" {
+		defer os.RemoveAll(registryConfigDir)
+	}
+	plan, err := steps.Parse(upgradeSteps)
+	if err != nil {
+		logger.Error(err, "error parsing upgrade steps")
+		return err
+	}
+	if plan.Preamble, err = r.parseScript(ctx, plan.Preamble, registryConfigDir, previousBundleAddr); err != nil {
+		return err
+	}
+
+	logger.Info("upgrading k8s components", "from", byoHost.Status.Installation.K8sVersion, "to", k8sVersion)
+	engine := &steps.Engine{
+		Runner: r.CmdRunner,
+		OnProgress: func(step string, phase steps.Phase, _ error) {
+			logger.Info("upgrade step", "step", step, "phase", phase)
+		},
+	}
+	if err = engine.Install(ctx, plan); err != nil {
+		if r.BundleCache != nil {
+			if errR := r.BundleCache.RemoveIncomplete(bundleAddr); errR != nil {
+				logger.Error(errR, "error removing incomplete bundle", "bundle", bundleAddr)
+			}
+		}
+		logger.Error(err, "error upgrading k8s components")
+		r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "K8sUpgradeFailed", "upgrade to k8s version %s failed, rolled back to bundle %s", k8sVersion, previousBundleAddr)
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sUpgradeSucceeded, infrastructurev1beta1.K8sUpgradeFailedReason, clusterv1.ConditionSeverityError, "%s", err.Error())
+		// the failure is reported, retrying the same upgrade would fail again
+		byoHost.Status.Installation.FailedUpgradeK8sVersion = k8sVersion
+		return nil
+	}
+
+	uninstallScript := string(secret.Data["uninstall"])
+	byoHost.Spec.UninstallationScript = &uninstallScript
+	byoHost.Status.Installation.K8sVersion = k8sVersion
+	byoHost.Status.Installation.FailedUpgradeK8sVersion = ""
+	byoHost.Status.Installation.Plan = string(secret.Data[infrastructurev1beta1.InstallationStepsSecretKey])
+	// the upgrade steps only replace the packages of the bundle, the component artifacts stay installed
+	r.setBundleInUse(ctx, byoHost, bundleAddr, byoHost.Status.BundleCache.ComponentsInUse)
+	logger.Info("k8s components successfully upgraded", "k8sVersion", k8sVersion)
+	r.Recorder.Eventf(byoHost, corev1.EventTypeNormal, "K8sUpgradeSucceeded", "k8s components upgraded to version %s", k8sVersion)
+	conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sUpgradeSucceeded)
+	return nil
+}
+
+// installBundleComponents installs the components listed in the manifest of the bundle, after checking
+// their checksums. Bundles without a manifest have their components installed by the install script.
+func (r *HostReconciler) installBundleComponents(ctx context.Context, engine *steps.Engine, bundleAddr string, components map[string]string) error {
+	manifest, bundleDir, err := r.loadBundleManifest(bundleAddr, components)
+	if err != nil || manifest == nil {
+		return err
+	}
+	if err = manifest.VerifyChecksums(bundleDir); err != nil {
+		return err
+	}
+	ctrl.LoggerFrom(ctx).Info("installing bundle components from manifest", "bundle", bundleAddr)
+	return engine.Install(ctx, manifest.Plan(bundleDir))
+}
+
+// uninstallK8sComponents undoes the installation steps applied on the host, in reverse order.
+// Hosts installed without steps run the uninstall script instead.
+func (r *HostReconciler) uninstallK8sComponents(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) error {
+	logger := ctrl.LoggerFrom(ctx)
+	installerHooks, err := r.getInstallationHooks(ctx, byoHost)
+	if err != nil {
+		return err
+	}
+	if err = r.runHooks(ctx, byoHost, installerHooks, hooks.PreUninstall); err != nil {
+		logger.Error(err, "error running pre-uninstall hooks")
+		return err
+	}
+	engine := r.stepEngine(byoHost)
+	applied := appliedInstallationSteps(byoHost)
+
+	var bundleAddr string
+	var components map[string]string
+	if byoHost.Status.BundleCache != nil {
+		bundleAddr = byoHost.Status.BundleCache.InUse
+		components = byoHost.Status.BundleCache.ComponentsInUse
+	}
+	manifest, bundleDir, err := r.loadBundleManifest(bundleAddr, components)
+	if err != nil {
+		return err
+	}
+	if manifest != nil {
+		logger.Info("removing bundle components from manifest", "bundle", bundleAddr)
+		if err = engine.Rollback(ctx, manifest.Plan(bundleDir), applied); err != nil {
+			logger.Error(err, "error removing bundle components")
+			r.Recorder.Event(byoHost, corev1.EventTypeWarning, "UninstallBundleComponentsFailed", "bundle components uninstallation failed")
+			return err
+		}
+	}
+
+	var plan *steps.Plan
+	if byoHost.Status.Installation != nil {
+		if plan, err = r.getInstallationPlan(ctx, byoHost); err != nil {
+			return err
+		}
+	}
+	if plan != nil {
+		logger.Info("Undoing installation steps")
+		err = engine.Rollback(ctx, plan, applied)
+	} else {
+		if byoHost.Spec.UninstallationScript == nil {
+			errC := r.checkAndPopulateUninstallScriptFromInstallSecret(ctx, byoHost)
+			if errC != nil {
+				return fmt.Errorf("UninstallationScript not found in Byohost %s: %w", byoHost.Name, errC)
+			}
+		}
+		logger.Info("Executing Uninstall script")
+		uninstallScript := *byoHost.Spec.UninstallationScript
+		uninstallScript, err = r.parseScript(ctx, uninstallScript, "", "")
+		if err != nil {
+			logger.Error(err, "error parsing Uninstallation script")
+			return err
+		}
+		err = r.CmdRunner.RunCmd(ctx, uninstallScript)
+	}
+	if err != nil {
+		logger.Error(err, "error execting Uninstallation script")
+		r.Recorder.Event(byoHost, corev1.EventTypeWarning, "UninstallScriptExecutionFailed", "uninstall script execution failed")
+		return err
+	}
+	if err = r.restoreHostState(ctx, byoHost); err != nil {
+		return err
+	}
+	// the k8s components are removed, failing post-uninstall hooks do not keep the host from being released
+	if err = r.runHooks(ctx, byoHost, installerHooks, hooks.PostUninstall); err != nil {
+		logger.Error(err, "error running post-uninstall hooks")
+	}
+	byoHost.Status.Installation = nil
+	return nil
+}
+
+// rollbackInstallation undoes the bundle components and the installation steps applied on the host,
+// or runs the uninstall script of hosts installed without steps, and restores the host state,
+// when the installation fails once they are applied. It returns the rollback and restore errors.
+func (r *HostReconciler) rollbackInstallation(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost, engine *steps.Engine, plan *steps.Plan,
+	bundleAddr string, components map[string]string) error {
+	logger := ctrl.LoggerFrom(ctx)
+	var errs []error
+	applied := appliedInstallationSteps(byoHost)
+	manifest, bundleDir, err := r.loadBundleManifest(bundleAddr, components)
+	if err != nil {
+		logger.Error(err, "error loading bundle manifest", "bundle", bundleAddr)
+		errs = append(errs, err)
+	} else if manifest != nil {
+		if err = engine.Rollback(ctx, manifest.Plan(bundleDir), applied); err != nil {
+			logger.Error(err, "error rolling back bundle components")
+			errs = append(errs, err)
+		}
+	}
+	if plan != nil {
+		if err = engine.Rollback(ctx, plan, applied); err != nil {
+			logger.Error(err, "error rolling back installation steps")
+			errs = append(errs, err)
+		}
+	} else if byoHost.Spec.UninstallationScript != nil {
+		uninstallScript, err := r.parseScript(ctx, *byoHost.Spec.UninstallationScript, "", "")
+		if err == nil {
+			err = r.CmdRunner.RunCmd(ctx, uninstallScript)
+		}
+		if err != nil {
+			logger.Error(err, "error executing uninstall script")
+			errs = append(errs, err)
+		}
+	}
+	return kerrors.NewAggregate(append(errs, r.restoreHostState(ctx, byoHost)))
+}
+
+// runHooks runs the installer hooks of the point and reports the result of each hook as an event
+func (r *HostReconciler) runHooks(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost, installerHooks *hooks.Hooks, point hooks.Point) error {
+	return installerHooks.Run(ctx, r.CmdRunner, point, func(hook hooks.Hook, err error) {
+		if err != nil {
+			r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "HookFailed", "%s hook %s failed", point, hook.Name)
+			return
+		}
+		r.Recorder.Eventf(byoHost, corev1.EventTypeNormal, "HookSucceeded", "%s hook %s succeeded", point, hook.Name)
+	})
+}
+
+// getInstallationHooks returns the hooks of the installation secret.
+// Nil hooks are returned if the secret is gone, no longer granted or has no hooks.
+func (r *HostReconciler) getInstallationHooks(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) (*hooks.Hooks, error) {
+	if byoHost.Spec.InstallationSecret == nil {
+		return nil, nil
+	}
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: byoHost.Spec.InstallationSecret.Name, Namespace: byoHost.Spec.InstallationSecret.Namespace}, secret)
+	if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+		// the grant of the installation secret is revoked once the host is released
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseInstallationHooks(secret)
+}
+
+// restoreHostState reverts the host to the state captured before installation,
+// reports the changes made to the host as an event and the result in the HostStateRestored condition
+func (r *HostReconciler) restoreHostState(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) error {
+	if r.HostState == nil {
+		return nil
+	}
+	changes, err := r.HostState.Restore(ctx)
+	if len(changes) > 0 {
+		r.Recorder.Eventf(byoHost, corev1.EventTypeNormal, "HostStateRestored", "restored host state: %s", strings.Join(changes, ", "))
+	}
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "error restoring host state")
+		r.Recorder.Event(byoHost, corev1.EventTypeWarning, "RestoreHostStateFailed", "failed to restore host state")
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.HostStateRestored, infrastructurev1beta1.HostStateRestoreFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+		return err
+	}
+	conditions.MarkTrue(byoHost, infrastructurev1beta1.HostStateRestored)
+	return nil
+}
+
+// getInstallationPlan returns the installation steps recorded for the installed k8s version, or from the
+// installation secret for hosts installed before the plan was recorded.
+// A nil plan is returned if the secret is gone, no longer granted or has no steps.
+func (r *HostReconciler) getInstallationPlan(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) (*steps.Plan, error) {
+	if installation := byoHost.Status.Installation; installation != nil && installation.Plan != "" {
+		return r.parsePlan(ctx, []byte(installation.Plan), "")
+	}
+	if byoHost.Spec.InstallationSecret == nil {
+		return nil, nil
+	}
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: byoHost.Spec.InstallationSecret.Name, Namespace: byoHost.Spec.InstallationSecret.Namespace}, secret)
+	if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+		// the grant of the installation secret is revoked once the host is released
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.parseInstallationPlan(ctx, secret, "")
+}
+
+// parseInstallationPlan parses the installation steps of the installation secret, if any,
+// and applies the host values to their preamble
+func (r *HostReconciler) parseInstallationPlan(ctx context.Context, secret *corev1.Secret, registryConfigDir string) (*steps.Plan, error) {
+	data, ok := secret.Data[infrastructurev1beta1.InstallationStepsSecretKey]
+	if !ok {
+		return nil, nil
+	}
+	return r.parsePlan(ctx, data, registryConfigDir)
+}
+
+// parsePlan parses marshalled installation steps and applies the host values to their preamble
+func (r *HostReconciler) parsePlan(ctx context.Context, data []byte, registryConfigDir string) (*steps.Plan, error) {
+	plan, err := steps.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Preamble, err = r.parseScript(ctx, plan.Preamble, registryConfigDir, ""); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// parseInstallationHooks parses the hooks of the installation secret, if any
+func parseInstallationHooks(secret *corev1.Secret) (*hooks.Hooks, error) {
+	data, ok := secret.Data[infrastructurev1beta1.InstallationHooksSecretKey]
+	if !ok {
+		return nil, nil
+	}
+	return hooks.Parse(data)
+}
+
+// parseInstallationComponents returns the addresses of the component artifacts of the installation secret,
+// by component name, nil if all the components are installed from the bundle
+func parseInstallationComponents(secret *corev1.Secret) (map[string]string, error) {
+	data, ok := secret.Data[infrastructurev1beta1.InstallationComponentsSecretKey]
+	if !ok {
+		return nil, nil
+	}
+	components := map[string]string{}
+	if err := json.Unmarshal(data, &components); err != nil {
+		return nil, err
+	}
+	return components, nil
+}
+
+// stepEngine returns an engine recording the progress of the installation steps in the ByoHost status
+func (r *HostReconciler) stepEngine(byoHost *infrastructurev1beta1.ByoHost) *steps.Engine {
+	return &steps.Engine{
+		Runner: r.CmdRunner,
+		OnProgress: func(step string, phase steps.Phase, err error) {
+			if byoHost.Status.Installation == nil {
+				byoHost.Status.Installation = &infrastructurev1beta1.InstallationStatus{}
+			}
+			stepStatus := infrastructurev1beta1.InstallationStepStatus{
+				Name:               step,
+				Phase:              infrastructurev1beta1.InstallationStepPhase(phase),
+				LastTransitionTime: metav1.Now(),
+			}
+			if err != nil {
+				stepStatus.Message = err.Error()
+			}
+			for i := range byoHost.Status.Installation.Steps {
+				if byoHost.Status.Installation.Steps[i].Name == step {
+					byoHost.Status.Installation.Steps[i] = stepStatus
+					return
+				}
+			}
+			byoHost.Status.Installation.Steps = append(byoHost.Status.Installation.Steps, stepStatus)
+		},
+	}
+}
+
+// appliedInstallationSteps returns the names of the installation steps applied on the host
+func appliedInstallationSteps(byoHost *infrastructurev1beta1.ByoHost) []string {
+	applied := []string{}
+	if byoHost.Status.Installation == nil {
+		return applied
+	}
+	for _, step := range byoHost.Status.Installation.Steps {
+		if step.Phase == infrastructurev1beta1.InstallationStepApplied {
+			applied = append(applied, step.Name)
+		}
+	}
+	return applied
+}
+
+// loadBundleManifest returns the manifest of the downloaded bundle and the bundle directory, with the
+// components installed from their own artifact replaced by the components of the artifact manifests.
+// A nil manifest is returned if the bundle has no manifest.
+func (r *HostReconciler) loadBundleManifest(bundleAddr string, components map[string]string) (*bundlemanifest.Manifest, string, error) {
+	if bundleAddr == "" {
+		return nil, "", nil
+	}
+	bundleDir := filepath.Join(r.DownloadPath, filepath.FromSlash(bundleAddr))
+	manifest, err := bundlemanifest.Load(bundleDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	for _, component := range slices.Sorted(maps.Keys(components)) {
+		artifactDir := filepath.Join(r.DownloadPath, filepath.FromSlash(components[component]))
+		artifact, err := bundlemanifest.Load(artifactDir)
+		if err != nil {
+			return nil, "", fmt.Errorf("manifest of component %s: %w", component, err)
+		}
+		manifest.Replace(artifact, artifactDir)
+	}
+	return manifest, bundleDir, nil
+}
+
 func (r *HostReconciler) reconcileDelete(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) (ctrl.Result, error) {
 	return ctrl.Result{}, nil
 }
 
-func (r *HostReconciler) getBootstrapScript(ctx context.Context, dataSecretName, namespace string) (string, error) {
+// getBootstrapScript reads the bootstrap data from the bootstrap Secret of the ByoHost, the manager
+// projects the bootstrap data of the attached machine into it and grants the host no other bootstrap Secret
+func (r *HostReconciler) getBootstrapScript(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) (string, error) {
 	secret := &corev1.Secret{}
-	err := r.Client.Get(ctx, types.NamespacedName{Name: dataSecretName, Namespace: namespace}, secret)
+	err := r.Client.Get(ctx, types.NamespacedName{Name: byoHost.BootstrapSecretName(), Namespace: byoHost.Namespace}, secret)
 	if err != nil {
 		return "", err
 	}
 
-	bootstrapSecret := string(secret.Data["value"])
+	bootstrapSecret := string(secret.Data[infrastructurev1beta1.BootstrapDataSecretKey])
 	return bootstrapSecret, nil
 }
 
-func (r *HostReconciler) parseScript(ctx context.Context, script string) (string, error) {
+func (r *HostReconciler) parseScript(ctx context.Context, script, registryConfigDir, previousBundleAddr string) (string, error) {
 	data, err := cloudinit.TemplateParser{
 		Template: map[string]string{
 			"BundleDownloadPath": r.DownloadPath,
+			"RegistryConfigDir":  registryConfigDir,
+			"PreviousBundleAddr": previousBundleAddr,
 		},
 	}.ParseTemplate(script)
 	if err != nil {
@@ -216,6 +740,86 @@ func (r *HostReconciler) parseScript(ctx context.Context, script string) (string
 	return data, nil
 }
 
+// setBundleInUse records the bundle and the component artifacts backing the k8s installation, protecting
+// them from garbage collection and locating their manifest on uninstall.
+// The previous bundle and artifacts in use are marked as used now, so that they are kept for reuse until they expire.
+func (r *HostReconciler) setBundleInUse(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost, bundleAddr string, components map[string]string) {
+	if byoHost.Status.BundleCache == nil {
+		byoHost.Status.BundleCache = &infrastructurev1beta1.BundleCacheStatus{}
+	}
+	addrs := []string{byoHost.Status.BundleCache.InUse, bundleAddr}
+	addrs = append(addrs, slices.Collect(maps.Values(byoHost.Status.BundleCache.ComponentsInUse))...)
+	addrs = append(addrs, slices.Collect(maps.Values(components))...)
+	for _, addr := range addrs {
+		if addr == "" || r.BundleCache == nil {
+			continue
+		}
+		if err := r.BundleCache.Touch(addr); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "error updating bundle last used time", "bundle", addr)
+		}
+	}
+	byoHost.Status.BundleCache.InUse = bundleAddr
+	byoHost.Status.BundleCache.ComponentsInUse = components
+	r.bundleCacheCollectedAt = time.Time{}
+}
+
+// reconcileBundleCache garbage collects the bundle cache and reports its usage in the ByoHost status,
+// at most every bundleCacheGCInterval unless the bundles in use changed
+func (r *HostReconciler) reconcileBundleCache(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) {
+	if r.BundleCache == nil || time.Since(r.bundleCacheCollectedAt) < bundleCacheGCInterval {
+		return
+	}
+	status := &infrastructurev1beta1.BundleCacheStatus{}
+	if byoHost.Status.BundleCache != nil {
+		status.InUse = byoHost.Status.BundleCache.InUse
+		status.ComponentsInUse = byoHost.Status.BundleCache.ComponentsInUse
+	}
+	bundles, err := r.BundleCache.GarbageCollect(append([]string{status.InUse}, slices.Collect(maps.Values(status.ComponentsInUse))...)...)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "error garbage collecting bundle cache")
+		return
+	}
+	r.bundleCacheCollectedAt = time.Now()
+	for _, bundle := range bundles {
+		status.SizeBytes += bundle.SizeBytes
+		status.Bundles = append(status.Bundles, infrastructurev1beta1.CachedBundle{
+			Address:   bundle.Addr,
+			Digest:    bundle.Digest,
+			SizeBytes: bundle.SizeBytes,
+			LastUsed:  metav1.NewTime(bundle.LastUsed),
+		})
+	}
+	byoHost.Status.BundleCache = status
+}
+
+// writeRegistryCredentials writes the dockerconfigjson of the Secret referenced by the installation secret
+// to a config.json readable only by the agent, in a new directory to be used as DOCKER_CONFIG.
+// It returns an empty path if there are no credentials.
+func (r *HostReconciler) writeRegistryCredentials(ctx context.Context, installationSecret *corev1.Secret) (string, error) {
+	credentialsSecretName := string(installationSecret.Data[infrastructurev1beta1.RegistryCredentialsSecretNameKey])
+	if credentialsSecretName == "" {
+		return "", nil
+	}
+	credentialsSecret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: credentialsSecretName, Namespace: installationSecret.Namespace}, credentialsSecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to get registry credentials secret %s: %w", credentialsSecretName, err)
+	}
+	dockerConfigJSON := credentialsSecret.Data[corev1.DockerConfigJsonKey]
+	if len(dockerConfigJSON) == 0 {
+		return "", nil
+	}
+	dir, err := os.MkdirTemp("", "byoh-registry-")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), dockerConfigJSON, 0o600); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
 // SetupWithManager sets up the controller with the manager
 func (r *HostReconciler) SetupWithManager(ctx context.Context, mgr manager.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
@@ -264,25 +868,10 @@ func (r *HostReconciler) hostCleanUp(ctx context.Context, byoHost *infrastructur
 		if r.SkipK8sInstallation {
 			logger.Info("Skipping uninstallation of k8s components")
 		} else {
-			if byoHost.Spec.UninstallationScript == nil {
-				errC := r.checkAndPopulateUninstallScriptFromInstallSecret(ctx, byoHost)
-				if errC != nil {
-					return fmt.Errorf("UninstallationScript not found in Byohost %s: %w", byoHost.Name, errC)
-				}
-			}
-			logger.Info("Executing Uninstall script")
-			uninstallScript := *byoHost.Spec.UninstallationScript
-			uninstallScript, err = r.parseScript(ctx, uninstallScript)
-			if err != nil {
-				logger.Error(err, "error parsing Uninstallation script")
-				return err
-			}
-			err = r.CmdRunner.RunCmd(ctx, uninstallScript)
-			if err != nil {
-				logger.Error(err, "error execting Uninstallation script")
-				r.Recorder.Event(byoHost, corev1.EventTypeWarning, "UninstallScriptExecutionFailed", "uninstall script execution failed")
+			if err = r.uninstallK8sComponents(ctx, byoHost); err != nil {
 				return err
 			}
+			r.setBundleInUse(ctx, byoHost, "", nil)
 		}
 		conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded, infrastructurev1beta1.K8sNodeAbsentReason, clusterv1.ConditionSeverityInfo, "")
 		logger.Info("host removed from the cluster and the uninstall is executed successfully")
@@ -303,6 +892,7 @@ func (r *HostReconciler) hostCleanUp(ctx context.Context, byoHost *infrastructur
 
 	byoHost.Spec.InstallationSecret = nil
 	byoHost.Spec.UninstallationScript = nil
+	conditions.Delete(byoHost, infrastructurev1beta1.K8sUpgradeSucceeded)
 	r.removeAnnotations(ctx, byoHost)
 	conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded, infrastructurev1beta1.K8sNodeAbsentReason, clusterv1.ConditionSeverityInfo, "")
 	return nil
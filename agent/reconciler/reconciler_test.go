@@ -5,18 +5,32 @@ package reconciler_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/bundlecache"
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/cloudinit/cloudinitfakes"
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/hoststate"
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/reconciler"
 	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer"
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/bundlemanifest"
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/hooks"
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/steps"
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/test/builder"
 	eventutils "github.com/cohesity/cluster-api-provider-bringyourownhost/test/utils/events"
 	byohruntime "github.com/cohesity/cluster-api-provider-bringyourownhost/util/runtime"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -210,8 +224,8 @@ var _ = Describe("Byohost Agent Tests", func() {
 			It("should return an error if we fail to load the bootstrap secret", func() {
 				byoHost.Spec.BootstrapSecret = &corev1.ObjectReference{
 					Kind:      "Secret",
-					Namespace: "non-existent",
-					Name:      "non-existent",
+					Namespace: ns,
+					Name:      byoHost.BootstrapSecretName(),
 				}
 				Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
 
@@ -219,12 +233,12 @@ var _ = Describe("Byohost Agent Tests", func() {
 					NamespacedName: byoHostLookupKey,
 				})
 				Expect(result).To(Equal(controllerruntime.Result{}))
-				Expect(reconcilerErr).To(MatchError("secrets \"non-existent\" not found"))
+				Expect(reconcilerErr).To(MatchError(fmt.Sprintf("secrets \"%s\" not found", byoHost.BootstrapSecretName())))
 
 				// assert events
 				events := eventutils.CollectEvents(recorder.Events)
 				Expect(events).Should(ConsistOf([]string{
-					fmt.Sprintf("Warning ReadBootstrapSecretFailed bootstrap secret %s not found", byoHost.Spec.BootstrapSecret.Name),
+					fmt.Sprintf("Warning ReadBootstrapSecretFailed bootstrap secret %s not found", byoHost.BootstrapSecretName()),
 				}))
 			})
 
@@ -236,7 +250,7 @@ var _ = Describe("Byohost Agent Tests", func() {
 runCmd:
 - echo 'run some command'`
 
-					bootstrapSecret = builder.Secret(ns, "test-secret").
+					bootstrapSecret = builder.Secret(ns, byoHost.BootstrapSecretName()).
 						WithData(secretData).
 						Build()
 					Expect(k8sClient.Create(ctx, bootstrapSecret)).NotTo(HaveOccurred())
@@ -434,6 +448,428 @@ runCmd:
 						}))
 					})
 
+					It("should provide the registry credentials to the install script", func() {
+						credentialsSecret := builder.Secret(ns, "registry-credentials").
+							WithType(corev1.SecretTypeDockerConfigJson).
+							WithKeyData(corev1.DockerConfigJsonKey, `{"auths":{"registry.local":{"auth":"dXNlcjpwYXNz"}}}`).
+							Build()
+						Expect(k8sClient.Create(ctx, credentialsSecret)).NotTo(HaveOccurred())
+						installationSecret := builder.Secret(ns, "registry-credentials-secret").
+							WithKeyData("install", "DOCKER_CONFIG={{.RegistryConfigDir}}").
+							WithKeyData("uninstall", uninstallScript).
+							WithKeyData(infrastructurev1beta1.RegistryCredentialsSecretNameKey, credentialsSecret.Name).
+							Build()
+						Expect(k8sClient.Create(ctx, installationSecret)).NotTo(HaveOccurred())
+						byoHost.Spec.InstallationSecret = &corev1.ObjectReference{
+							Kind:      "Secret",
+							Namespace: installationSecret.Namespace,
+							Name:      installationSecret.Name,
+						}
+						Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+
+						var registryConfigDir string
+						fakeCommandRunner.RunCmdCalls(func(_ context.Context, cmd string) error {
+							if strings.HasPrefix(cmd, "DOCKER_CONFIG=") {
+								registryConfigDir = strings.TrimPrefix(cmd, "DOCKER_CONFIG=")
+								Expect(filepath.Join(registryConfigDir, "config.json")).To(BeARegularFile())
+							}
+							return nil
+						})
+
+						_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).ToNot(HaveOccurred())
+						Expect(registryConfigDir).NotTo(BeEmpty())
+						Expect(registryConfigDir).NotTo(BeADirectory())
+					})
+
+					It("should track the installed bundle in the bundle cache", func() {
+						downloadPath := GinkgoT().TempDir()
+						bundleAddr := "registry.local/byoh/byoh-bundle:v1.32.0"
+						hostReconciler.DownloadPath = downloadPath
+						hostReconciler.BundleCache = bundlecache.New(downloadPath, time.Hour, 0)
+
+						cacheSecret := builder.Secret(ns, "bundle-cache-secret").
+							WithKeyData("install", "install").
+							WithKeyData("uninstall", uninstallScript).
+							WithKeyData(infrastructurev1beta1.BundleAddrSecretKey, bundleAddr).
+							Build()
+						Expect(k8sClient.Create(ctx, cacheSecret)).NotTo(HaveOccurred())
+						byoHost.Spec.InstallationSecret = &corev1.ObjectReference{
+							Kind:      "Secret",
+							Namespace: cacheSecret.Namespace,
+							Name:      cacheSecret.Name,
+						}
+						Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+
+						// the install script downloads the bundle
+						fakeCommandRunner.RunCmdCalls(func(_ context.Context, cmd string) error {
+							if cmd == "install" {
+								bundlePath := filepath.Join(downloadPath, bundleAddr)
+								Expect(os.MkdirAll(bundlePath, 0o755)).To(Succeed())
+								Expect(os.WriteFile(filepath.Join(bundlePath, installer.BundleCacheMarkerFile), []byte("sha256:abc"), 0o600)).To(Succeed())
+							}
+							return nil
+						})
+
+						_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).ToNot(HaveOccurred())
+
+						updatedByoHost := &infrastructurev1beta1.ByoHost{}
+						Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+						Expect(updatedByoHost.Status.BundleCache).NotTo(BeNil())
+						Expect(updatedByoHost.Status.BundleCache.InUse).To(Equal(bundleAddr))
+						Expect(updatedByoHost.Status.BundleCache.Bundles).To(HaveLen(1))
+						Expect(updatedByoHost.Status.BundleCache.Bundles[0].Digest).To(Equal("sha256:abc"))
+
+						// the cache is not walked again on the next reconcile
+						otherBundlePath := filepath.Join(downloadPath, "registry.local/byoh/byoh-bundle:v1.31.0")
+						Expect(os.MkdirAll(otherBundlePath, 0o755)).To(Succeed())
+						Expect(os.WriteFile(filepath.Join(otherBundlePath, installer.BundleCacheMarkerFile), []byte("sha256:def"), 0o600)).To(Succeed())
+						_, reconcilerErr = hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).ToNot(HaveOccurred())
+						Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+						Expect(updatedByoHost.Status.BundleCache.Bundles).To(HaveLen(1))
+					})
+
+					It("should install the components listed in the bundle manifest", func() {
+						downloadPath := GinkgoT().TempDir()
+						bundleAddr := "registry.local/byoh/byoh-bundle:v1.32.0"
+						hostReconciler.DownloadPath = downloadPath
+
+						manifestSecret := builder.Secret(ns, "bundle-manifest-secret").
+							WithKeyData("install", "install").
+							WithKeyData("uninstall", uninstallScript).
+							WithKeyData(infrastructurev1beta1.BundleAddrSecretKey, bundleAddr).
+							Build()
+						Expect(k8sClient.Create(ctx, manifestSecret)).NotTo(HaveOccurred())
+						byoHost.Spec.InstallationSecret = &corev1.ObjectReference{
+							Kind:      "Secret",
+							Namespace: manifestSecret.Namespace,
+							Name:      manifestSecret.Name,
+						}
+						Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+
+						// the install script downloads a bundle shipping a manifest
+						fakeCommandRunner.RunCmdCalls(func(_ context.Context, cmd string) error {
+							if cmd == "install" {
+								bundlePath := filepath.Join(downloadPath, bundleAddr)
+								Expect(os.MkdirAll(bundlePath, 0o755)).To(Succeed())
+								Expect(os.WriteFile(filepath.Join(bundlePath, "kubelet.deb"), []byte("kubelet"), 0o600)).To(Succeed())
+								Expect(os.WriteFile(filepath.Join(bundlePath, bundlemanifest.FileName), []byte(`apiVersion: `+bundlemanifest.APIVersion+`
+kind: `+bundlemanifest.Kind+`
+components:
+- name: kubelet
+  version: 1.32.0-00
+  type: deb
+  file: kubelet.deb
+  sha256: 1ca4bc7eb9b3d6f1e205da9cfab437c89d3760d0765a29a6bcbccf4ad51a2cb1
+`), 0o600)).To(Succeed())
+							}
+							return nil
+						})
+
+						_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).ToNot(HaveOccurred())
+						Expect(fakeCommandRunner.RunCmdCallCount()).To(BeNumerically(">=", 2))
+						_, manifestScript := fakeCommandRunner.RunCmdArgsForCall(1)
+						Expect(manifestScript).To(ContainSubstring("apt-mark hold 'kubelet'"))
+
+						updatedByoHost := &infrastructurev1beta1.ByoHost{}
+						Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+						Expect(updatedByoHost.Status.BundleCache).NotTo(BeNil())
+						Expect(updatedByoHost.Status.BundleCache.InUse).To(Equal(bundleAddr))
+					})
+
+					It("should install the component artifacts instead of the components of the bundle", func() {
+						downloadPath := GinkgoT().TempDir()
+						bundleAddr := "registry.local/byoh/byoh-bundle:v1.32.0"
+						componentAddr := "registry.local/byoh/byoh-component-cri-tools-ubuntu_20.04.1_x86-64:v1.32.1"
+						hostReconciler.DownloadPath = downloadPath
+						hostReconciler.BundleCache = bundlecache.New(downloadPath, time.Hour, 0)
+
+						componentsSecret := builder.Secret(ns, "bundle-components-secret").
+							WithKeyData("install", "install").
+							WithKeyData("uninstall", uninstallScript).
+							WithKeyData(infrastructurev1beta1.BundleAddrSecretKey, bundleAddr).
+							WithKeyData(infrastructurev1beta1.InstallationComponentsSecretKey, `{"cri-tools":"`+componentAddr+`"}`).
+							Build()
+						Expect(k8sClient.Create(ctx, componentsSecret)).NotTo(HaveOccurred())
+						byoHost.Spec.InstallationSecret = &corev1.ObjectReference{
+							Kind:      "Secret",
+							Namespace: componentsSecret.Namespace,
+							Name:      componentsSecret.Name,
+						}
+						Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+
+						writeManifest := func(dir, component, file string) {
+							sum := sha256.Sum256([]byte(component))
+							Expect(os.MkdirAll(dir, 0o755)).To(Succeed())
+							Expect(os.WriteFile(filepath.Join(dir, installer.BundleCacheMarkerFile), []byte("sha256:abc"), 0o600)).To(Succeed())
+							Expect(os.WriteFile(filepath.Join(dir, file), []byte(component), 0o600)).To(Succeed())
+							Expect(os.WriteFile(filepath.Join(dir, bundlemanifest.FileName), []byte(`apiVersion: `+bundlemanifest.APIVersion+`
+kind: `+bundlemanifest.Kind+`
+components:
+- name: `+component+`
+  version: 1.32.1-00
+  type: deb
+  file: `+file+`
+  sha256: `+hex.EncodeToString(sum[:])+`
+`), 0o600)).To(Succeed())
+						}
+						// the install script downloads the bundle and the cri-tools artifact
+						fakeCommandRunner.RunCmdCalls(func(_ context.Context, cmd string) error {
+							if cmd == "install" {
+								writeManifest(filepath.Join(downloadPath, bundleAddr), "cri-tools", "cri-tools-bundle.deb")
+								writeManifest(filepath.Join(downloadPath, componentAddr), "cri-tools", "cri-tools.deb")
+							}
+							return nil
+						})
+
+						_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).ToNot(HaveOccurred())
+						Expect(fakeCommandRunner.RunCmdCallCount()).To(BeNumerically(">=", 2))
+						_, manifestScript := fakeCommandRunner.RunCmdArgsForCall(1)
+						Expect(manifestScript).To(ContainSubstring(filepath.Join(downloadPath, componentAddr, "cri-tools.deb")))
+						Expect(manifestScript).NotTo(ContainSubstring("cri-tools-bundle.deb"))
+
+						updatedByoHost := &infrastructurev1beta1.ByoHost{}
+						Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+						Expect(updatedByoHost.Status.BundleCache).NotTo(BeNil())
+						Expect(updatedByoHost.Status.BundleCache.InUse).To(Equal(bundleAddr))
+						Expect(updatedByoHost.Status.BundleCache.ComponentsInUse).To(Equal(map[string]string{"cri-tools": componentAddr}))
+						Expect(updatedByoHost.Status.BundleCache.Bundles).To(HaveLen(2))
+					})
+
+					Context("When the bundle components fail to install", func() {
+						var (
+							downloadPath string
+							bundleAddr   = "registry.local/byoh/byoh-bundle:v1.32.0"
+						)
+
+						// useSecret points the host to an installation secret of the bundle with the extra data
+						useSecret := func(name string, data map[string]string) {
+							secretBuilder := builder.Secret(ns, name).
+								WithKeyData("install", "install").
+								WithKeyData("uninstall", uninstallScript).
+								WithKeyData(infrastructurev1beta1.BundleAddrSecretKey, bundleAddr)
+							for key, value := range data {
+								secretBuilder = secretBuilder.WithKeyData(key, value)
+							}
+							secret := secretBuilder.Build()
+							Expect(k8sClient.Create(ctx, secret)).NotTo(HaveOccurred())
+							byoHost.Spec.InstallationSecret = &corev1.ObjectReference{
+								Kind:      "Secret",
+								Namespace: secret.Namespace,
+								Name:      secret.Name,
+							}
+							Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+						}
+
+						BeforeEach(func() {
+							downloadPath = GinkgoT().TempDir()
+							hostReconciler.DownloadPath = downloadPath
+							// the bundle ships a manifest with a wrong checksum
+							bundlePath := filepath.Join(downloadPath, bundleAddr)
+							Expect(os.MkdirAll(bundlePath, 0o755)).To(Succeed())
+							Expect(os.WriteFile(filepath.Join(bundlePath, "kubelet.deb"), []byte("kubelet"), 0o600)).To(Succeed())
+							Expect(os.WriteFile(filepath.Join(bundlePath, bundlemanifest.FileName), []byte(`apiVersion: `+bundlemanifest.APIVersion+`
+kind: `+bundlemanifest.Kind+`
+components:
+- name: kubelet
+  version: 1.32.0-00
+  type: deb
+  file: kubelet.deb
+  sha256: e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
+`), 0o600)).To(Succeed())
+						})
+
+						It("should run the uninstall script of an installation without steps", func() {
+							useSecret("bundle-components-script-secret", nil)
+
+							_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+								NamespacedName: byoHostLookupKey,
+							})
+							Expect(reconcilerErr).To(MatchError(bundlemanifest.ErrChecksumMismatch))
+							Expect(fakeCommandRunner.RunCmdCallCount()).To(Equal(2))
+							_, rollbackCmd := fakeCommandRunner.RunCmdArgsForCall(1)
+							Expect(rollbackCmd).To(Equal(uninstallScript))
+
+							updatedByoHost := &infrastructurev1beta1.ByoHost{}
+							Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+							Expect(conditions.GetReason(updatedByoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded)).To(Equal(infrastructurev1beta1.K8sComponentsInstallationFailedReason))
+						})
+
+						It("should return the errors rolling back the installation steps", func() {
+							plan := &steps.Plan{
+								Preamble: "set -e",
+								Steps: []steps.Step{
+									{Name: "kubeadm", Apply: "dpkg --install kubeadm.deb", Undo: "dpkg --purge kubeadm"},
+								},
+							}
+							planData, err := plan.Marshal()
+							Expect(err).NotTo(HaveOccurred())
+							useSecret("bundle-components-steps-secret", map[string]string{
+								infrastructurev1beta1.InstallationStepsSecretKey: string(planData),
+							})
+							var commands []string
+							fakeCommandRunner.RunCmdCalls(func(_ context.Context, cmd string) error {
+								commands = append(commands, strings.TrimPrefix(cmd, "set -e\n"))
+								if strings.HasSuffix(cmd, "dpkg --purge kubeadm") {
+									return errors.New("kubeadm is in use")
+								}
+								return nil
+							})
+
+							_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+								NamespacedName: byoHostLookupKey,
+							})
+							Expect(reconcilerErr).To(MatchError(bundlemanifest.ErrChecksumMismatch))
+							Expect(reconcilerErr).To(MatchError(ContainSubstring("kubeadm is in use")))
+							Expect(commands).To(Equal([]string{"dpkg --install kubeadm.deb", "dpkg --purge kubeadm"}))
+						})
+					})
+
+					It("should execute the installation steps and roll them back on failure", func() {
+						plan := &steps.Plan{
+							Preamble: "BUNDLE_DOWNLOAD_PATH={{.BundleDownloadPath}}",
+							Steps: []steps.Step{
+								{Name: "disable-swap", Apply: "swapoff -a", Undo: "swapon -a"},
+								{Name: "kubelet", Apply: "dpkg --install kubelet.deb", Verify: "dpkg -s kubelet", Undo: "dpkg --purge kubelet"},
+							},
+						}
+						planData, err := plan.Marshal()
+						Expect(err).NotTo(HaveOccurred())
+						stepsSecret := builder.Secret(ns, "installation-steps-secret").
+							WithKeyData("install", plan.InstallScript()).
+							WithKeyData("uninstall", plan.UninstallScript()).
+							WithKeyData(infrastructurev1beta1.InstallationStepsSecretKey, string(planData)).
+							Build()
+						Expect(k8sClient.Create(ctx, stepsSecret)).NotTo(HaveOccurred())
+						byoHost.Spec.InstallationSecret = &corev1.ObjectReference{
+							Kind:      "Secret",
+							Namespace: stepsSecret.Namespace,
+							Name:      stepsSecret.Name,
+						}
+						Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+
+						var commands []string
+						fakeCommandRunner.RunCmdCalls(func(_ context.Context, cmd string) error {
+							Expect(cmd).To(HavePrefix("BUNDLE_DOWNLOAD_PATH=" + hostReconciler.DownloadPath + "\n"))
+							commands = append(commands, strings.SplitN(cmd, "\n", 2)[1])
+							if strings.HasSuffix(cmd, "dpkg -s kubelet") {
+								return errors.New("kubelet is not installed")
+							}
+							return nil
+						})
+
+						_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).To(MatchError(ContainSubstring("step kubelet failed")))
+						Expect(commands).To(Equal([]string{"swapoff -a", "dpkg --install kubelet.deb", "dpkg -s kubelet", "dpkg --purge kubelet", "swapon -a"}))
+
+						updatedByoHost := &infrastructurev1beta1.ByoHost{}
+						Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+						Expect(updatedByoHost.Status.Installation).NotTo(BeNil())
+						Expect(updatedByoHost.Status.Installation.Steps).To(HaveLen(2))
+						for _, step := range updatedByoHost.Status.Installation.Steps {
+							Expect(step.Phase).To(Equal(infrastructurev1beta1.InstallationStepRolledBack))
+						}
+						Expect(conditions.GetReason(updatedByoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded)).To(Equal(infrastructurev1beta1.K8sComponentsInstallationFailedReason))
+					})
+
+					It("should run the hooks around the installation and roll it back if a post-install hook fails", func() {
+						plan := &steps.Plan{
+							Preamble: "set -e",
+							Steps: []steps.Step{
+								{Name: "kubelet", Apply: "dpkg --install kubelet.deb", Undo: "dpkg --purge kubelet"},
+							},
+						}
+						planData, err := plan.Marshal()
+						Expect(err).NotTo(HaveOccurred())
+						hooksData, err := (&hooks.Hooks{
+							PreInstall:  []hooks.Hook{{Name: "ca-bundle", Script: "update-ca-certificates"}},
+							PostInstall: []hooks.Hook{{Name: "auditd", Script: "augenrules --load"}},
+						}).Marshal()
+						Expect(err).NotTo(HaveOccurred())
+						hooksSecret := builder.Secret(ns, "installation-hooks-secret").
+							WithKeyData("install", plan.InstallScript()).
+							WithKeyData("uninstall", plan.UninstallScript()).
+							WithKeyData(infrastructurev1beta1.InstallationStepsSecretKey, string(planData)).
+							WithKeyData(infrastructurev1beta1.InstallationHooksSecretKey, string(hooksData)).
+							Build()
+						Expect(k8sClient.Create(ctx, hooksSecret)).NotTo(HaveOccurred())
+						byoHost.Spec.InstallationSecret = &corev1.ObjectReference{
+							Kind:      "Secret",
+							Namespace: hooksSecret.Namespace,
+							Name:      hooksSecret.Name,
+						}
+						Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+
+						var commands []string
+						fakeCommandRunner.RunCmdCalls(func(_ context.Context, cmd string) error {
+							commands = append(commands, strings.TrimPrefix(cmd, "set -e\n"))
+							if cmd == "augenrules --load" {
+								return errors.New("invalid audit rules")
+							}
+							return nil
+						})
+
+						_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).To(MatchError(ContainSubstring("post-install hook auditd failed")))
+						Expect(commands).To(Equal([]string{"update-ca-certificates", "dpkg --install kubelet.deb", "augenrules --load", "dpkg --purge kubelet"}))
+
+						updatedByoHost := &infrastructurev1beta1.ByoHost{}
+						Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+						Expect(conditions.GetReason(updatedByoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded)).To(Equal(infrastructurev1beta1.K8sComponentsInstallationFailedReason))
+						Expect(eventutils.CollectEvents(recorder.Events)).Should(ConsistOf([]string{
+							"Normal HookSucceeded pre-install hook ca-bundle succeeded",
+							"Warning HookFailed post-install hook auditd failed",
+						}))
+					})
+
+					It("should remove the incompletely downloaded bundle if install script execution failed", func() {
+						downloadPath := GinkgoT().TempDir()
+						bundleAddr := "registry.local/byoh/byoh-bundle:v1.32.0"
+						hostReconciler.DownloadPath = downloadPath
+						hostReconciler.BundleCache = bundlecache.New(downloadPath, time.Hour, 0)
+
+						cacheSecret := builder.Secret(ns, "partial-bundle-secret").
+							WithKeyData("install", "install").
+							WithKeyData(infrastructurev1beta1.BundleAddrSecretKey, bundleAddr).
+							Build()
+						Expect(k8sClient.Create(ctx, cacheSecret)).NotTo(HaveOccurred())
+						byoHost.Spec.InstallationSecret = &corev1.ObjectReference{
+							Kind:      "Secret",
+							Namespace: cacheSecret.Namespace,
+							Name:      cacheSecret.Name,
+						}
+						Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+
+						fakeCommandRunner.RunCmdCalls(func(_ context.Context, cmd string) error {
+							Expect(os.MkdirAll(filepath.Join(downloadPath, bundleAddr), 0o755)).To(Succeed())
+							return errors.New("failed to download bundle")
+						})
+
+						_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).To(HaveOccurred())
+						Expect(filepath.Join(downloadPath, bundleAddr)).NotTo(BeADirectory())
+					})
+
 					It("should return error if install script execution failed", func() {
 						fakeCommandRunner.RunCmdReturns(errors.New("failed to execute install script"))
 						invalidInstallationSecret := builder.Secret(ns, "invalid-test-secret").
@@ -548,6 +984,135 @@ runCmd:
 							"Normal BootstrapK8sNodeSucceeded k8s Node Bootstraped",
 						}))
 					})
+					Context("When the k8s version of the bootstrapped host changes", func() {
+						var (
+							previousBundle     = "registry.local/byoh/byoh-bundle:v1.22"
+							newBundle          = "registry.local/byoh/byoh-bundle:v1.23"
+							previousComponents = map[string]string{"containerd": "registry.local/byoh/containerd:v1.7.0"}
+							commands           []string
+						)
+
+						// setUpgradeSecret points the host to an installation secret regenerated for the new version
+						setUpgradeSecret := func() {
+							plan := &steps.Plan{
+								Preamble: "PREVIOUS_BUNDLE_PATH={{.BundleDownloadPath}}/{{.PreviousBundleAddr}}",
+								Steps: []steps.Step{
+									{Name: "kubeadm", Apply: "dpkg --install kubeadm.deb", Undo: "dpkg --install $PREVIOUS_BUNDLE_PATH/kubeadm.deb"},
+									{Name: "kubelet", Apply: "dpkg --install kubelet.deb", Verify: "systemctl is-active kubelet", Undo: "dpkg --install $PREVIOUS_BUNDLE_PATH/kubelet.deb"},
+								},
+							}
+							planData, err := plan.Marshal()
+							Expect(err).NotTo(HaveOccurred())
+							upgradeSecret := builder.Secret(ns, "upgrade-secret").
+								WithKeyData("install", "echo install").
+								WithKeyData("uninstall", "echo uninstall v1.23").
+								WithKeyData(infrastructurev1beta1.BundleAddrSecretKey, newBundle).
+								WithKeyData(infrastructurev1beta1.InstallationStepsSecretKey, "steps of v1.23").
+								WithKeyData(infrastructurev1beta1.InstallationUpgradeStepsSecretKey, string(planData)).
+								Build()
+							Expect(k8sClient.Create(ctx, upgradeSecret)).NotTo(HaveOccurred())
+							DeferCleanup(func() {
+								Expect(k8sClient.Delete(ctx, upgradeSecret)).NotTo(HaveOccurred())
+							})
+							byoHost.Spec.InstallationSecret = &corev1.ObjectReference{
+								Kind:      "Secret",
+								Namespace: upgradeSecret.Namespace,
+								Name:      upgradeSecret.Name,
+							}
+							Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+						}
+
+						BeforeEach(func() {
+							conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded)
+							conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)
+							byoHost.Status.Installation = &infrastructurev1beta1.InstallationStatus{K8sVersion: "1.22", Plan: "steps of v1.22"}
+							byoHost.Status.BundleCache = &infrastructurev1beta1.BundleCacheStatus{InUse: previousBundle, ComponentsInUse: previousComponents}
+							previousUninstallScript := "echo uninstall v1.22"
+							byoHost.Spec.UninstallationScript = &previousUninstallScript
+							byoHost.Annotations[infrastructurev1beta1.K8sVersionAnnotation] = "1.23"
+							Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+
+							commands = nil
+							fakeCommandRunner.RunCmdCalls(func(_ context.Context, cmd string) error {
+								Expect(cmd).To(HavePrefix("PREVIOUS_BUNDLE_PATH=" + hostReconciler.DownloadPath + "/" + previousBundle + "\n"))
+								commands = append(commands, strings.SplitN(cmd, "\n", 2)[1])
+								return nil
+							})
+						})
+
+						It("should wait for the installation secret of the new version", func() {
+							_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+								NamespacedName: byoHostLookupKey,
+							})
+							Expect(reconcilerErr).ToNot(HaveOccurred())
+							Expect(fakeCommandRunner.RunCmdCallCount()).To(BeZero())
+
+							updatedByoHost := &infrastructurev1beta1.ByoHost{}
+							Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+							Expect(conditions.GetReason(updatedByoHost, infrastructurev1beta1.K8sUpgradeSucceeded)).To(Equal(infrastructurev1beta1.K8sUpgradePendingReason))
+						})
+
+						It("should upgrade the k8s components in place", func() {
+							setUpgradeSecret()
+
+							_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+								NamespacedName: byoHostLookupKey,
+							})
+							Expect(reconcilerErr).ToNot(HaveOccurred())
+							Expect(commands).To(Equal([]string{"dpkg --install kubeadm.deb", "dpkg --install kubelet.deb", "systemctl is-active kubelet"}))
+
+							updatedByoHost := &infrastructurev1beta1.ByoHost{}
+							Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+							Expect(conditions.IsTrue(updatedByoHost, infrastructurev1beta1.K8sUpgradeSucceeded)).To(BeTrue())
+							Expect(updatedByoHost.Status.Installation.K8sVersion).To(Equal("1.23"))
+							Expect(updatedByoHost.Status.Installation.Plan).To(Equal("steps of v1.23"))
+							Expect(updatedByoHost.Status.BundleCache.InUse).To(Equal(newBundle))
+							Expect(updatedByoHost.Status.BundleCache.ComponentsInUse).To(Equal(previousComponents))
+							Expect(*updatedByoHost.Spec.UninstallationScript).To(Equal("echo uninstall v1.23"))
+							Expect(eventutils.CollectEvents(recorder.Events)).To(ContainElement("Normal K8sUpgradeSucceeded k8s components upgraded to version 1.23"))
+						})
+
+						It("should roll back to the previous bundle if the upgrade fails", func() {
+							setUpgradeSecret()
+							fakeCommandRunner.RunCmdCalls(func(_ context.Context, cmd string) error {
+								commands = append(commands, strings.SplitN(cmd, "\n", 2)[1])
+								if strings.HasSuffix(cmd, "systemctl is-active kubelet") {
+									return errors.New("kubelet is not running")
+								}
+								return nil
+							})
+
+							_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+								NamespacedName: byoHostLookupKey,
+							})
+							Expect(reconcilerErr).NotTo(HaveOccurred())
+							Expect(commands[len(commands)-2:]).To(Equal([]string{
+								"dpkg --install $PREVIOUS_BUNDLE_PATH/kubelet.deb",
+								"dpkg --install $PREVIOUS_BUNDLE_PATH/kubeadm.deb",
+							}))
+
+							updatedByoHost := &infrastructurev1beta1.ByoHost{}
+							Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+							Expect(conditions.GetReason(updatedByoHost, infrastructurev1beta1.K8sUpgradeSucceeded)).To(Equal(infrastructurev1beta1.K8sUpgradeFailedReason))
+							Expect(conditions.GetMessage(updatedByoHost, infrastructurev1beta1.K8sUpgradeSucceeded)).To(ContainSubstring("step kubelet failed"))
+							Expect(updatedByoHost.Status.Installation.K8sVersion).To(Equal("1.22"))
+							Expect(updatedByoHost.Status.Installation.FailedUpgradeK8sVersion).To(Equal("1.23"))
+							Expect(updatedByoHost.Status.BundleCache.InUse).To(Equal(previousBundle))
+							Expect(updatedByoHost.Status.BundleCache.ComponentsInUse).To(Equal(previousComponents))
+							// the host is still uninstalled with the plan and the script of the previous version
+							Expect(updatedByoHost.Status.Installation.Plan).To(Equal("steps of v1.22"))
+							Expect(*updatedByoHost.Spec.UninstallationScript).To(Equal("echo uninstall v1.22"))
+
+							// the failed upgrade is not retried until the k8s version changes
+							runCount := fakeCommandRunner.RunCmdCallCount()
+							_, reconcilerErr = hostReconciler.Reconcile(ctx, controllerruntime.Request{
+								NamespacedName: byoHostLookupKey,
+							})
+							Expect(reconcilerErr).NotTo(HaveOccurred())
+							Expect(fakeCommandRunner.RunCmdCallCount()).To(Equal(runCount))
+						})
+					})
+
 					AfterEach(func() {
 						Expect(k8sClient.Delete(ctx, installationSecret)).NotTo(HaveOccurred())
 					})
@@ -617,6 +1182,121 @@ runCmd:
 				Expect(fakeCommandRunner.RunCmdCallCount()).To(Equal(0))
 			})
 
+			It("should only undo the installation steps applied on the host", func() {
+				plan := &steps.Plan{
+					Steps: []steps.Step{
+						{Name: "disable-firewall", When: "command -v ufw", Apply: "ufw disable", Undo: "ufw enable"},
+						{Name: "kubelet", Apply: "dpkg --install kubelet.deb", Undo: "dpkg --purge kubelet"},
+					},
+				}
+				planData, err := plan.Marshal()
+				Expect(err).NotTo(HaveOccurred())
+				stepsSecret := builder.Secret(ns, "cleanup-steps-secret").
+					WithKeyData("uninstall", plan.UninstallScript()).
+					WithKeyData(infrastructurev1beta1.InstallationStepsSecretKey, string(planData)).
+					Build()
+				Expect(k8sClient.Create(ctx, stepsSecret)).NotTo(HaveOccurred())
+				byoHost.Spec.InstallationSecret = &corev1.ObjectReference{
+					Kind:      "Secret",
+					Namespace: stepsSecret.Namespace,
+					Name:      stepsSecret.Name,
+				}
+				byoHost.Status.Installation = &infrastructurev1beta1.InstallationStatus{
+					Steps: []infrastructurev1beta1.InstallationStepStatus{
+						{Name: "disable-firewall", Phase: infrastructurev1beta1.InstallationStepSkipped, LastTransitionTime: metav1.Now()},
+						{Name: "kubelet", Phase: infrastructurev1beta1.InstallationStepApplied, LastTransitionTime: metav1.Now()},
+					},
+				}
+				Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+
+				_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+					NamespacedName: byoHostLookupKey,
+				})
+				Expect(reconcilerErr).ToNot(HaveOccurred())
+
+				// 0-2: CleanupKubelet, 3: kubeadm reset, 4: undo kubelet
+				Expect(fakeCommandRunner.RunCmdCallCount()).To(Equal(5))
+				_, undoCmd := fakeCommandRunner.RunCmdArgsForCall(4)
+				Expect(undoCmd).To(HaveSuffix("dpkg --purge kubelet"))
+
+				updatedByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+				Expect(updatedByoHost.Status.Installation).To(BeNil())
+			})
+
+			It("should restore the host state captured before installation", func() {
+				root := GinkgoT().TempDir()
+				Expect(os.MkdirAll(filepath.Join(root, "proc"), 0o755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(root, "proc", "swaps"), []byte("Filename Type Size Used Priority\n"), 0o600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(root, "proc", "modules"), nil, 0o600)).To(Succeed())
+				snapshot, err := json.Marshal(&hoststate.State{SwapActive: true, SysctlFiles: map[string]string{}})
+				Expect(err).NotTo(HaveOccurred())
+				snapshotPath := filepath.Join(root, hoststate.SnapshotFileName)
+				Expect(os.WriteFile(snapshotPath, snapshot, 0o600)).To(Succeed())
+				hostReconciler.HostState = &hoststate.Snapshotter{
+					Path:   snapshotPath,
+					Root:   root,
+					Runner: fakeCommandRunner,
+					Output: func(context.Context, string) ([]byte, error) { return nil, nil },
+				}
+				byoHost.Spec.UninstallationScript = &uninstallScript
+				Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+
+				_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+					NamespacedName: byoHostLookupKey,
+				})
+				Expect(reconcilerErr).ToNot(HaveOccurred())
+
+				// 0-2: CleanupKubelet, 3: kubeadm reset, 4: uninstall script, 5: enable swap
+				Expect(fakeCommandRunner.RunCmdCallCount()).To(Equal(6))
+				_, restoreCmd := fakeCommandRunner.RunCmdArgsForCall(5)
+				Expect(restoreCmd).To(Equal("swapon -a"))
+				Expect(snapshotPath).NotTo(BeAnExistingFile())
+
+				events := eventutils.CollectEvents(recorder.Events)
+				Expect(events).Should(ContainElement("Normal HostStateRestored restored host state: enabled swap"))
+
+				updatedByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+				Expect(conditions.IsTrue(updatedByoHost, infrastructurev1beta1.HostStateRestored)).To(BeTrue())
+			})
+
+			It("should report the host state it fails to restore", func() {
+				root := GinkgoT().TempDir()
+				Expect(os.MkdirAll(filepath.Join(root, "proc"), 0o755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(root, "proc", "swaps"), []byte("Filename Type Size Used Priority\n"), 0o600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(root, "proc", "modules"), nil, 0o600)).To(Succeed())
+				snapshot, err := json.Marshal(&hoststate.State{SwapActive: true, SysctlFiles: map[string]string{}})
+				Expect(err).NotTo(HaveOccurred())
+				snapshotPath := filepath.Join(root, hoststate.SnapshotFileName)
+				Expect(os.WriteFile(snapshotPath, snapshot, 0o600)).To(Succeed())
+				hostReconciler.HostState = &hoststate.Snapshotter{
+					Path:   snapshotPath,
+					Root:   root,
+					Runner: fakeCommandRunner,
+					Output: func(context.Context, string) ([]byte, error) { return nil, nil },
+				}
+				byoHost.Spec.UninstallationScript = &uninstallScript
+				Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+				// 0-2: CleanupKubelet, 3: kubeadm reset, 4: uninstall script, 5: enable swap
+				fakeCommandRunner.RunCmdReturnsOnCall(5, errors.New("swapon failed"))
+
+				_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+					NamespacedName: byoHostLookupKey,
+				})
+				Expect(reconcilerErr).To(MatchError(ContainSubstring("swapon failed")))
+				// the snapshot is kept to retry the restore
+				Expect(snapshotPath).To(BeAnExistingFile())
+
+				updatedByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+				hostStateRestored := conditions.Get(updatedByoHost, infrastructurev1beta1.HostStateRestored)
+				Expect(hostStateRestored).NotTo(BeNil())
+				Expect(hostStateRestored.Status).To(Equal(corev1.ConditionFalse))
+				Expect(hostStateRestored.Reason).To(Equal(infrastructurev1beta1.HostStateRestoreFailedReason))
+				Expect(hostStateRestored.Message).To(ContainSubstring("swapon failed"))
+			})
+
 			It("should reset the node and set the Reason to K8sNodeAbsentReason", func() {
 				byoHost.Spec.UninstallationScript = &uninstallScript
 				Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
@@ -0,0 +1,214 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package registration
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
+	"github.com/go-logr/logr"
+	certv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/util/certificate/csr"
+	"k8s.io/client-go/util/connrotation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CertificateRenewalCheckInterval defines how often the expiry of the host certificate is checked
+var CertificateRenewalCheckInterval = 10 * time.Minute
+
+// CertificateRenewer renews the client certificate of the host before it expires, with a CSR
+// authenticated by the current certificate. The renewed certificate replaces the kubeconfig and is
+// presented by the clients created from Config without restarting the agent.
+type CertificateRenewer struct {
+	// K8sClient reports the certificate expiry in the ByoHost status
+	K8sClient client.Client
+	// Namespace of the ByoHost
+	Namespace string
+
+	logger         logr.Logger
+	hostName       string
+	configPath     string
+	credentialsDir string
+	expiryDuration time.Duration
+	// baseConfig is the loaded kubeconfig, the renewed kubeconfig keeps its server and CA
+	baseConfig *restclient.Config
+	config     *restclient.Config
+	dialer     *connrotation.Dialer
+	// hostClient requests the renewed certificate with the current one
+	hostClient clientset.Interface
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// NewCertificateRenewer returns a CertificateRenewer of the client certificate of the config
+func NewCertificateRenewer(config *restclient.Config, logger logr.Logger, hostName string, expiryDurationInSeconds int64) (*CertificateRenewer, error) {
+	// the certificate is loaded once from the credentials files, then presented from memory
+	config = restclient.CopyConfig(config)
+	if err := restclient.LoadTLSFiles(config); err != nil {
+		return nil, fmt.Errorf("invalid client certificate: %v", err)
+	}
+	config.CertFile, config.KeyFile = "", ""
+	cert, err := tls.X509KeyPair(config.CertData, config.KeyData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client certificate: %v", err)
+	}
+	r := &CertificateRenewer{
+		logger:         logger,
+		hostName:       hostName,
+		configPath:     GetBYOHConfigPath(),
+		credentialsDir: GetCredentialsDir(),
+		expiryDuration: time.Duration(expiryDurationInSeconds) * time.Second,
+		baseConfig:     config,
+		cert:           &cert,
+	}
+
+	tlsConfig, err := restclient.TLSConfigFor(config)
+	if err != nil {
+		return nil, err
+	}
+	// present the current certificate in each TLS handshake
+	tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		return r.cert, nil
+	}
+	proxy := http.ProxyFromEnvironment
+	if config.Proxy != nil {
+		proxy = config.Proxy
+	}
+	r.dialer = connrotation.NewDialer((&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext)
+	r.config = restclient.CopyConfig(config)
+	r.config.Transport = utilnet.SetTransportDefaults(&http.Transport{
+		Proxy:               proxy,
+		TLSHandshakeTimeout: 10 * time.Second,
+		TLSClientConfig:     tlsConfig,
+		MaxIdleConnsPerHost: 25,
+		DialContext:         r.dialer.DialContext,
+	})
+	// the transport enforces the TLS options
+	r.config.TLSClientConfig = restclient.TLSClientConfig{}
+	if r.hostClient, err = clientset.NewForConfig(r.config); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Config returns the config of the clients presenting the current certificate of the host
+func (r *CertificateRenewer) Config() *restclient.Config {
+	return r.config
+}
+
+// NotAfter returns the expiry of the current certificate of the host
+func (r *CertificateRenewer) NotAfter() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert.Leaf.NotAfter
+}
+
+// RenewalDue returns true once less than 20% of the lifetime of the current certificate is left
+// https://github.com/kubernetes-sigs/cluster-api/blob/main/docs/proposals/20210222-kubelet-authentication.md#kubelet-authenticator-flow
+func (r *CertificateRenewer) RenewalDue(now time.Time) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	lifetime := r.cert.Leaf.NotAfter.Sub(r.cert.Leaf.NotBefore)
+	return now.After(r.cert.Leaf.NotAfter.Add(lifetime / -5))
+}
+
+// Start reports the certificate expiry and renews the certificate when due until the context is done
+func (r *CertificateRenewer) Start(ctx context.Context) error {
+	r.reportExpiry(ctx)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if !r.RenewalDue(time.Now()) {
+			r.logger.V(1).Info("certificate is valid", "expiry", r.NotAfter())
+			return
+		}
+		r.logger.Info("certificate expiration time left is less than 20%, renewing", "expiry", r.NotAfter())
+		if err := r.Renew(ctx); err != nil {
+			r.logger.Error(err, "certificate renewal failed")
+			return
+		}
+		r.reportExpiry(ctx)
+	}, CertificateRenewalCheckInterval)
+	return nil
+}
+
+// Renew requests a new certificate with a new private key, authenticated by the current certificate,
+// replaces the kubeconfig and closes the connections authenticated by the current certificate
+func (r *CertificateRenewer) Renew(ctx context.Context) error {
+	keyData, privateKey, err := generatePrivateKey()
+	if err != nil {
+		return err
+	}
+	// the renewed certificate keeps the bindings of the host
+	r.mu.RLock()
+	organizations := r.cert.Leaf.Subject.Organization
+	r.mu.RUnlock()
+	csrData, err := generateCSR(r.hostName, organizations, privateKey)
+	if err != nil {
+		return fmt.Errorf("error generating csr %s, err=%v", r.hostName, err)
+	}
+	certTimeToExpire := r.expiryDuration
+	reqName, reqUID, err := csr.RequestCertificate(r.hostClient,
+		csrData,
+		fmt.Sprintf(ByohCSRNameFormat, r.hostName, utilrand.String(5)),
+		certv1.KubeAPIServerClientSignerName,
+		&certTimeToExpire,
+		[]certv1.KeyUsage{certv1.UsageClientAuth},
+		privateKey)
+	if err != nil {
+		return err
+	}
+	r.logger.Info("waiting for renewed client certificate to be issued", "csr", reqName)
+	ctx, cancel := context.WithTimeout(ctx, CSRApprovalTimeout)
+	defer cancel()
+	certData, err := csr.WaitForCertificate(ctx, r.hostClient, reqName, reqUID)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.X509KeyPair(certData, keyData)
+	if err != nil {
+		return fmt.Errorf("invalid renewed client certificate: %v", err)
+	}
+	if err = writeKubeconfigFromBootstrapping(r.baseConfig, r.configPath, r.credentialsDir, certData, keyData); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.mu.Unlock()
+	// reconnect with the renewed certificate
+	r.dialer.CloseAll()
+	r.logger.Info("client certificate renewed", "expiry", cert.Leaf.NotAfter, "path", r.configPath)
+	return nil
+}
+
+// reportExpiry reports the expiry of the current certificate in the ByoHost status
+func (r *CertificateRenewer) reportExpiry(ctx context.Context) {
+	if r.K8sClient == nil {
+		return
+	}
+	byoHost := &infrastructurev1beta1.ByoHost{}
+	if err := r.K8sClient.Get(ctx, client.ObjectKey{Namespace: r.Namespace, Name: r.hostName}, byoHost); err != nil {
+		r.logger.Error(err, "error getting ByoHost to report the certificate expiry")
+		return
+	}
+	original := byoHost.DeepCopy()
+	byoHost.Status.CertificateExpiry = &metav1.Time{Time: r.NotAfter()}
+	if err := r.K8sClient.Status().Patch(ctx, byoHost, client.MergeFrom(original)); err != nil {
+		r.logger.Error(err, "error reporting the certificate expiry")
+	}
+}
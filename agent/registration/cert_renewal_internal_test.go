@@ -0,0 +1,164 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package registration
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	certv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/util/cert"
+)
+
+// signCertificate returns the PEM encoded client certificate of the public key, valid from notBefore
+// to notAfter and signed by the CA
+func signCertificate(subject pkix.Name, publicKey any, notBefore, notAfter time.Time, ca *x509.Certificate, caKey crypto.Signer) []byte {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      subject,
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, ca, publicKey, caKey)
+	Expect(err).NotTo(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: cert.CertificateBlockType, Bytes: certDER})
+}
+
+var _ = Describe("CertificateRenewer", func() {
+	var (
+		hostName   = "renewal-host"
+		notBefore  = time.Now().Add(-time.Hour).Truncate(time.Second)
+		notAfter   = notBefore.Add(100 * time.Hour)
+		ca         *x509.Certificate
+		caKey      crypto.Signer
+		fakeClient *kfake.Clientset
+		renewer    *CertificateRenewer
+		configDir  string
+	)
+
+	BeforeEach(func() {
+		var err error
+		_, caKey, err = generatePrivateKey()
+		Expect(err).NotTo(HaveOccurred())
+		ca, err = cert.NewSelfSignedCACert(cert.Config{CommonName: "test-ca"}, caKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		keyData, privateKey, err := generatePrivateKey()
+		Expect(err).NotTo(HaveOccurred())
+		subject := pkix.Name{CommonName: "byoh:host:" + hostName, Organization: []string{ByohCSROrg, "byoh:namespace:default"}}
+		certData := signCertificate(subject, privateKey.Public(), notBefore, notAfter, ca, caKey)
+
+		config := &restclient.Config{
+			Host: "https://127.0.0.1:6443",
+			TLSClientConfig: restclient.TLSClientConfig{
+				CAData:   pem.EncodeToMemory(&pem.Block{Type: cert.CertificateBlockType, Bytes: ca.Raw}),
+				CertData: certData,
+				KeyData:  keyData,
+			},
+		}
+		renewer, err = NewCertificateRenewer(config, logr.Discard(), hostName, ExpirationSeconds)
+		Expect(err).NotTo(HaveOccurred())
+
+		configDir, err = os.MkdirTemp("", "renewal")
+		Expect(err).NotTo(HaveOccurred())
+		renewer.configPath = filepath.Join(configDir, "config")
+		renewer.credentialsDir = filepath.Join(configDir, "pki")
+		fakeClient = kfake.NewSimpleClientset()
+		renewer.hostClient = fakeClient
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(configDir)).To(Succeed())
+	})
+
+	It("should be due at 80% of the certificate lifetime", func() {
+		Expect(renewer.NotAfter()).To(BeTemporally("==", notAfter))
+		Expect(renewer.RenewalDue(notBefore.Add(79 * time.Hour))).To(BeFalse())
+		Expect(renewer.RenewalDue(notBefore.Add(80 * time.Hour))).To(BeFalse())
+		Expect(renewer.RenewalDue(notBefore.Add(80*time.Hour + time.Second))).To(BeTrue())
+		Expect(renewer.RenewalDue(notAfter.Add(time.Hour))).To(BeTrue())
+	})
+
+	Context("When the renewed certificate is issued", func() {
+		var renewedCertData []byte
+
+		BeforeEach(func() {
+			ctx, cancel := context.WithTimeout(context.TODO(), 30*time.Second)
+			defer cancel()
+			renewErr := make(chan error, 1)
+			go func() {
+				renewErr <- renewer.Renew(ctx)
+			}()
+
+			Eventually(func() []certv1.CertificateSigningRequest {
+				csrList, err := fakeClient.CertificatesV1().CertificateSigningRequests().List(ctx, metav1.ListOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				return csrList.Items
+			}).Should(HaveLen(1))
+			csrList, err := fakeClient.CertificatesV1().CertificateSigningRequests().List(ctx, metav1.ListOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			csr := csrList.Items[0]
+			Expect(csr.Spec.ExpirationSeconds).NotTo(BeNil())
+			Expect(*csr.Spec.ExpirationSeconds).To(BeEquivalentTo(ExpirationSeconds))
+
+			block, _ := pem.Decode(csr.Spec.Request)
+			Expect(block).NotTo(BeNil())
+			request, err := x509.ParseCertificateRequest(block.Bytes)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(request.Subject.Organization).To(ConsistOf(ByohCSROrg, "byoh:namespace:default"))
+
+			renewedCertData = signCertificate(request.Subject, request.PublicKey, time.Now().Truncate(time.Second), time.Now().Add(200*time.Hour).Truncate(time.Second), ca, caKey)
+			csr.Status.Conditions = []certv1.CertificateSigningRequestCondition{{
+				Type:   certv1.CertificateApproved,
+				Status: corev1.ConditionTrue,
+			}}
+			csr.Status.Certificate = renewedCertData
+			_, err = fakeClient.CertificatesV1().CertificateSigningRequests().UpdateStatus(ctx, &csr, metav1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(renewErr, 10*time.Second).Should(Receive(BeNil()))
+		})
+
+		It("should swap in the renewed certificate", func() {
+			block, _ := pem.Decode(renewedCertData)
+			renewedCert, err := x509.ParseCertificate(block.Bytes)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(renewer.NotAfter()).To(BeTemporally("==", renewedCert.NotAfter))
+			transport, ok := renewer.Config().Transport.(*http.Transport)
+			Expect(ok).To(BeTrue())
+			presented, err := transport.TLSClientConfig.GetClientCertificate(&tls.CertificateRequestInfo{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(presented.Certificate[0]).To(Equal(renewedCert.Raw))
+		})
+
+		It("should rewrite the kubeconfig with the renewed certificate", func() {
+			config, err := LoadRESTClientConfig(renewer.configPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Host).To(Equal("https://127.0.0.1:6443"))
+			Expect(config.CertFile).To(Equal(filepath.Join(renewer.credentialsDir, CurrentCredentialsFile)))
+			Expect(restclient.LoadTLSFiles(config)).To(Succeed())
+			Expect(config.CertData).To(ContainSubstring(string(renewedCertData)))
+		})
+	})
+})
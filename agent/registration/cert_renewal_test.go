@@ -0,0 +1,115 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package registration_test
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"time"
+
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/registration"
+	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Certificate renewal", func() {
+	var (
+		hostName    = "renewal-host"
+		certRenewer *registration.CertificateRenewer
+		notAfter    time.Time
+		err         error
+	)
+
+	BeforeEach(func() {
+		certRenewer, err = registration.NewCertificateRenewer(cfg, logr.Discard(), hostName, registration.ExpirationSeconds)
+		Expect(err).NotTo(HaveOccurred())
+
+		block, _ := pem.Decode(cfg.CertData)
+		Expect(block).NotTo(BeNil())
+		cert, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).NotTo(HaveOccurred())
+		notAfter = cert.NotAfter
+	})
+
+	It("should fail without a valid client certificate", func() {
+		invalidConfig := rest.CopyConfig(cfg)
+		invalidConfig.CertData = []byte("invalid")
+		_, err = registration.NewCertificateRenewer(invalidConfig, logr.Discard(), hostName, registration.ExpirationSeconds)
+		Expect(err).To(MatchError(ContainSubstring("invalid client certificate")))
+	})
+
+	It("should present the client certificate in the transport of its config", func() {
+		renewerConfig := certRenewer.Config()
+		Expect(renewerConfig.CertData).To(BeEmpty())
+		Expect(renewerConfig.Transport).NotTo(BeNil())
+
+		hostClient, err := clientset.NewForConfig(renewerConfig)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = hostClient.CertificatesV1().CertificateSigningRequests().List(context.TODO(), metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should be due once less than 20% of the certificate lifetime is left", func() {
+		Expect(certRenewer.NotAfter()).To(BeTemporally("==", notAfter))
+		Expect(certRenewer.RenewalDue(notAfter.Add(-time.Hour * 24 * 365 * 10))).To(BeFalse())
+		Expect(certRenewer.RenewalDue(notAfter.Add(-time.Second))).To(BeTrue())
+	})
+
+	It("should request the renewed certificate with the current certificate", func() {
+		ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+		defer cancel()
+		go func() {
+			defer GinkgoRecover()
+			Expect(certRenewer.Renew(ctx)).NotTo(Succeed())
+		}()
+
+		hostClient, err := clientset.NewForConfig(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(func() bool {
+			csrList, err := hostClient.CertificatesV1().CertificateSigningRequests().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return false
+			}
+			for _, csr := range csrList.Items {
+				if strings.HasPrefix(csr.Name, "byoh-csr-"+hostName+"-") {
+					return csr.Spec.Username != "" && !strings.HasPrefix(csr.Spec.Username, "system:bootstrap:")
+				}
+			}
+			return false
+		}).Should(BeTrue())
+	})
+
+	It("should report the certificate expiry in the ByoHost status", func() {
+		byoHost := &infrastructurev1beta1.ByoHost{
+			ObjectMeta: metav1.ObjectMeta{Name: hostName, Namespace: "default"},
+		}
+		Expect(k8sClient.Create(context.TODO(), byoHost)).Should(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(context.TODO(), byoHost)).Should(Succeed())
+		}()
+
+		certRenewer.K8sClient = k8sClient
+		certRenewer.Namespace = "default"
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+		go func() {
+			defer GinkgoRecover()
+			Expect(certRenewer.Start(ctx)).Should(Succeed())
+		}()
+
+		Eventually(func() *metav1.Time {
+			Expect(k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(byoHost), byoHost)).Should(Succeed())
+			return byoHost.Status.CertificateExpiry
+		}).ShouldNot(BeNil())
+		Expect(byoHost.Status.CertificateExpiry.Time).To(BeTemporally("~", notAfter, time.Second))
+	})
+})
@@ -5,7 +5,11 @@ package registration
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
@@ -14,9 +18,11 @@ import (
 	"path/filepath"
 	"time"
 
+	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
 	"github.com/go-logr/logr"
 	certv1 "k8s.io/api/certificates/v1"
 	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
 	clientset "k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -33,25 +39,51 @@ const (
 	ExpirationSeconds = 86400 * 365
 	ByohCSROrg        = "byoh:hosts"
 	ByohCSRCNFormat   = "byoh:host:%s"
-	ByohCSRNameFormat = "byoh-csr-%s"
-	TmpPrivateKey     = "byoh-client.key.tmp"
+	// ByohCSRNameFormat is the name of the CSRs of a host, suffixed so that each request
+	// creates a new CSR for its new private key
+	ByohCSRNameFormat = "byoh-csr-%s-%s"
 	DefaultConfigPath = ".byoh/config"
+	// CurrentCredentialsFile is the link to the file of the current certificate and private key
+	// of the host, referenced by the kubeconfig
+	CurrentCredentialsFile = "byoh-client-current.pem"
+)
+
+// KeyAlgorithm is the algorithm of the private keys of the host certificates
+type KeyAlgorithm string
+
+const (
+	// KeyAlgorithmECDSA generates ECDSA P-256 private keys
+	KeyAlgorithmECDSA KeyAlgorithm = "ecdsa"
+	// KeyAlgorithmRSA generates RSA private keys of KeySize bits
+	KeyAlgorithmRSA KeyAlgorithm = "rsa"
 )
 
 var (
 	ConfigPath string
+	// CredentialsDir is the directory of the certificates and private keys of the host,
+	// the pki directory next to the kubeconfig if not set
+	CredentialsDir string
+	// PrivateKeyAlgorithm is the algorithm of the private keys generated for the host certificates
+	PrivateKeyAlgorithm = KeyAlgorithmECDSA
 	// CSRApprovalTimeout defines the time to wait for certificate to
 	// be issued. Currently set to 1 hour.
 	CSRApprovalTimeout = 3600 * time.Second
 )
 
 type ByohCSR struct {
+	// Namespace and Labels of the ByoHost, the host certificate is requested bound to them
+	Namespace string
+	Labels    map[string]string
+
 	bootstrapClient       clientset.Interface
 	bootstrapClientConfig *restclient.Config
 	logger                logr.Logger
 	configPath            string
-	PrivateKey            []byte
-	expiryDuration        time.Duration
+	credentialsDir        string
+	// PrivateKey is the private key of the requested certificate, it is only kept in memory
+	// until the certificate is issued
+	PrivateKey     []byte
+	expiryDuration time.Duration
 }
 
 // NewByohCSR returns a ByohCSR instance
@@ -64,6 +96,7 @@ func NewByohCSR(bootstrapClientConfig *restclient.Config, logger logr.Logger, ex
 		bootstrapClientConfig: bootstrapClientConfig,
 		bootstrapClient:       bootstrapClient,
 		configPath:            GetBYOHConfigPath(),
+		credentialsDir:        GetCredentialsDir(),
 		logger:                logger,
 		expiryDuration:        time.Duration(expiryDurationInSeconds) * time.Second,
 	}, nil
@@ -86,33 +119,27 @@ func (bcsr *ByohCSR) BootstrapKubeconfig(hostName string) error {
 	if err != nil {
 		return err
 	}
-	err = writeKubeconfigFromBootstrapping(bcsr.bootstrapClientConfig, bcsr.configPath, certData, bcsr.PrivateKey)
+	err = writeKubeconfigFromBootstrapping(bcsr.bootstrapClientConfig, bcsr.configPath, bcsr.credentialsDir, certData, bcsr.PrivateKey)
 	if err != nil {
 		return err
 	}
 	bcsr.logger.Info("kubeconfig created", "path", bcsr.configPath)
-	if err := os.Remove(TmpPrivateKey); err != nil && !os.IsNotExist(err) {
-		bcsr.logger.Error(err, "Failed cleaning up private key file")
-	}
 	return nil
 }
 
-// RequestBYOHClientCert will generate Private Key and then will create a
+// RequestBYOHClientCert will generate Private Key in memory and then will create a
 // CertificateSigningRequest in K8s
 func (bcsr *ByohCSR) RequestBYOHClientCert(hostname string) (string, types.UID, error) {
 	if hostname == "" {
 		return "", "", fmt.Errorf("hostname is not valid")
 	}
-	keyData, _, err := keyutil.LoadOrGenerateKeyFile(TmpPrivateKey)
+	keyData, privateKey, err := generatePrivateKey()
 	if err != nil {
 		return "", "", err
 	}
-	privateKey, err := keyutil.ParsePrivateKeyPEM(keyData)
-	if err != nil {
-		return "", "", fmt.Errorf("invalid private key for certificate request: %v", err)
-	}
 	bcsr.PrivateKey = keyData
-	csrData, err := generateCSR(hostname, privateKey)
+	organizations := append([]string{ByohCSROrg}, infrastructurev1beta1.HostBindingGroups(bcsr.Namespace, bcsr.Labels)...)
+	csrData, err := generateCSR(hostname, organizations, privateKey)
 	if err != nil {
 		return "", "", fmt.Errorf("error generating csr %s, err=%v", hostname, err)
 	}
@@ -120,7 +147,7 @@ func (bcsr *ByohCSR) RequestBYOHClientCert(hostname string) (string, types.UID,
 	bcsr.logger.Info("certTimeToExpire", "duration", certTimeToExpire)
 	reqName, reqUID, err := csr.RequestCertificate(bcsr.bootstrapClient,
 		csrData,
-		fmt.Sprintf(ByohCSRNameFormat, hostname),
+		fmt.Sprintf(ByohCSRNameFormat, hostname, utilrand.String(5)),
 		certv1.KubeAPIServerClientSignerName,
 		&certTimeToExpire,
 		[]certv1.KeyUsage{certv1.UsageClientAuth},
@@ -132,12 +159,38 @@ func (bcsr *ByohCSR) RequestBYOHClientCert(hostname string) (string, types.UID,
 	return reqName, reqUID, nil
 }
 
-func generateCSR(hostname string, privKey any) ([]byte, error) {
+// generatePrivateKey returns a new private key of the PrivateKeyAlgorithm and its PEM encoding
+func generatePrivateKey() ([]byte, crypto.Signer, error) {
+	switch PrivateKeyAlgorithm {
+	case KeyAlgorithmECDSA:
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		der, err := x509.MarshalECPrivateKey(privateKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: keyutil.ECPrivateKeyBlockType, Bytes: der}), privateKey, nil
+	case KeyAlgorithmRSA:
+		privateKey, err := rsa.GenerateKey(rand.Reader, KeySize)
+		if err != nil {
+			return nil, nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: keyutil.RSAPrivateKeyBlockType, Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}), privateKey, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported private key algorithm %q", PrivateKeyAlgorithm)
+	}
+}
+
+// generateCSR returns a PEM encoded request of a certificate of the host in the organizations,
+// ByohCSROrg followed by the bindings of the host
+func generateCSR(hostname string, organizations []string, privKey any) ([]byte, error) {
 	// Generate a new *x509.CertificateRequest template
 	csrTemplate := x509.CertificateRequest{
 		Subject: pkix.Name{
 			CommonName:   fmt.Sprintf(ByohCSRCNFormat, hostname),
-			Organization: []string{ByohCSROrg},
+			Organization: organizations,
 		},
 	}
 	// Generate the CSR bytes
@@ -171,8 +224,13 @@ func LoadRESTClientConfig(kubeconfigPath string) (*restclient.Config, error) {
 }
 
 // writeKubeconfigFromBootstrapping will write the new kubeconfig fetching
-// some details from bootstrap client config and using key/cert details
-func writeKubeconfigFromBootstrapping(bootstrapClientConfig *restclient.Config, kubeconfigPath string, certData, keyData []byte) error {
+// some details from bootstrap client config and referencing the key/cert written in the credentials directory
+func writeKubeconfigFromBootstrapping(bootstrapClientConfig *restclient.Config, kubeconfigPath, credentialsDir string, certData, keyData []byte) error {
+	credentialsPath, err := writeClientCredentials(credentialsDir, certData, keyData)
+	if err != nil {
+		return err
+	}
+
 	// Get the CA data from the bootstrap client config.
 	caFile, caData := bootstrapClientConfig.CAFile, []byte{}
 	if caFile == "" {
@@ -188,10 +246,10 @@ func writeKubeconfigFromBootstrapping(bootstrapClientConfig *restclient.Config,
 			CertificateAuthority:     caFile,
 			CertificateAuthorityData: caData,
 		}},
-		// Define auth based on the obtained client cert.
+		// Define auth based on the obtained client cert, the credentials file holds both the cert and the key.
 		AuthInfos: map[string]*clientcmdapi.AuthInfo{"default-auth": {
-			ClientCertificateData: certData,
-			ClientKeyData:         keyData,
+			ClientCertificate: credentialsPath,
+			ClientKey:         credentialsPath,
 		}},
 		// Define a context that connects the auth info and cluster, and set it as the default
 		Contexts: map[string]*clientcmdapi.Context{"default-context": {
@@ -202,8 +260,91 @@ func writeKubeconfigFromBootstrapping(bootstrapClientConfig *restclient.Config,
 		CurrentContext: "default-context",
 	}
 
-	// Marshal to disk
-	return clientcmd.WriteToFile(kubeconfigData, kubeconfigPath)
+	// Marshal to disk, replacing the kubeconfig atomically so that it is never read partially written
+	return writeFileAtomically(kubeconfigPath, kubeconfigData)
+}
+
+// writeFileAtomically writes the kubeconfig to a temporary file renamed to its path
+func writeFileAtomically(kubeconfigPath string, kubeconfigData clientcmdapi.Config) error {
+	content, err := clientcmd.Write(kubeconfigData)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(kubeconfigPath)
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(dir, filepath.Base(kubeconfigPath)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err = tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), kubeconfigPath)
+}
+
+// writeClientCredentials writes the certificate and the private key of the host to a new file,
+// readable by its owner only, and atomically links the CurrentCredentialsFile to it.
+// It returns the path of the link, the previous credentials file is removed.
+func writeClientCredentials(credentialsDir string, certData, keyData []byte) (string, error) {
+	credentialsDir, err := filepath.Abs(credentialsDir)
+	if err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(credentialsDir, 0o700); err != nil {
+		return "", err
+	}
+	// os.CreateTemp creates the file with 0600 permissions
+	credentialsFile, err := os.CreateTemp(credentialsDir, "byoh-client-*.pem")
+	if err != nil {
+		return "", err
+	}
+	linked := false
+	defer func() {
+		if !linked {
+			os.Remove(credentialsFile.Name())
+		}
+	}()
+	if _, err = credentialsFile.Write(append(append([]byte{}, certData...), keyData...)); err != nil {
+		credentialsFile.Close()
+		return "", err
+	}
+	if err = credentialsFile.Close(); err != nil {
+		return "", err
+	}
+
+	currentPath := filepath.Join(credentialsDir, CurrentCredentialsFile)
+	previous, _ := os.Readlink(currentPath)
+	tmpLink := currentPath + ".tmp"
+	defer os.Remove(tmpLink)
+	if err = os.Remove(tmpLink); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if err = os.Symlink(filepath.Base(credentialsFile.Name()), tmpLink); err != nil {
+		return "", err
+	}
+	if err = os.Rename(tmpLink, currentPath); err != nil {
+		return "", err
+	}
+	linked = true
+	if previous != "" && previous != filepath.Base(credentialsFile.Name()) {
+		os.Remove(filepath.Join(credentialsDir, previous))
+	}
+	return currentPath, nil
+}
+
+// GetCredentialsDir returns the directory of the certificates and private keys of the host
+func GetCredentialsDir() string {
+	if CredentialsDir != "" {
+		return CredentialsDir
+	}
+	return filepath.Join(filepath.Dir(GetBYOHConfigPath()), "pki")
 }
 
 // GetBYOHConfigPath set the directory for BYOH kubeconfig
@@ -7,6 +7,7 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"os"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -16,14 +17,14 @@ var _ = Describe("Registration", func() {
 	Context("When generateCSR is called", func() {
 		hostName := "test-host"
 		It("should return error if Private Key is not valid", func() {
-			certData, err := generateCSR(hostName, &rsa.PrivateKey{})
+			certData, err := generateCSR(hostName, []string{ByohCSROrg}, &rsa.PrivateKey{})
 			Expect(err).Should(HaveOccurred())
 			Expect(certData).To(BeNil())
 		})
 		It("should return csrData with the correct arguments", func() {
 			privateKeyData, err := rsa.GenerateKey(rand.Reader, 2048)
 			Expect(err).Should(Not(HaveOccurred()))
-			certData, err := generateCSR(hostName, privateKeyData)
+			certData, err := generateCSR(hostName, []string{ByohCSROrg}, privateKeyData)
 			Expect(err).Should(Not(HaveOccurred()))
 			Expect(certData).ToNot(BeNil())
 		})
@@ -58,12 +59,31 @@ users:
 			Expect(err).ShouldNot(HaveOccurred())
 			restConfig, err := LoadRESTClientConfig(fileboot.Name())
 			Expect(err).ShouldNot(HaveOccurred())
-			err = writeKubeconfigFromBootstrapping(restConfig, filekubeconfig.Name(), []byte("cert-data"), []byte("key-data"))
+			credentialsDir := filepath.Join(fileDir, "pki")
+			err = writeKubeconfigFromBootstrapping(restConfig, filekubeconfig.Name(), credentialsDir, []byte("cert-data"), []byte("key-data"))
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(filekubeconfig.Name()).To(BeARegularFile())
 			content, err := os.ReadFile(filekubeconfig.Name())
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(content).ShouldNot(BeEmpty())
+			credentialsPath := filepath.Join(credentialsDir, CurrentCredentialsFile)
+			Expect(string(content)).To(ContainSubstring("client-key: " + credentialsPath))
+			credentials, err := os.ReadFile(credentialsPath)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(credentials)).To(Equal("cert-datakey-data"))
+
+			// the rotated credentials replace the previous file
+			previousFile, err := os.Readlink(credentialsPath)
+			Expect(err).ShouldNot(HaveOccurred())
+			err = writeKubeconfigFromBootstrapping(restConfig, filekubeconfig.Name(), credentialsDir, []byte("new-cert"), []byte("new-key"))
+			Expect(err).ShouldNot(HaveOccurred())
+			credentials, err = os.ReadFile(credentialsPath)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(credentials)).To(Equal("new-certnew-key"))
+			Expect(filepath.Join(credentialsDir, previousFile)).NotTo(BeAnExistingFile())
+			entries, err := os.ReadDir(credentialsDir)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(entries).To(HaveLen(2))
 			err = os.RemoveAll(fileDir)
 			Expect(err).ToNot(HaveOccurred())
 		})
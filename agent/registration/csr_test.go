@@ -10,10 +10,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/agent/registration"
-	"github.com/cohesity/cluster-api-provider-bringyourownhost/test/builder"
 	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -95,9 +95,11 @@ kovW9X7Ook/tTW0HyX6D6HRciA==
 			registration.ConfigPath = "config"
 			fileDir, err = os.MkdirTemp("", "bootstrap")
 			Expect(err).ShouldNot(HaveOccurred())
+			registration.CredentialsDir = filepath.Join(fileDir, "pki")
 		})
 
 		AfterEach(func() {
+			registration.CredentialsDir = ""
 			err := os.RemoveAll(fileDir)
 			Expect(err).ToNot(HaveOccurred())
 		})
@@ -130,9 +132,10 @@ kovW9X7Ook/tTW0HyX6D6HRciA==
 		It("should create csr if bootstrap kubeconfig is valid", func() {
 			CSRRegistrar, err := registration.NewByohCSR(cfg, logr.Discard(), certExpiryDuration)
 			Expect(err).ShouldNot(HaveOccurred())
-			_, _, err = CSRRegistrar.RequestBYOHClientCert(hostName)
+			reqName, _, err := CSRRegistrar.RequestBYOHClientCert(hostName)
 			Expect(err).NotTo(HaveOccurred())
-			ByohCSR, err := k8sClientSet.CertificatesV1().CertificateSigningRequests().Get(ctx, fmt.Sprintf(registration.ByohCSRNameFormat, hostName), metav1.GetOptions{})
+			Expect(reqName).To(HavePrefix("byoh-csr-" + hostName + "-"))
+			ByohCSR, err := k8sClientSet.CertificatesV1().CertificateSigningRequests().Get(ctx, reqName, metav1.GetOptions{})
 			Expect(err).ShouldNot(HaveOccurred())
 			// Validate k8s CSR resource
 			Expect(ByohCSR.Spec.SignerName).Should(Equal(certv1.KubeAPIServerClientSignerName))
@@ -145,24 +148,51 @@ kovW9X7Ook/tTW0HyX6D6HRciA==
 			Expect(err).ToNot(HaveOccurred())
 			Expect(csr.Subject.CommonName).To(Equal(fmt.Sprintf(registration.ByohCSRCNFormat, hostName)))
 			Expect(csr.Subject.Organization[0]).To(Equal("byoh:hosts"))
-
-			Expect(os.Remove(registration.TmpPrivateKey)).ShouldNot(HaveOccurred())
+			Expect(csr.PublicKeyAlgorithm).To(Equal(x509.ECDSA))
+			Expect(filepath.Join(fileDir, "pki")).NotTo(BeAnExistingFile())
+		})
+		It("should create csr with a RSA private key if requested", func() {
+			registration.PrivateKeyAlgorithm = registration.KeyAlgorithmRSA
+			defer func() {
+				registration.PrivateKeyAlgorithm = registration.KeyAlgorithmECDSA
+			}()
+			CSRRegistrar, err := registration.NewByohCSR(cfg, logr.Discard(), certExpiryDuration)
+			Expect(err).ShouldNot(HaveOccurred())
+			reqName, _, err := CSRRegistrar.RequestBYOHClientCert(hostName)
+			Expect(err).NotTo(HaveOccurred())
+			ByohCSR, err := k8sClientSet.CertificatesV1().CertificateSigningRequests().Get(ctx, reqName, metav1.GetOptions{})
+			Expect(err).ShouldNot(HaveOccurred())
+			pemData, _ := pem.Decode(ByohCSR.Spec.Request)
+			Expect(pemData).ToNot(BeNil())
+			csr, err := x509.ParseCertificateRequest(pemData.Bytes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(csr.PublicKeyAlgorithm).To(Equal(x509.RSA))
 		})
-		It("should fail creating CSR if the private key got changed", func() {
-			byohCSR, err := builder.CertificateSigningRequest(
-				fmt.Sprintf(registration.ByohCSRNameFormat, hostName),
-				fmt.Sprintf(registration.ByohCSRCNFormat, hostName),
-				"byoh:hosts", 2048).Build()
+		It("should create csr binding the host to its namespace and labels", func() {
+			CSRRegistrar, err := registration.NewByohCSR(cfg, logr.Discard(), certExpiryDuration)
+			Expect(err).ShouldNot(HaveOccurred())
+			CSRRegistrar.Namespace = "tenant-a"
+			CSRRegistrar.Labels = map[string]string{"site": "apac", "cores": "2"}
+			reqName, _, err := CSRRegistrar.RequestBYOHClientCert(hostName)
 			Expect(err).NotTo(HaveOccurred())
-			_, err = k8sClientSet.CertificatesV1().CertificateSigningRequests().Create(ctx, byohCSR, metav1.CreateOptions{})
+			ByohCSR, err := k8sClientSet.CertificatesV1().CertificateSigningRequests().Get(ctx, reqName, metav1.GetOptions{})
 			Expect(err).ShouldNot(HaveOccurred())
+			pemData, _ := pem.Decode(ByohCSR.Spec.Request)
+			Expect(pemData).ToNot(BeNil())
+			csr, err := x509.ParseCertificateRequest(pemData.Bytes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(csr.Subject.Organization).To(ConsistOf("byoh:hosts", "byoh:namespace:tenant-a", "byoh:label:cores=2", "byoh:label:site=apac"))
+		})
+		It("should create a new csr with a new private key for each request", func() {
 			CSRRegistrar, err := registration.NewByohCSR(cfg, klogr.New(), certExpiryDuration)
 			Expect(err).ShouldNot(HaveOccurred())
-			_, _, err = CSRRegistrar.RequestBYOHClientCert(hostName)
-			Expect(err).Should(HaveOccurred())
-			Expect(err.Error()).Should(ContainSubstring("retrieved csr is not compatible"))
-
-			Expect(os.Remove(registration.TmpPrivateKey)).ShouldNot(HaveOccurred())
+			firstName, _, err := CSRRegistrar.RequestBYOHClientCert(hostName)
+			Expect(err).ShouldNot(HaveOccurred())
+			firstKey := CSRRegistrar.PrivateKey
+			secondName, _, err := CSRRegistrar.RequestBYOHClientCert(hostName)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(secondName).NotTo(Equal(firstName))
+			Expect(CSRRegistrar.PrivateKey).NotTo(Equal(firstKey))
 		})
 		It("should timeout if the CSR is not approved", func() {
 			registration.CSRApprovalTimeout = time.Second * 5
@@ -171,14 +201,14 @@ kovW9X7Ook/tTW0HyX6D6HRciA==
 			err = CSRRegistrar.BootstrapKubeconfig(hostName)
 			Expect(err).Should(HaveOccurred())
 			Expect(err).To(MatchError("timed out waiting for the condition"))
-			Expect(os.Remove(registration.TmpPrivateKey)).ShouldNot(HaveOccurred())
+			Expect(filepath.Join(fileDir, "pki")).NotTo(BeAnExistingFile())
 		})
 		It("should return error if not able to write kubeconfig", func() {
 			// Simulate ByoAdmission Controller
 			go func() {
 				for {
 					time.Sleep(time.Millisecond * 100)
-					byohCSR, err := k8sClientSet.CertificatesV1().CertificateSigningRequests().Get(ctx, fmt.Sprintf(registration.ByohCSRNameFormat, hostName), metav1.GetOptions{})
+					byohCSR, err := getHostCSR(ctx, hostName)
 					if err != nil {
 						continue
 					}
@@ -188,9 +218,9 @@ kovW9X7Ook/tTW0HyX6D6HRciA==
 						Message: "approved",
 						Status:  corev1.ConditionTrue,
 					})
-					_, err = k8sClientSet.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, fmt.Sprintf(registration.ByohCSRNameFormat, hostName), byohCSR, metav1.UpdateOptions{})
+					_, err = k8sClientSet.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, byohCSR.Name, byohCSR, metav1.UpdateOptions{})
 					Expect(err).ShouldNot(HaveOccurred())
-					byohCSR, err = k8sClientSet.CertificatesV1().CertificateSigningRequests().Get(ctx, fmt.Sprintf(registration.ByohCSRNameFormat, hostName), metav1.GetOptions{})
+					byohCSR, err = k8sClientSet.CertificatesV1().CertificateSigningRequests().Get(ctx, byohCSR.Name, metav1.GetOptions{})
 					Expect(err).ShouldNot(HaveOccurred())
 					byohCSR.Status.Certificate = []byte(testCert)
 					_, err = k8sClientSet.CertificatesV1().CertificateSigningRequests().UpdateStatus(ctx, byohCSR, metav1.UpdateOptions{})
@@ -199,19 +229,19 @@ kovW9X7Ook/tTW0HyX6D6HRciA==
 				}
 			}()
 			registration.ConfigPath = "/non-existent-mount/config"
+			registration.CredentialsDir = "/non-existent-mount/pki"
 			CSRRegistrar, err := registration.NewByohCSR(cfg, klogr.New(), certExpiryDuration)
 			Expect(err).ShouldNot(HaveOccurred())
 			err = CSRRegistrar.BootstrapKubeconfig(hostName)
 			Expect(err).Should(HaveOccurred())
 			Expect(err).To(MatchError(ContainSubstring("permission denied")))
-			Expect(os.Remove(registration.TmpPrivateKey)).ShouldNot(HaveOccurred())
 		})
 		It("should create kubeconfig if csr is approved", func() {
 			// Simulate ByoAdmission Controller
 			go func() {
 				for {
 					time.Sleep(time.Millisecond * 100)
-					byohCSR, err := k8sClientSet.CertificatesV1().CertificateSigningRequests().Get(ctx, fmt.Sprintf(registration.ByohCSRNameFormat, hostName), metav1.GetOptions{})
+					byohCSR, err := getHostCSR(ctx, hostName)
 					if err != nil {
 						continue
 					}
@@ -221,9 +251,9 @@ kovW9X7Ook/tTW0HyX6D6HRciA==
 						Message: "approved",
 						Status:  corev1.ConditionTrue,
 					})
-					_, err = k8sClientSet.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, fmt.Sprintf(registration.ByohCSRNameFormat, hostName), byohCSR, metav1.UpdateOptions{})
+					_, err = k8sClientSet.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, byohCSR.Name, byohCSR, metav1.UpdateOptions{})
 					Expect(err).ShouldNot(HaveOccurred())
-					byohCSR, err = k8sClientSet.CertificatesV1().CertificateSigningRequests().Get(ctx, fmt.Sprintf(registration.ByohCSRNameFormat, hostName), metav1.GetOptions{})
+					byohCSR, err = k8sClientSet.CertificatesV1().CertificateSigningRequests().Get(ctx, byohCSR.Name, metav1.GetOptions{})
 					Expect(err).ShouldNot(HaveOccurred())
 					byohCSR.Status.Certificate = []byte(testCert)
 					_, err = k8sClientSet.CertificatesV1().CertificateSigningRequests().UpdateStatus(ctx, byohCSR, metav1.UpdateOptions{})
@@ -236,6 +266,14 @@ kovW9X7Ook/tTW0HyX6D6HRciA==
 			err = CSRRegistrar.BootstrapKubeconfig(hostName)
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(registration.ConfigPath).To(BeARegularFile())
+			content, err := os.ReadFile(registration.ConfigPath)
+			Expect(err).ShouldNot(HaveOccurred())
+			credentialsPath := filepath.Join(fileDir, "pki", registration.CurrentCredentialsFile)
+			Expect(string(content)).To(ContainSubstring("client-certificate: " + credentialsPath))
+			Expect(string(content)).NotTo(ContainSubstring("client-key-data"))
+			credentialsInfo, err := os.Stat(credentialsPath)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(credentialsInfo.Mode().Perm()).To(Equal(os.FileMode(0o600)))
 			Expect(os.Remove(registration.ConfigPath)).ShouldNot(HaveOccurred())
 		})
 	})
@@ -266,3 +304,17 @@ kovW9X7Ook/tTW0HyX6D6HRciA==
 		})
 	})
 })
+
+// getHostCSR returns the first CSR requested by the host
+func getHostCSR(ctx context.Context, hostName string) (*certv1.CertificateSigningRequest, error) {
+	csrList, err := k8sClientSet.CertificatesV1().CertificateSigningRequests().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range csrList.Items {
+		if strings.HasPrefix(csrList.Items[i].Name, fmt.Sprintf(registration.ByohCSRNameFormat, hostName, "")) {
+			return &csrList.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no csr of host %s", hostName)
+}
@@ -4,6 +4,11 @@
 package v1beta1
 
 import (
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -25,6 +30,24 @@ const (
 
 	// BootstrapTokenExtraGroups is the byoh group that has access to create CertificateSigningRequest
 	BootstrapTokenExtraGroups = "system:bootstrappers:byoh"
+
+	// BootstrapKubeconfigFinalizer allows ReconcileBootstrapKubeconfig to delete the bootstrap token secret
+	BootstrapKubeconfigFinalizer = "bootstrapkubeconfig.infrastructure.cluster.x-k8s.io"
+
+	// BootstrapKubeconfigSecretKey is the key of the bootstrap kubeconfig in its Secret
+	BootstrapKubeconfigSecretKey = "value"
+
+	// HostNamespaceGroupPrefix prefixes, in the organizations of a host certificate, the namespace
+	// the host is bound to. The host may only create and update ByoHosts in this namespace.
+	HostNamespaceGroupPrefix = "byoh:namespace:"
+
+	// HostLabelGroupPrefix prefixes, in the organizations of a host certificate, each <key>=<value>
+	// label the ByoHosts of the host must carry.
+	HostLabelGroupPrefix = "byoh:label:"
+
+	// RegenerateTokenAnnotation annotation used to request a new bootstrap token, e.g. once the token
+	// expired or its registrations are exhausted. It is removed once the token is regenerated.
+	RegenerateTokenAnnotation = "byoh.infrastructure.cluster.x-k8s.io/regenerate-token"
 )
 
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
@@ -36,29 +59,98 @@ type BootstrapKubeconfigSpec struct {
 	// More info: https://book.kubebuilder.io/reference/markers/crd-validation.html
 
 	// APIServer is the address of the kubernetes cluster (https://hostname:port).
-	APIServer string `json:"apiserver"`
+	// Discovered from the cluster-info ConfigMap in kube-public, or else from the manager, if not set.
+	// The address of an in-cluster manager (e.g. https://10.96.0.1:443) is usually unreachable from the hosts.
+	// +optional
+	APIServer string `json:"apiserver,omitempty"`
 
 	// InsecureSkipTLSVerify skips the validity check for the server's certificate. This will make your HTTPS connections insecure.
 	// +optional
 	// +kubebuilder:default=false
 	InsecureSkipTLSVerify bool `json:"insecure-skip-tls-verify,omitempty"`
 
-	// CertificateAuthorityData contains PEM-encoded certificate authority certificates.
-	CertificateAuthorityData string `json:"certificate-authority-data"`
+	// CertificateAuthorityData contains base64 encoded PEM certificate authority certificates.
+	// Discovered from the cluster-info ConfigMap in kube-public, or else from the manager, if not set
+	// and the server's certificate is verified.
+	// +optional
+	CertificateAuthorityData string `json:"certificate-authority-data,omitempty"`
+
+	// TTL is the time to live of the bootstrap token, the token is revoked once expired.
+	// +optional
+	// +kubebuilder:default="30m"
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// MaxRegistrations is the maximum number of host registrations with the bootstrap token,
+	// the token is revoked once reached. The registrations are not limited if not set.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxRegistrations *int32 `json:"maxRegistrations,omitempty"`
+
+	// TargetNamespace binds the hosts registered with the bootstrap token to this namespace, their
+	// certificates are only approved for it and they can only create ByoHosts in it.
+	// The hosts are not bound to a namespace if not set.
+	// +optional
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+
+	// TargetLabels are the labels the ByoHosts of the hosts registered with the bootstrap token must carry,
+	// their certificates are only approved with these labels.
+	// +optional
+	TargetLabels map[string]string `json:"targetLabels,omitempty"`
 }
 
 // BootstrapKubeconfigStatus defines the observed state of BootstrapKubeconfig.
 type BootstrapKubeconfigStatus struct {
 	// Important: Run "make" to regenerate code after modifying this file
 
-	// BootstrapKubeconfigData is an optional reference to a bootstrap kubeconfig info
-	// for starting the host registration process
+	// BootstrapKubeconfigData is the bootstrap kubeconfig of the bootstrap token.
+	//
+	// Deprecated: the bootstrap kubeconfig is in the Secret referenced by BootstrapKubeconfigSecretRef.
+	// The field is no longer written, the token of a BootstrapKubeconfig still reporting it is regenerated
+	// in the Secret and the field is cleared.
 	// +optional
 	BootstrapKubeconfigData *string `json:"bootstrapKubeconfigData,omitempty"`
+
+	// BootstrapKubeconfigSecretRef references the Secret holding the bootstrap kubeconfig under the
+	// BootstrapKubeconfigSecretKey, for starting the host registration process. The Secret is owned by
+	// the BootstrapKubeconfig in its namespace and removed once the bootstrap token is revoked.
+	// +optional
+	BootstrapKubeconfigSecretRef *corev1.LocalObjectReference `json:"bootstrapKubeconfigSecretRef,omitempty"`
+
+	// APIServer is the address of the kubernetes cluster in the bootstrap kubeconfig.
+	// +optional
+	APIServer string `json:"apiserver,omitempty"`
+
+	// CertificateAuthorityData contains the base64 encoded PEM certificate authority certificates
+	// in the bootstrap kubeconfig.
+	// +optional
+	CertificateAuthorityData string `json:"certificate-authority-data,omitempty"`
+
+	// TokenID is the ID of the current bootstrap token.
+	// +optional
+	TokenID string `json:"tokenID,omitempty"`
+
+	// ExpiresAt is the expiration time of the current bootstrap token.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// Registrations is the number of host registrations with the current bootstrap token.
+	// +optional
+	Registrations int32 `json:"registrations,omitempty"`
+
+	// RegisteredCSRs are the UIDs of the approved CSRs counted in Registrations,
+	// so that the approval of a CSR is only counted once.
+	// +optional
+	// +listType=set
+	RegisteredCSRs []string `json:"registeredCSRs,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="TargetNamespace",type="string",JSONPath=`.spec.targetNamespace`
+// +kubebuilder:printcolumn:name="TokenID",type="string",JSONPath=`.status.tokenID`
+// +kubebuilder:printcolumn:name="Expires",type="date",JSONPath=`.status.expiresAt`
+// +kubebuilder:printcolumn:name="Registrations",type="integer",JSONPath=`.status.registrations`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // BootstrapKubeconfig is the Schema for the bootstrapkubeconfigs API.
 type BootstrapKubeconfig struct {
@@ -89,3 +181,58 @@ type BootstrapKubeconfigList struct {
 func init() {
 	SchemeBuilder.Register(&BootstrapKubeconfig{}, &BootstrapKubeconfigList{})
 }
+
+// SecretName returns the name of the Secret holding the bootstrap kubeconfig
+func (b *BootstrapKubeconfig) SecretName() string {
+	return b.Name + "-bootstrap-kubeconfig"
+}
+
+// TokenExpired returns true once the current bootstrap token expired
+func (b *BootstrapKubeconfig) TokenExpired(now time.Time) bool {
+	return b.Status.ExpiresAt != nil && !now.Before(b.Status.ExpiresAt.Time)
+}
+
+// RegistrationsExhausted returns true once the current bootstrap token reached its MaxRegistrations
+func (b *BootstrapKubeconfig) RegistrationsExhausted() bool {
+	return b.Spec.MaxRegistrations != nil && b.Status.Registrations >= *b.Spec.MaxRegistrations
+}
+
+// HostBindingGroups returns the groups binding the hosts registered with the bootstrap token
+// to the TargetNamespace and the TargetLabels
+func (b *BootstrapKubeconfig) HostBindingGroups() []string {
+	return HostBindingGroups(b.Spec.TargetNamespace, b.Spec.TargetLabels)
+}
+
+// HostBindingGroups returns the organizations of a host certificate binding the host to the namespace,
+// if not empty, and to the labels, sorted
+func HostBindingGroups(namespace string, labels map[string]string) []string {
+	groups := make([]string, 0, len(labels)+1)
+	if namespace != "" {
+		groups = append(groups, HostNamespaceGroupPrefix+namespace)
+	}
+	labelGroups := make([]string, 0, len(labels))
+	for key, value := range labels {
+		labelGroups = append(labelGroups, HostLabelGroupPrefix+key+"="+value)
+	}
+	sort.Strings(labelGroups)
+	return append(groups, labelGroups...)
+}
+
+// IsHostBindingGroup returns true if the group binds a host to a namespace or a label
+func IsHostBindingGroup(group string) bool {
+	return strings.HasPrefix(group, HostNamespaceGroupPrefix) || strings.HasPrefix(group, HostLabelGroupPrefix)
+}
+
+// HostBinding returns the namespace, empty if not bound, and the labels a host is bound to by its groups
+func HostBinding(groups []string) (namespace string, labels map[string]string) {
+	labels = map[string]string{}
+	for _, group := range groups {
+		if ns, found := strings.CutPrefix(group, HostNamespaceGroupPrefix); found {
+			namespace = ns
+		} else if label, found := strings.CutPrefix(group, HostLabelGroupPrefix); found {
+			key, value, _ := strings.Cut(label, "=")
+			labels[key] = value
+		}
+	}
+	return namespace, labels
+}
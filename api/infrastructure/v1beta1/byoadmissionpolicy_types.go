@@ -0,0 +1,61 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// ByoAdmissionPolicySpec defines the hosts allowed to register.
+type ByoAdmissionPolicySpec struct {
+	// Important: Run "make" to regenerate code after modifying this file
+	// The following markers will use OpenAPI v3 schema to validate the value
+	// More info: https://book.kubebuilder.io/reference/markers/crd-validation.html
+
+	// HostnamePatterns are the shell patterns (e.g. worker-*) of the hostnames whose certificate
+	// signing requests are approved.
+	// +kubebuilder:validation:MinItems=1
+	HostnamePatterns []string `json:"hostnamePatterns"`
+
+	// MaxExpirationSeconds limits the duration of the host certificates requested by the matching hosts,
+	// below the limit of the manager.
+	// +optional
+	// +kubebuilder:validation:Minimum=600
+	MaxExpirationSeconds *int32 `json:"maxExpirationSeconds,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=byoadmissionpolicies,scope=Namespaced,shortName=byoap
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ByoAdmissionPolicy is the Schema for the byoadmissionpolicies API.
+// Once a policy exists in a namespace, the certificate signing requests of the hosts bound to the
+// namespace are only approved for the hostnames matching one of its policies. The hosts bound to no
+// namespace may register in all of them, they are subject to the policies of all the namespaces.
+type ByoAdmissionPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the hosts allowed by the policy
+	// +required
+	Spec ByoAdmissionPolicySpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// ByoAdmissionPolicyList contains a list of ByoAdmissionPolicy.
+type ByoAdmissionPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ByoAdmissionPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ByoAdmissionPolicy{}, &ByoAdmissionPolicyList{})
+}
@@ -4,6 +4,7 @@
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -31,6 +32,33 @@ type ByoClusterSpec struct {
 	// if not set, the default will be set to https://projects.registry.vmware.com/cluster_api_provider_bringyourownhost
 	// +optional
 	BundleLookupBaseRegistry string `json:"bundleLookupBaseRegistry,omitempty"`
+
+	// BundleLookupCredentialsSecretRef is an optional reference to a kubernetes.io/dockerconfigjson secret,
+	// in the same namespace, holding the credentials used to pull byoh bundle images from BundleLookupBaseRegistry
+	// +optional
+	BundleLookupCredentialsSecretRef *corev1.LocalObjectReference `json:"bundleLookupCredentialsSecretRef,omitempty"`
+
+	// Proxy is an optional HTTP proxy used by the installation, containerd and kubelet of the cluster hosts,
+	// unless the K8sInstallerConfig sets its own
+	// +optional
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+
+	// Installer is the optional default installer of the cluster machines whose ByoMachine has no InstallerRef,
+	// so that the installation is configured once per cluster
+	// +optional
+	Installer *ClusterInstaller `json:"installer,omitempty"`
+}
+
+// ClusterInstaller defines the default installer of the cluster machines. TemplateRef takes precedence over Spec.
+type ClusterInstaller struct {
+	// TemplateRef is an optional reference to the installer template (e.g. K8sInstallerConfigTemplate)
+	// the installer config of the machines is created from
+	// +optional
+	TemplateRef *corev1.ObjectReference `json:"templateRef,omitempty"`
+
+	// Spec is the optional spec of the K8sInstallerConfig created for the machines when TemplateRef is not set
+	// +optional
+	Spec *K8sInstallerConfigSpec `json:"spec,omitempty"`
 }
 
 // ByoClusterStatus defines the observed state of ByoCluster.
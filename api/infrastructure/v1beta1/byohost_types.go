@@ -22,6 +22,8 @@ const (
 	AttachedByoMachineLabel = "byoh.infrastructure.cluster.x-k8s.io/byomachine-name"
 	// BundleLookupBaseRegistryAnnotation annotation used to store the base registry for the bundle lookup
 	BundleLookupBaseRegistryAnnotation = "byoh.infrastructure.cluster.x-k8s.io/bundle-registry"
+	// BootstrapDataSecretKey is the key of the bootstrap data in the bootstrap Secret of a ByoHost
+	BootstrapDataSecretKey = "value"
 )
 
 // ByoHostSpec defines the desired state of ByoHost.
@@ -90,6 +92,108 @@ type ByoHostStatus struct {
 	// network interfaces.
 	// +optional
 	Network []NetworkStatus `json:"network,omitempty"`
+
+	// BundleCache reports the bundles cached on the host by the agent.
+	// +optional
+	BundleCache *BundleCacheStatus `json:"bundleCache,omitempty"`
+
+	// Installation reports the progress of the k8s components installation steps.
+	// +optional
+	Installation *InstallationStatus `json:"installation,omitempty"`
+
+	// CertificateExpiry is the expiry of the client certificate of the host agent,
+	// which is renewed once less than 20% of its lifetime is left.
+	// +optional
+	CertificateExpiry *metav1.Time `json:"certificateExpiry,omitempty"`
+}
+
+// InstallationStepPhase is the state of an installation step.
+// +kubebuilder:validation:Enum=Applied;Skipped;Failed;RolledBack
+type InstallationStepPhase string
+
+const (
+	// InstallationStepApplied means the step was applied and verified
+	InstallationStepApplied InstallationStepPhase = "Applied"
+	// InstallationStepSkipped means the step condition was not met on the host
+	InstallationStepSkipped InstallationStepPhase = "Skipped"
+	// InstallationStepFailed means the step could not be applied, verified or rolled back
+	InstallationStepFailed InstallationStepPhase = "Failed"
+	// InstallationStepRolledBack means the step was undone
+	InstallationStepRolledBack InstallationStepPhase = "RolledBack"
+)
+
+// InstallationStatus is the progress of the k8s components installation on the host.
+type InstallationStatus struct {
+	// K8sVersion is the k8s version of the installed components.
+	// +optional
+	K8sVersion string `json:"k8sVersion,omitempty"`
+
+	// FailedUpgradeK8sVersion is the k8s version the in-place upgrade to failed and was rolled back from.
+	// The upgrade is not retried until the k8s version of the host changes.
+	// +optional
+	FailedUpgradeK8sVersion string `json:"failedUpgradeK8sVersion,omitempty"`
+
+	// Plan is the installation steps of the installed k8s version, undone on uninstall.
+	// It is kept when an in-place upgrade is rolled back, although the installation secret is
+	// regenerated for the new version.
+	// +optional
+	Plan string `json:"plan,omitempty"`
+
+	// Steps are the installation steps executed on the host, in execution order.
+	// +optional
+	Steps []InstallationStepStatus `json:"steps,omitempty"`
+}
+
+// InstallationStepStatus is the state of an installation step on the host.
+type InstallationStepStatus struct {
+	// Name of the step.
+	Name string `json:"name"`
+
+	// Phase of the step.
+	Phase InstallationStepPhase `json:"phase"`
+
+	// Message is the reason the step failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastTransitionTime is the last time the step changed phase.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+}
+
+// BundleCacheStatus is the usage of the bundle cache on the host.
+type BundleCacheStatus struct {
+	// SizeBytes is the total disk space used by the cached bundles.
+	SizeBytes int64 `json:"sizeBytes"`
+
+	// InUse is the address of the bundle backing the current k8s installation,
+	// it is never garbage collected.
+	// +optional
+	InUse string `json:"inUse,omitempty"`
+
+	// ComponentsInUse are the addresses of the component artifacts backing the current k8s installation,
+	// by component name, they are never garbage collected.
+	// +optional
+	ComponentsInUse map[string]string `json:"componentsInUse,omitempty"`
+
+	// Bundles is the list of bundles in the cache.
+	// +optional
+	Bundles []CachedBundle `json:"bundles,omitempty"`
+}
+
+// CachedBundle is a bundle downloaded on the host.
+type CachedBundle struct {
+	// Address is the address of the bundle in the OCI registry.
+	Address string `json:"address"`
+
+	// Digest is the manifest digest the bundle address resolved to when it was downloaded.
+	// +optional
+	Digest string `json:"digest,omitempty"`
+
+	// SizeBytes is the disk space used by the bundle.
+	SizeBytes int64 `json:"sizeBytes"`
+
+	// LastUsed is the last time the bundle was installed or uninstalled.
+	LastUsed metav1.Time `json:"lastUsed"`
 }
 
 // +kubebuilder:object:root=true
@@ -138,3 +242,9 @@ func (byoHost *ByoHost) GetConditions() clusterv1.Conditions {
 func (byoHost *ByoHost) SetConditions(conditions clusterv1.Conditions) {
 	byoHost.Status.Conditions = conditions
 }
+
+// BootstrapSecretName returns the name of the Secret the bootstrap data of the attached machine
+// is projected into for the host agent
+func (byoHost *ByoHost) BootstrapSecretName() string {
+	return byoHost.Name + "-bootstrap-data"
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+const (
+	// HostAdmissionAnnotation annotation used by the administrator to approve or reject a ByoHostAdmission,
+	// set to HostAdmissionApproved or HostAdmissionRejected
+	HostAdmissionAnnotation = "byoh.infrastructure.cluster.x-k8s.io/admission"
+	// HostAdmissionApproved is the value of the HostAdmissionAnnotation approving the host
+	HostAdmissionApproved = "approved"
+	// HostAdmissionRejected is the value of the HostAdmissionAnnotation rejecting the host
+	HostAdmissionRejected = "rejected"
+)
+
+// HostAdmissionPhase is the state of the admission of a host.
+// +kubebuilder:validation:Enum=PendingApproval;Approved;Rejected
+type HostAdmissionPhase string
+
+const (
+	// HostAdmissionPendingApproval means the host waits for the administrator decision
+	HostAdmissionPendingApproval HostAdmissionPhase = "PendingApproval"
+	// HostAdmissionPhaseApproved means the host was approved by the administrator
+	HostAdmissionPhaseApproved HostAdmissionPhase = "Approved"
+	// HostAdmissionPhaseRejected means the host was rejected by the administrator
+	HostAdmissionPhaseRejected HostAdmissionPhase = "Rejected"
+)
+
+// ByoHostAdmissionStatus reports the facts of the host pending admission.
+type ByoHostAdmissionStatus struct {
+	// Phase of the admission of the host.
+	// +optional
+	Phase HostAdmissionPhase `json:"phase,omitempty"`
+
+	// CSRName is the name of the last certificate signing request of the host.
+	// +optional
+	CSRName string `json:"csrName,omitempty"`
+
+	// Requester is the user that requested the certificate of the host.
+	// +optional
+	Requester string `json:"requester,omitempty"`
+
+	// RequestedAt is the creation time of the last certificate signing request of the host.
+	// +optional
+	RequestedAt *metav1.Time `json:"requestedAt,omitempty"`
+
+	// PublicKeySHA256 is the hex encoded SHA-256 digest of the public key of the last certificate signing
+	// request of the host. The approval of the host only holds for this key: a bootstrap token requesting
+	// a certificate for another key resets the admission to PendingApproval.
+	// +optional
+	PublicKeySHA256 string `json:"publicKeySHA256,omitempty"`
+
+	// ByoHostRef is the reference to the ByoHost registered by the host.
+	// +optional
+	ByoHostRef *corev1.ObjectReference `json:"byoHostRef,omitempty"`
+
+	// HostDetails are the platform details reported by the ByoHost.
+	// +optional
+	HostDetails HostInfo `json:"hostinfo,omitempty"`
+
+	// Network is the network status reported by the ByoHost.
+	// +optional
+	Network []NetworkStatus `json:"network,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=byohostadmissions,scope=Namespaced,shortName=byoha
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Requester",type="string",JSONPath=`.status.requester`
+// +kubebuilder:printcolumn:name="OSImage",type="string",JSONPath=`.status.hostinfo.osimage`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ByoHostAdmission is the Schema for the byohostadmissions API.
+// It is created by the manager in manual host admission mode for each host, named after its hostname
+// in the namespace the host is bound to, and holds its certificate signing requests and ByoHost until
+// the HostAdmissionAnnotation approves them. Hosts of the same name in other namespaces are admitted apart.
+type ByoHostAdmission struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// status reports the facts of the host
+	// +optional
+	Status ByoHostAdmissionStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// ByoHostAdmissionList contains a list of ByoHostAdmission.
+type ByoHostAdmissionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ByoHostAdmission `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ByoHostAdmission{}, &ByoHostAdmissionList{})
+}
+
+// Decision returns the phase decided by the HostAdmissionAnnotation, PendingApproval if undecided
+func (a *ByoHostAdmission) Decision() HostAdmissionPhase {
+	switch a.Annotations[HostAdmissionAnnotation] {
+	case HostAdmissionApproved:
+		return HostAdmissionPhaseApproved
+	case HostAdmissionRejected:
+		return HostAdmissionPhaseRejected
+	default:
+		return HostAdmissionPendingApproval
+	}
+}
@@ -52,6 +52,39 @@ const (
 	// K8sComponentsInstallationFailedReason indicates that the installer failed to install all the
 	// k8s components on this host
 	K8sComponentsInstallationFailedReason = "K8sComponentsInstallationFailed"
+
+	// K8sUpgradeSucceeded documents if the in-place upgrade of the k8s components to the version
+	// of the K8sVersionAnnotation succeeded. This condition is only set on hosts whose version changed.
+	K8sUpgradeSucceeded clusterv1.ConditionType = "K8sUpgradeSucceeded"
+
+	// K8sUpgradePendingReason indicates that the k8s version of the host changed and the installer
+	// controller is yet to provide the installation secret of the new version.
+	// In-place upgrades are enabled by K8sInstallerConfig.Spec.InPlaceUpgrade
+	K8sUpgradePendingReason = "K8sUpgradePending"
+
+	// K8sUpgradeFailedReason indicates that the in-place upgrade failed and the k8s components
+	// of the previous bundle were restored
+	K8sUpgradeFailedReason = "K8sUpgradeFailed"
+
+	// HostStateRestored documents if the byoh agent restored the swap, firewall, kernel modules, held packages
+	// and sysctl settings the host had before installation. This condition is only set once a restore is attempted.
+	HostStateRestored clusterv1.ConditionType = "HostStateRestored"
+
+	// HostStateRestoreFailedReason indicates that the host state could not be fully restored,
+	// the restore is retried on the next uninstall
+	HostStateRestoreFailedReason = "HostStateRestoreFailed"
+
+	// HostAdmittedCondition documents if the host was approved by the administrator in its ByoHostAdmission.
+	// This condition is only set in manual host admission mode, where hosts are only attached
+	// to ByoMachines once it is true.
+	HostAdmittedCondition clusterv1.ConditionType = "HostAdmitted"
+
+	// HostAdmissionPendingReason indicates that the ByoHostAdmission of the host is yet to be
+	// approved or rejected by the administrator
+	HostAdmissionPendingReason = "PendingApproval"
+
+	// HostAdmissionRejectedReason indicates that the administrator rejected the ByoHostAdmission of the host
+	HostAdmissionRejectedReason = "HostAdmissionRejected"
 )
 
 // Conditions and Reasons defined on BYOMachine
@@ -77,6 +110,45 @@ const (
 	InstallationSecretNotAvailableReason = "InstallationSecretNotAvailable"
 )
 
+// Conditions and Reasons defined on K8sInstallerConfig
+const (
+
+	// OSSupported documents if bundles are published for the OS and architecture of the host
+	OSSupported clusterv1.ConditionType = "OSSupported"
+
+	// OSNotSupportedReason indicates that no bundle is published for the OS and architecture of the host,
+	// the installation secret cannot be generated
+	OSNotSupportedReason = "OSNotSupported"
+
+	// K8sVersionSupported documents if bundles of the k8s version are published for the OS of the host.
+	// An unsupported version is a warning only, as the bundle repository may provide other versions.
+	K8sVersionSupported clusterv1.ConditionType = "K8sVersionSupported"
+
+	// K8sVersionNotSupportedReason indicates that no bundle of the k8s version is published for the OS of the host
+	K8sVersionNotSupportedReason = "K8sVersionNotSupported"
+
+	// InstallationSecretGenerated documents if the installation secret was generated for the host.
+	// The reasons of this condition are mirrored to the BYOHostReady condition of the ByoMachine.
+	InstallationSecretGenerated clusterv1.ConditionType = "InstallationSecretGenerated"
+
+	// InstallationSecretGenerationFailedReason indicates that the installation data could not be generated,
+	// e.g. a referenced secret or ConfigMap does not exist
+	InstallationSecretGenerationFailedReason = "InstallationSecretGenerationFailed"
+
+	// ComponentVersionsNotSupportedReason indicates that the component versions of the spec are not
+	// compatible with the k8s version, the installation secret cannot be generated
+	ComponentVersionsNotSupportedReason = "ComponentVersionsNotSupported"
+
+	// K8sInstallerConfigDrifted documents that the k8s components of the attached host were installed
+	// from an older generation of the K8sInstallerConfig spec. The installation secret is not regenerated
+	// for installed hosts, the host has to be reinstalled to pick up the spec changes.
+	K8sInstallerConfigDrifted clusterv1.ConditionType = "Drifted"
+
+	// InstalledFromOlderSpecReason indicates that the spec changed after the attached host installed
+	// the k8s components
+	InstalledFromOlderSpecReason = "InstalledFromOlderSpec"
+)
+
 // Reasons common to all Byo Resources
 const (
 
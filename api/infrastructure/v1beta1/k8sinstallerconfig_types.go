@@ -6,6 +6,7 @@ package v1beta1
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
@@ -15,6 +16,30 @@ const (
 	// resources associated with K8sInstallerConfig before removing it from the
 	// API Server.
 	K8sInstallerConfigFinalizer = "k8sinstallerconfig.infrastructure.cluster.x-k8s.io"
+
+	// RegistryCredentialsSecretNameKey is the installation secret key holding the name of the dockerconfigjson
+	// Secret, in the namespace of the installation secret, used by the host to pull the bundle from an authenticated registry
+	RegistryCredentialsSecretNameKey = "registryCredentialsSecretName"
+
+	// BundleAddrSecretKey is the installation secret key holding the address of the bundle
+	// installed by the install script, used by the host to track its bundle cache
+	BundleAddrSecretKey = "bundleAddr"
+
+	// InstallationStepsSecretKey is the installation secret key holding the installation steps,
+	// the install and uninstall scripts are rendered from them
+	InstallationStepsSecretKey = "steps"
+
+	// InstallationUpgradeStepsSecretKey is the installation secret key holding the steps upgrading
+	// the k8s components of a host installed from a previous bundle to the bundle of the secret
+	InstallationUpgradeStepsSecretKey = "upgradeSteps"
+
+	// InstallationHooksSecretKey is the installation secret key holding the hooks run by the host
+	// around the installation and uninstallation, with their ConfigMap scripts resolved
+	InstallationHooksSecretKey = "hooks"
+
+	// InstallationComponentsSecretKey is the installation secret key holding the addresses of the
+	// component artifacts, by component name, installed instead of the components shipped in the bundle
+	InstallationComponentsSecretKey = "components"
 )
 
 // K8sInstallerConfigSpec defines the desired state of K8sInstallerConfig.
@@ -26,8 +51,212 @@ type K8sInstallerConfigSpec struct {
 	// BundleRepo is the OCI registry from which the carvel imgpkg bundle will be downloaded
 	BundleRepo string `json:"bundleRepo"`
 
-	// BundleType is the type of bundle (e.g. k8s) that needs to be downloaded
+	// BundleType is the type of bundle (e.g. k8s) that needs to be downloaded.
+	// With the repo type, the k8s components are installed from PackageRepository instead of a bundle
 	BundleType string `json:"bundleType"`
+
+	// PackageRepository is the apt repository the k8s components are installed from, required by the repo BundleType.
+	// BundleRepo, BundleVerification and CredentialsSecretRef do not apply to it.
+	// +optional
+	PackageRepository *PackageRepository `json:"packageRepository,omitempty"`
+
+	// BundleVerification is an optional set of checks the bundle must pass before
+	// it is installed on a host
+	// +optional
+	BundleVerification *BundleVerification `json:"bundleVerification,omitempty"`
+
+	// CredentialsSecretRef is an optional reference to a kubernetes.io/dockerconfigjson secret,
+	// in the same namespace, holding the credentials used to pull the bundle from BundleRepo.
+	// If not set, the ByoCluster BundleLookupCredentialsSecretRef is used if any.
+	// +optional
+	CredentialsSecretRef *corev1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
+
+	// InPlaceUpgrade enables upgrading the k8s components of the attached host in place when the
+	// K8sVersionAnnotation of the ByoHost changes: the installation secret is regenerated for the
+	// new version and the host agent upgrades the node, rolling back to the previous bundle on failure.
+	// It cannot be used with a BundleVerification Digest, which pins a single version.
+	// +optional
+	InPlaceUpgrade bool `json:"inPlaceUpgrade,omitempty"`
+
+	// Containerd is an optional set of containerd settings the installer renders into the containerd
+	// configuration before starting the service. If not set, the configuration shipped in the bundle is used.
+	// +optional
+	Containerd *ContainerdConfig `json:"containerd,omitempty"`
+
+	// Proxy is an optional HTTP proxy used by the installation, containerd and kubelet.
+	// If not set, the ByoCluster Proxy is used if any.
+	// +optional
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+
+	// Hooks are optional site-specific scripts run by the host agent around the installation
+	// and uninstallation of the k8s components.
+	// +optional
+	Hooks *InstallerHooks `json:"hooks,omitempty"`
+
+	// Components optionally pins the versions of individual components, which are installed from their own
+	// artifacts in BundleRepo instead of the versions shipped in the bundle. The versions must be compatible
+	// with the k8s version of the machine. It cannot be used with the repo BundleType nor with InPlaceUpgrade.
+	// +optional
+	Components *ComponentVersions `json:"components,omitempty"`
+}
+
+// ComponentVersions defines the versions of the components installed independently of the bundle.
+type ComponentVersions struct {
+	// Containerd is the version of containerd (e.g. v1.7.22).
+	// +optional
+	// +kubebuilder:validation:Pattern=`^v[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?$`
+	Containerd string `json:"containerd,omitempty"`
+
+	// CNIPlugins is the version of the kubernetes-cni plugins (e.g. v1.5.1).
+	// +optional
+	// +kubebuilder:validation:Pattern=`^v[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?$`
+	CNIPlugins string `json:"cniPlugins,omitempty"`
+
+	// CRITools is the version of cri-tools (e.g. v1.30.1).
+	// +optional
+	// +kubebuilder:validation:Pattern=`^v[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?$`
+	CRITools string `json:"criTools,omitempty"`
+}
+
+// InstallerHooks defines the hooks run at each point of the installation, in order.
+type InstallerHooks struct {
+	// PreInstall hooks are run before the k8s components are installed. A failing hook fails the installation.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	PreInstall []InstallerHook `json:"preInstall,omitempty"`
+
+	// PostInstall hooks are run once the k8s components are installed. A failing hook rolls back the installation.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	PostInstall []InstallerHook `json:"postInstall,omitempty"`
+
+	// PreUninstall hooks are run before the k8s components are uninstalled. A failing hook fails the uninstallation.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	PreUninstall []InstallerHook `json:"preUninstall,omitempty"`
+
+	// PostUninstall hooks are run once the k8s components are uninstalled. Failures are only reported.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	PostUninstall []InstallerHook `json:"postUninstall,omitempty"`
+}
+
+// InstallerHook defines a bash script run on the host. Exactly one of Script or ConfigMapKeyRef must be set.
+type InstallerHook struct {
+	// Name identifies the hook in the host events.
+	Name string `json:"name"`
+
+	// Script is the inline bash script of the hook.
+	// +optional
+	Script string `json:"script,omitempty"`
+
+	// ConfigMapKeyRef selects the key of a ConfigMap, in the same namespace, holding the bash script of the hook.
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
+// BundleVerification defines how the bundle content is verified before installation.
+// At least one of Digest or PublicKey should be set.
+type BundleVerification struct {
+	// Digest pins the bundle to an OCI manifest digest (e.g. sha256:...).
+	// Hosts pull the bundle by this digest, so a tag overwritten in the registry
+	// cannot change what gets installed.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^sha256:[a-f0-9]{64}$`
+	Digest string `json:"digest,omitempty"`
+
+	// PublicKey is a PEM encoded cosign public key. When set, hosts verify the
+	// bundle signature with cosign and refuse to install unsigned or mismatching bundles.
+	// +optional
+	PublicKey string `json:"publicKey,omitempty"`
+}
+
+// PackageRepository defines the apt repository of the k8s packages.
+type PackageRepository struct {
+	// URL of the repository (e.g. https://pkgs.k8s.io/core:/stable:/v1.30/deb/).
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+
+	// Suite of the repository, "/" for flat repositories such as pkgs.k8s.io.
+	// +optional
+	// +kubebuilder:default="/"
+	Suite string `json:"suite,omitempty"`
+
+	// Components of the repository (e.g. main), none for flat repositories.
+	// +optional
+	Components []string `json:"components,omitempty"`
+
+	// GPGKey is the ASCII armored public key the repository is signed with.
+	// +kubebuilder:validation:MinLength=1
+	GPGKey string `json:"gpgKey"`
+
+	// ContainerdVersion pins the version of the containerd package (e.g. 1.7.12), which is installed from
+	// the apt sources of the host. The latest version is installed if not set.
+	// +optional
+	ContainerdVersion string `json:"containerdVersion,omitempty"`
+}
+
+// ContainerdConfig defines the containerd settings of the hosts.
+type ContainerdConfig struct {
+	// RegistryMirrors are the mirrors pulled from instead of the registries, in order.
+	// +optional
+	// +listType=map
+	// +listMapKey=registry
+	RegistryMirrors []RegistryMirror `json:"registryMirrors,omitempty"`
+
+	// InsecureRegistries are the registry hosts (e.g. registry.local:5000) whose TLS certificate is not verified.
+	// +optional
+	// +kubebuilder:validation:items:Pattern=`^[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?(:[0-9]{1,5})?$`
+	InsecureRegistries []string `json:"insecureRegistries,omitempty"`
+
+	// SandboxImage is the image of the pod sandbox container (e.g. registry.k8s.io/pause:3.9).
+	// +optional
+	SandboxImage string `json:"sandboxImage,omitempty"`
+
+	// SystemdCgroup makes runc use the systemd cgroup driver, which kubelet expects by default.
+	// +optional
+	// +kubebuilder:default=true
+	SystemdCgroup *bool `json:"systemdCgroup,omitempty"`
+
+	// Snapshotter is the snapshotter of the CRI plugin (e.g. overlayfs).
+	// +optional
+	Snapshotter string `json:"snapshotter,omitempty"`
+
+	// DataRoot is the root directory of the containerd persistent data, /var/lib/containerd by default.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^/`
+	DataRoot string `json:"dataRoot,omitempty"`
+}
+
+// ProxyConfig defines the HTTP proxy of the hosts.
+type ProxyConfig struct {
+	// HTTPProxy is the proxy URL for HTTP requests (e.g. http://proxy.local:3128).
+	// +optional
+	HTTPProxy string `json:"httpProxy,omitempty"`
+
+	// HTTPSProxy is the proxy URL for HTTPS requests (e.g. http://proxy.local:3128).
+	// +optional
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+
+	// NoProxy are the hosts, domains and CIDRs reached without the proxy.
+	// The pod and service CIDRs and the control plane endpoint of the cluster are always added.
+	// +optional
+	NoProxy []string `json:"noProxy,omitempty"`
+}
+
+// RegistryMirror defines the mirrors of a registry.
+type RegistryMirror struct {
+	// Registry is the registry host (e.g. docker.io) the mirrors serve.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?(:[0-9]{1,5})?$`
+	Registry string `json:"registry"`
+
+	// Endpoints are the mirror URLs (e.g. https://mirror.local:5000), tried in order before the registry.
+	// +kubebuilder:validation:MinItems=1
+	Endpoints []string `json:"endpoints"`
 }
 
 // K8sInstallerConfigStatus defines the observed state of K8sInstallerConfig.
@@ -41,6 +270,28 @@ type K8sInstallerConfigStatus struct {
 	// InstallationSecret is an optional reference to a generated installation secret by K8sInstallerConfig controller
 	// +optional
 	InstallationSecret *corev1.ObjectReference `json:"installationSecret,omitempty"`
+
+	// BundleDigest is the digest of the bundle the installation secret was generated for.
+	// It is only set when BundleVerification is configured.
+	// +optional
+	BundleDigest string `json:"bundleDigest,omitempty"`
+
+	// OSBundle is the OS of the bundles installed on the host, resolved from the OS and architecture of the host
+	// +optional
+	OSBundle string `json:"osBundle,omitempty"`
+
+	// BundleAddr is the address of the bundle the installation secret was generated for.
+	// It is empty for packages installed from a package repository.
+	// +optional
+	BundleAddr string `json:"bundleAddr,omitempty"`
+
+	// ObservedGeneration is the generation of the spec the installation secret was generated from
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions defines current service state of the K8sInstallerConfig.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -72,6 +323,16 @@ type K8sInstallerConfigList struct {
 	Items           []K8sInstallerConfig `json:"items"`
 }
 
+// GetConditions returns the conditions of K8sInstallerConfig status
+func (c *K8sInstallerConfig) GetConditions() clusterv1.Conditions {
+	return c.Status.Conditions
+}
+
+// SetConditions sets the conditions of K8sInstallerConfig status
+func (c *K8sInstallerConfig) SetConditions(conditions clusterv1.Conditions) {
+	c.Status.Conditions = conditions
+}
+
 func init() {
 	SchemeBuilder.Register(&K8sInstallerConfig{}, &K8sInstallerConfigList{})
 }
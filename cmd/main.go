@@ -7,6 +7,7 @@ import (
 	"context"
 	"crypto/tls"
 	"flag"
+	"math"
 	"os"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -29,6 +30,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer"
 	infrastructurecontroller "github.com/cohesity/cluster-api-provider-bringyourownhost/internal/controller/infrastructure"
 	webhookinfrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/internal/webhook/infrastructure/v1beta1"
 	// +kubebuilder:scaffold:imports
@@ -56,6 +58,9 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var maxCSRExpirationSeconds int
+	var hostAdmissionMode string
+	var namespacedHostAttachment bool
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -74,6 +79,14 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.IntVar(&maxCSRExpirationSeconds, "max-csr-expiration-seconds",
+		int(infrastructurecontroller.DefaultMaxExpirationSeconds),
+		"The maximum duration in seconds of the host certificates approved by the ByoAdmission controller.")
+	flag.StringVar(&hostAdmissionMode, "host-admission-mode", "auto",
+		"The admission of new hosts, auto or manual. In manual mode, host CSRs and ByoHosts are held "+
+			"until their ByoHostAdmission is approved by an administrator.")
+	flag.BoolVar(&namespacedHostAttachment, "namespaced-host-attachment", false,
+		"If set, ByoHosts are only attached to the ByoMachines of their namespace.")
 
 	c, cancel := context.WithCancel(context.Background())
 	cancel()
@@ -86,6 +99,12 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if hostAdmissionMode != "auto" && hostAdmissionMode != "manual" {
+		setupLog.Error(nil, "invalid host-admission-mode, must be auto or manual", "host-admission-mode", hostAdmissionMode)
+		os.Exit(1)
+	}
+	manualHostAdmission := hostAdmissionMode == "manual"
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -194,17 +213,20 @@ func main() {
 	}
 
 	if err = (&infrastructurecontroller.ByoMachineReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Tracker:  tracker,
-		Recorder: mgr.GetEventRecorderFor("byomachine-controller"),
+		Client:                   mgr.GetClient(),
+		Scheme:                   mgr.GetScheme(),
+		Tracker:                  tracker,
+		Recorder:                 mgr.GetEventRecorderFor("byomachine-controller"),
+		ManualHostAdmission:      manualHostAdmission,
+		NamespacedHostAttachment: namespacedHostAttachment,
 	}).SetupWithManager(c, mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ByoMachine")
 		os.Exit(1)
 	}
 	if err = (&infrastructurecontroller.ByoHostReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:              mgr.GetClient(),
+		Scheme:              mgr.GetScheme(),
+		ManualHostAdmission: manualHostAdmission,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ByoHost")
 		os.Exit(1)
@@ -224,8 +246,9 @@ func main() {
 		os.Exit(1)
 	}
 	if err = (&infrastructurecontroller.K8sInstallerConfigReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		DigestResolver: installer.NewRegistryDigestResolver(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "K8sInstallerConfig")
 		os.Exit(1)
@@ -234,8 +257,16 @@ func main() {
 	// Set 'MANUAL_CSR_APPROVAL=enable' to disable ByoAdmission controller. Now CSRs should be approved manually.
 	// nolint:goconst
 	if os.Getenv("MANUAL_CSR_APPROVAL") != "enable" {
+		if maxCSRExpirationSeconds < 600 || maxCSRExpirationSeconds > math.MaxInt32 {
+			setupLog.Error(nil, "invalid max-csr-expiration-seconds, must be between 600 and 2147483647",
+				"max-csr-expiration-seconds", maxCSRExpirationSeconds)
+			os.Exit(1)
+		}
 		if err = (&infrastructurecontroller.ByoAdmissionReconciler{
-			ClientSet: clientset.NewForConfigOrDie(ctrl.GetConfigOrDie()),
+			ClientSet:            clientset.NewForConfigOrDie(ctrl.GetConfigOrDie()),
+			Client:               mgr.GetClient(),
+			MaxExpirationSeconds: int32(maxCSRExpirationSeconds),
+			ManualHostAdmission:  manualHostAdmission,
 		}).SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "ByoAdmission")
 			os.Exit(1)
@@ -243,8 +274,10 @@ func main() {
 	}
 
 	if err := (&infrastructurecontroller.BootstrapKubeconfigReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:     mgr.GetClient(),
+		Scheme:     mgr.GetScheme(),
+		ClientSet:  clientset.NewForConfigOrDie(mgr.GetConfig()),
+		RestConfig: mgr.GetConfig(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "BootstrapKubeconfig")
 		os.Exit(1)
@@ -263,6 +296,13 @@ func main() {
 			os.Exit(1)
 		}
 	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookinfrastructurev1beta1.SetupK8sInstallerConfigWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "K8sInstallerConfig")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
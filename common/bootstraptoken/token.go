@@ -31,9 +31,9 @@ func GetTokenIDSecretFromBootstrapToken(tokenStr string) (tokenID, tokenSecret s
 	return substrs[1], substrs[2], nil
 }
 
-// GenerateSecretFromBootstrapTokenStr builds the secret object from the token string
+// GenerateSecretFromBootstrapTokenStr builds the secret object from the token string, expiring at the expiration time
 // It also adds default description and auth groups that can be used by the bootstrap-kubeconfig
-func GenerateSecretFromBootstrapToken(tokenStr string, ttl time.Duration) (*v1.Secret, error) {
+func GenerateSecretFromBootstrapToken(tokenStr string, expiration time.Time) (*v1.Secret, error) {
 	tokenID, tokenSecret, err := GetTokenIDSecretFromBootstrapToken(tokenStr)
 	if err != nil {
 		return nil, err
@@ -41,7 +41,7 @@ func GenerateSecretFromBootstrapToken(tokenStr string, ttl time.Duration) (*v1.S
 	secretData := map[string][]byte{
 		bootstrapapi.BootstrapTokenIDKey:               []byte(tokenID),
 		bootstrapapi.BootstrapTokenSecretKey:           []byte(tokenSecret),
-		bootstrapapi.BootstrapTokenExpirationKey:       []byte(time.Now().UTC().Add(ttl).Format(time.RFC3339)),
+		bootstrapapi.BootstrapTokenExpirationKey:       []byte(expiration.UTC().Format(time.RFC3339)),
 		bootstrapapi.BootstrapTokenUsageSigningKey:     []byte("true"),
 		bootstrapapi.BootstrapTokenUsageAuthentication: []byte("true"),
 		bootstrapapi.BootstrapTokenDescriptionKey:      []byte(infrastructurev1beta1.BootstrapTokenDescription),
@@ -60,6 +60,7 @@ func GenerateSecretFromBootstrapToken(tokenStr string, ttl time.Duration) (*v1.S
 }
 
 // GenerateBootstrapKubeconfigFromBootstrapToken creates a bootstrap kubeconfig object from the bootstrap token generated
+// and the API server and CA data resolved in the status of the BootstrapKubeconfig
 // It also adds default cluster, context and auth info
 func GenerateBootstrapKubeconfigFromBootstrapToken(tokenStr string, bootstrapKubeconfig *infrastructurev1beta1.BootstrapKubeconfig) (*clientcmdapi.Config, error) {
 	tokenID, tokenSecret, err := GetTokenIDSecretFromBootstrapToken(tokenStr)
@@ -67,13 +68,19 @@ func GenerateBootstrapKubeconfigFromBootstrapToken(tokenStr string, bootstrapKub
 		return nil, err
 	}
 
+	// the hosts bound to a namespace register in it
+	namespace := infrastructurev1beta1.DefaultNamespace
+	if bootstrapKubeconfig.Spec.TargetNamespace != "" {
+		namespace = bootstrapKubeconfig.Spec.TargetNamespace
+	}
+
 	// Build resulting kubeconfig.
 	kubeconfigData := clientcmdapi.Config{
 		// Define a cluster stanza based on the bootstrap kubeconfig.
 		Clusters: map[string]*clientcmdapi.Cluster{infrastructurev1beta1.DefaultClusterName: {
-			Server:                   bootstrapKubeconfig.Spec.APIServer,
+			Server:                   bootstrapKubeconfig.Status.APIServer,
 			InsecureSkipTLSVerify:    bootstrapKubeconfig.Spec.InsecureSkipTLSVerify,
-			CertificateAuthorityData: []byte(bootstrapKubeconfig.Spec.CertificateAuthorityData),
+			CertificateAuthorityData: []byte(bootstrapKubeconfig.Status.CertificateAuthorityData),
 		}},
 		// Define auth based on the obtained client cert.
 		AuthInfos: map[string]*clientcmdapi.AuthInfo{infrastructurev1beta1.DefaultAuth: {
@@ -83,7 +90,7 @@ func GenerateBootstrapKubeconfigFromBootstrapToken(tokenStr string, bootstrapKub
 		Contexts: map[string]*clientcmdapi.Context{infrastructurev1beta1.DefaultContext: {
 			Cluster:   infrastructurev1beta1.DefaultClusterName,
 			AuthInfo:  infrastructurev1beta1.DefaultAuth,
-			Namespace: infrastructurev1beta1.DefaultNamespace,
+			Namespace: namespace,
 		}},
 		CurrentContext: infrastructurev1beta1.DefaultContext,
 	}
@@ -31,6 +31,40 @@ echo Add configuration under well-known name
 (cd "$CONFIG_PATH" && tar -cvf conf.tar *)
 cp "$CONFIG_PATH"/conf.tar .
 
+echo Writing bundle manifest
+deb_version() {
+	if command -v dpkg-deb > /dev/null; then
+		dpkg-deb -f "$1" Version
+	else
+		echo unknown
+	fi
+}
+component() {
+	echo "- name: $1"
+	echo "  version: \"$2\""
+	echo "  type: $3"
+	echo "  file: $4"
+	echo "  sha256: $(sha256sum "$4" | cut -d ' ' -f 1)"
+}
+CONTAINERD_VERSION=$(basename "$INGREDIENTS_PATH"/*containerd* | sed 's/^[^0-9]*\([0-9][0-9.]*[0-9]\).*/\1/')
+{
+	echo "apiVersion: bundle.byoh.infrastructure.cluster.x-k8s.io/v1alpha1"
+	echo "kind: BundleManifest"
+	echo "components:"
+	component conf 1 tar conf.tar
+	echo "  postInstall: sysctl --system"
+	for pkg in cri-tools kubernetes-cni kubectl kubelet kubeadm; do
+		if [ -f "$pkg.deb" ]; then
+			component $pkg "$(deb_version $pkg.deb)" deb $pkg.deb
+		fi
+	done
+	component containerd "$CONTAINERD_VERSION" tar containerd.tar
+	echo "  postInstall: systemctl daemon-reload && systemctl enable containerd && systemctl start containerd"
+	echo "  preUninstall: systemctl stop containerd && systemctl disable containerd && systemctl daemon-reload"
+	echo "  postUninstall: rm -rf /opt/cni/ /opt/containerd/"
+} > bundle.yaml
+cat bundle.yaml
+
 echo Creating bundle tar
 tar -cvf /bundle/bundle.tar *
 
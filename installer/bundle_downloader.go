@@ -21,6 +21,15 @@ type bundleDownloader struct {
 	repoAddr     string
 	downloadPath string
 	logger       logr.Logger
+	// digest pins the bundle to an OCI manifest digest, if set
+	digest string
+	// publicKey is the PEM encoded cosign key used to verify the bundle, if set
+	publicKey string
+	// registryCredentials is the dockerconfigjson used to pull the bundle, if set
+	registryCredentials []byte
+	// componentVersions are the versions of the components installed from their own artifact
+	// instead of the bundle, keyed by component
+	componentVersions map[string]string
 }
 
 // NewBundleDownloader will return a new bundle downloader instance
@@ -33,6 +42,27 @@ func NewBundleDownloader(bundleType, repoAddr, downloadPath string, logger logr.
 	}
 }
 
+// WithVerification pins the bundle to the given digest and sets the public key used
+// to verify its signature. Empty values leave the corresponding check disabled.
+func (bd *bundleDownloader) WithVerification(digest, publicKey string) *bundleDownloader {
+	bd.digest = digest
+	bd.publicKey = publicKey
+	return bd
+}
+
+// WithRegistryCredentials sets the dockerconfigjson used to pull the bundle from an authenticated registry
+func (bd *bundleDownloader) WithRegistryCredentials(dockerConfigJSON []byte) *bundleDownloader {
+	bd.registryCredentials = dockerConfigJSON
+	return bd
+}
+
+// WithComponentVersions installs the components from their own artifact, at the given versions, instead of
+// from the bundle
+func (bd *bundleDownloader) WithComponentVersions(versions map[string]string) *bundleDownloader {
+	bd.componentVersions = versions
+	return bd
+}
+
 // convertError returns known errors in standardized format.
 // func convertError(err error) error {
 // 	downloadErrMap := map[string]Error{
@@ -72,8 +102,23 @@ func (bd *bundleDownloader) getBundlePathWithRepo() string {
 }
 
 // GetBundleAddr returns the exact address to the bundle in the repo.
+// When a digest is pinned it is appended to the tag, so the bundle is pulled by digest.
 func (bd *bundleDownloader) GetBundleAddr(normalizedOsVersion, k8sVersion string) string {
-	return fmt.Sprintf("%s/%s:%s", bd.repoAddr, GetBundleName(normalizedOsVersion), k8sVersion)
+	addr := fmt.Sprintf("%s/%s:%s", bd.repoAddr, GetBundleName(normalizedOsVersion), k8sVersion)
+	if bd.digest != "" {
+		addr = fmt.Sprintf("%s@%s", addr, bd.digest)
+	}
+	return addr
+}
+
+// GetComponentName returns the name of the artifact of the component in normalized format.
+func GetComponentName(component, normalizedOsVersion string) string {
+	return strings.ToLower(fmt.Sprintf("byoh-component-%s-%s", component, normalizedOsVersion))
+}
+
+// GetComponentAddr returns the exact address to the artifact of the component in the repo.
+func (bd *bundleDownloader) GetComponentAddr(component, normalizedOsVersion, version string) string {
+	return fmt.Sprintf("%s/%s:%s", bd.repoAddr, GetComponentName(component, normalizedOsVersion), version)
 }
 
 // checkDirExist checks if a dirrectory exists.
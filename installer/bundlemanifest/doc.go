@@ -0,0 +1,6 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package bundlemanifest parses and validates the bundle.yaml manifest shipped in a bundle,
+// and returns the steps installing the components it lists
+package bundlemanifest
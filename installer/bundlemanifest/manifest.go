@@ -0,0 +1,254 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundlemanifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/steps"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// FileName is the name of the manifest at the root of a bundle
+	FileName = "bundle.yaml"
+	// APIVersion is the version of the manifest format
+	APIVersion = "bundle.byoh.infrastructure.cluster.x-k8s.io/v1alpha1"
+	// Kind is the kind of the manifest
+	Kind = "BundleManifest"
+	// ComponentStepPrefix prefixes the name of the component in the name of its installation step
+	ComponentStepPrefix = "component-"
+)
+
+// ComponentType is the packaging of a bundle component
+type ComponentType string
+
+const (
+	// DebComponent is a debian package, installed with dpkg and held at its version
+	DebComponent ComponentType = "deb"
+	// TarComponent is a tarball extracted at the root of the host filesystem
+	TarComponent ComponentType = "tar"
+)
+
+var (
+	// ErrInvalidManifest is returned when the manifest does not follow the manifest format
+	ErrInvalidManifest = errors.New("invalid bundle manifest")
+	// ErrChecksumMismatch is returned when a component file does not match its checksum
+	ErrChecksumMismatch = errors.New("bundle component checksum mismatch")
+
+	sha256Pattern = regexp.MustCompile(`^[a-f0-9]{64}$`)
+	namePattern   = regexp.MustCompile(`^[a-z0-9][a-z0-9.+-]*$`)
+)
+
+// Manifest describes the content of a bundle
+type Manifest struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// Components are installed in the listed order and uninstalled in the reverse order
+	Components []Component `json:"components"`
+}
+
+// Component is a unit of software shipped in the bundle
+type Component struct {
+	// Name identifies the component in the bundle
+	Name string `json:"name"`
+	// Version is the version of the component, informational only
+	Version string `json:"version"`
+	// Type is the packaging of the component
+	Type ComponentType `json:"type"`
+	// File is the path of the component file, relative to the bundle root
+	File string `json:"file"`
+	// SHA256 is the hex encoded sha256 checksum of the component file
+	SHA256 string `json:"sha256"`
+	// Package is the debian package name of a deb component. Defaults to the component name.
+	Package string `json:"package,omitempty"`
+	// PostInstall is a shell snippet run after the component is installed
+	PostInstall string `json:"postInstall,omitempty"`
+	// PreUninstall is a shell snippet run before the component is uninstalled
+	PreUninstall string `json:"preUninstall,omitempty"`
+	// PostUninstall is a shell snippet run after the component is uninstalled
+	PostUninstall string `json:"postUninstall,omitempty"`
+
+	// dir is the directory of the artifact the component was replaced from, the bundle directory if empty
+	dir string
+}
+
+// Parse parses and validates a manifest. Unknown fields are rejected.
+func Parse(data []byte) (*Manifest, error) {
+	manifest := &Manifest{}
+	if err := yaml.UnmarshalStrict(data, manifest); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+	}
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// Load parses the manifest of the bundle downloaded in bundleDir.
+// It returns an error satisfying errors.Is(err, fs.ErrNotExist) if the bundle has no manifest.
+func Load(bundleDir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(bundleDir, FileName))
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Validate checks the manifest follows the manifest format
+func (m *Manifest) Validate() error {
+	var errs []error
+	if m.APIVersion != APIVersion {
+		errs = append(errs, fmt.Errorf("unsupported apiVersion %q, expected %q", m.APIVersion, APIVersion))
+	}
+	if m.Kind != Kind {
+		errs = append(errs, fmt.Errorf("unsupported kind %q, expected %q", m.Kind, Kind))
+	}
+	if len(m.Components) == 0 {
+		errs = append(errs, errors.New("no components"))
+	}
+	names := map[string]bool{}
+	for i := range m.Components {
+		component := &m.Components[i]
+		if names[component.Name] {
+			errs = append(errs, fmt.Errorf("components[%d]: duplicate name %q", i, component.Name))
+		}
+		names[component.Name] = true
+		if err := component.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("components[%d]: %w", i, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidManifest, errors.Join(errs...))
+	}
+	return nil
+}
+
+func (c *Component) validate() error {
+	var errs []error
+	if !namePattern.MatchString(c.Name) {
+		errs = append(errs, fmt.Errorf("invalid name %q", c.Name))
+	}
+	if c.Version == "" {
+		errs = append(errs, errors.New("version is required"))
+	}
+	switch c.Type {
+	case DebComponent:
+		if c.Package != "" && !namePattern.MatchString(c.Package) {
+			errs = append(errs, fmt.Errorf("invalid package %q", c.Package))
+		}
+	case TarComponent:
+		if c.Package != "" {
+			errs = append(errs, errors.New("package is only supported for deb components"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unsupported type %q, expected %q or %q", c.Type, DebComponent, TarComponent))
+	}
+	if c.File == "" || !filepath.IsLocal(c.File) {
+		errs = append(errs, fmt.Errorf("file %q must be a path within the bundle", c.File))
+	}
+	if !sha256Pattern.MatchString(c.SHA256) {
+		errs = append(errs, fmt.Errorf("sha256 %q must be 64 lowercase hex characters", c.SHA256))
+	}
+	return errors.Join(errs...)
+}
+
+// Replace replaces the components of the manifest with the components of the same name of the
+// manifest of an artifact downloaded in artifactDir, so that their version can be chosen independently
+// of the bundle. Components of the artifact which are not part of the manifest are ignored.
+func (m *Manifest) Replace(artifact *Manifest, artifactDir string) {
+	for _, replacement := range artifact.Components {
+		for i := range m.Components {
+			if m.Components[i].Name == replacement.Name {
+				m.Components[i] = replacement
+				m.Components[i].dir = artifactDir
+			}
+		}
+	}
+}
+
+// VerifyChecksums checks the component files in bundleDir match the manifest checksums
+func (m *Manifest) VerifyChecksums(bundleDir string) error {
+	for _, component := range m.Components {
+		sum, err := fileSHA256(component.path(bundleDir))
+		if err != nil {
+			return fmt.Errorf("component %s: %w", component.Name, err)
+		}
+		if sum != component.SHA256 {
+			return fmt.Errorf("%w: component %s file %s has sha256 %s, expected %s", ErrChecksumMismatch, component.Name, component.File, sum, component.SHA256)
+		}
+	}
+	return nil
+}
+
+// Plan returns the steps installing the components of the bundle downloaded in bundleDir.
+// Components are uninstalled in the reverse order of installation.
+func (m *Manifest) Plan(bundleDir string) *steps.Plan {
+	plan := &steps.Plan{Preamble: "\nset -euox pipefail"}
+	for _, component := range m.Components {
+		path := steps.ShellQuote(component.path(bundleDir))
+		step := steps.Step{Name: ComponentStepPrefix + component.Name}
+		switch component.Type {
+		case DebComponent:
+			step.Apply = fmt.Sprintf("dpkg --install %s && apt-mark hold %s", path, steps.ShellQuote(component.packageName()))
+			step.Verify = fmt.Sprintf("dpkg -s %s >>/dev/null", steps.ShellQuote(component.packageName()))
+			step.Undo = fmt.Sprintf("dpkg --purge %s", steps.ShellQuote(component.packageName()))
+		case TarComponent:
+			step.Apply = fmt.Sprintf("tar -C / -xvf %s", path)
+			// remove the files extracted from the tarball, directories may be shared and are kept
+			step.Undo = fmt.Sprintf(`tar tf %s | xargs -n 1 echo '/' | sed 's/ //g' | grep -e '[^/]$' | xargs rm -f`, path)
+		}
+		step.Apply = joinCommands(step.Apply, component.PostInstall)
+		step.Undo = joinCommands(component.PreUninstall, step.Undo, component.PostUninstall)
+		plan.Steps = append(plan.Steps, step)
+	}
+	return plan
+}
+
+// path returns the path of the component file, in the directory of the artifact it was replaced from if any
+func (c *Component) path(bundleDir string) string {
+	if c.dir != "" {
+		return filepath.Join(c.dir, c.File)
+	}
+	return filepath.Join(bundleDir, c.File)
+}
+
+func (c *Component) packageName() string {
+	if c.Package != "" {
+		return c.Package
+	}
+	return c.Name
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// joinCommands joins the non empty commands in a single snippet
+func joinCommands(commands ...string) string {
+	lines := []string{}
+	for _, command := range commands {
+		if command = strings.TrimSpace(command); command != "" {
+			lines = append(lines, command)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,191 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundlemanifest_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/bundlemanifest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bundle manifest", func() {
+	var (
+		bundleDir      string
+		kubeletContent = []byte("kubelet deb")
+		confContent    = []byte("conf tar")
+	)
+
+	checksum := func(content []byte) string {
+		sum := sha256.Sum256(content)
+		return hex.EncodeToString(sum[:])
+	}
+
+	validManifest := func() string {
+		return `apiVersion: ` + bundlemanifest.APIVersion + `
+kind: ` + bundlemanifest.Kind + `
+components:
+- name: conf
+  version: "1.0"
+  type: tar
+  file: conf.tar
+  sha256: ` + checksum(confContent) + `
+  postInstall: sysctl --system
+- name: kubelet
+  version: 1.32.0-00
+  type: deb
+  file: debs/kubelet.deb
+  sha256: ` + checksum(kubeletContent) + `
+  preUninstall: systemctl stop kubelet
+`
+	}
+
+	BeforeEach(func() {
+		bundleDir = GinkgoT().TempDir()
+		Expect(os.MkdirAll(filepath.Join(bundleDir, "debs"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(bundleDir, "debs", "kubelet.deb"), kubeletContent, 0o600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(bundleDir, "conf.tar"), confContent, 0o600)).To(Succeed())
+	})
+
+	Context("When parsing a manifest", func() {
+		It("should parse the components in install order", func() {
+			manifest, err := bundlemanifest.Parse([]byte(validManifest()))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.Components).To(HaveLen(2))
+			Expect(manifest.Components[0].Name).To(Equal("conf"))
+			Expect(manifest.Components[1].Type).To(Equal(bundlemanifest.DebComponent))
+		})
+
+		It("should reject unknown fields", func() {
+			_, err := bundlemanifest.Parse([]byte(validManifest() + "extra: field\n"))
+			Expect(err).To(MatchError(bundlemanifest.ErrInvalidManifest))
+		})
+
+		DescribeTable("should reject invalid manifests",
+			func(from, to string) {
+				_, err := bundlemanifest.Parse([]byte(strings.Replace(validManifest(), from, to, 1)))
+				Expect(err).To(MatchError(bundlemanifest.ErrInvalidManifest))
+			},
+			Entry("unsupported version", "/v1alpha1", "/v2"),
+			Entry("unsupported kind", "kind: "+bundlemanifest.Kind, "kind: Other"),
+			Entry("unsupported type", "type: deb", "type: rpm"),
+			Entry("file outside the bundle", "file: conf.tar", "file: ../conf.tar"),
+			Entry("absolute file", "file: conf.tar", "file: /etc/conf.tar"),
+			Entry("duplicate name", "name: kubelet", "name: conf"),
+			Entry("missing version", `version: "1.0"`, `version: ""`),
+			Entry("invalid checksum", "sha256: "+checksum(confContent), "sha256: abc"),
+		)
+
+		It("should report a missing manifest", func() {
+			_, err := bundlemanifest.Load(bundleDir)
+			Expect(err).To(MatchError(fs.ErrNotExist))
+		})
+	})
+
+	Context("When verifying checksums", func() {
+		It("should succeed if the component files match", func() {
+			Expect(os.WriteFile(filepath.Join(bundleDir, bundlemanifest.FileName), []byte(validManifest()), 0o600)).To(Succeed())
+			manifest, err := bundlemanifest.Load(bundleDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.VerifyChecksums(bundleDir)).To(Succeed())
+		})
+
+		It("should fail if a component file was altered", func() {
+			manifest, err := bundlemanifest.Parse([]byte(validManifest()))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(bundleDir, "conf.tar"), []byte("tampered"), 0o600)).To(Succeed())
+			Expect(manifest.VerifyChecksums(bundleDir)).To(MatchError(bundlemanifest.ErrChecksumMismatch))
+		})
+
+		It("should fail if a component file is missing", func() {
+			manifest, err := bundlemanifest.Parse([]byte(validManifest()))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.Remove(filepath.Join(bundleDir, "debs", "kubelet.deb"))).To(Succeed())
+			Expect(manifest.VerifyChecksums(bundleDir)).To(MatchError(fs.ErrNotExist))
+		})
+	})
+
+	Context("When planning the installation", func() {
+		var manifest *bundlemanifest.Manifest
+
+		BeforeEach(func() {
+			var err error
+			manifest, err = bundlemanifest.Parse([]byte(validManifest()))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should install the components in order", func() {
+			script := manifest.Plan("/var/lib/byoh/bundles/repo:v1").InstallScript()
+			conf := strings.Index(script, "tar -C / -xvf '/var/lib/byoh/bundles/repo:v1/conf.tar'")
+			sysctl := strings.Index(script, "sysctl --system")
+			kubelet := strings.Index(script, "dpkg --install '/var/lib/byoh/bundles/repo:v1/debs/kubelet.deb' && apt-mark hold 'kubelet'")
+			Expect(conf).To(BeNumerically(">", 0))
+			Expect(sysctl).To(BeNumerically(">", conf))
+			Expect(kubelet).To(BeNumerically(">", sysctl))
+		})
+
+		It("should uninstall the components in reverse order", func() {
+			script := manifest.Plan("/var/lib/byoh/bundles/repo:v1").UninstallScript()
+			stop := strings.Index(script, "systemctl stop kubelet")
+			kubelet := strings.Index(script, "dpkg --purge 'kubelet'")
+			conf := strings.Index(script, "tar tf '/var/lib/byoh/bundles/repo:v1/conf.tar'")
+			Expect(stop).To(BeNumerically(">", 0))
+			Expect(kubelet).To(BeNumerically(">", stop))
+			Expect(conf).To(BeNumerically(">", kubelet))
+		})
+
+		It("should name the steps after the components", func() {
+			plan := manifest.Plan("/var/lib/byoh/bundles/repo:v1")
+			Expect(plan.Steps).To(HaveLen(2))
+			Expect(plan.Steps[1].Name).To(Equal(bundlemanifest.ComponentStepPrefix + "kubelet"))
+			Expect(plan.Steps[1].Verify).To(Equal("dpkg -s 'kubelet' >>/dev/null"))
+		})
+
+		It("should install the replaced components from their artifact", func() {
+			artifact, err := bundlemanifest.Parse([]byte(`apiVersion: ` + bundlemanifest.APIVersion + `
+kind: ` + bundlemanifest.Kind + `
+components:
+- name: kubelet
+  version: 1.32.1-00
+  type: deb
+  file: kubelet.deb
+  sha256: ` + checksum(kubeletContent) + `
+- name: other
+  version: "1"
+  type: tar
+  file: other.tar
+  sha256: ` + checksum(confContent) + `
+`))
+			Expect(err).NotTo(HaveOccurred())
+			manifest.Replace(artifact, "/var/lib/byoh/bundles/kubelet:v1.32.1")
+
+			plan := manifest.Plan("/var/lib/byoh/bundles/repo:v1")
+			Expect(plan.Steps).To(HaveLen(2))
+			Expect(plan.Steps[0].Apply).To(ContainSubstring("'/var/lib/byoh/bundles/repo:v1/conf.tar'"))
+			Expect(plan.Steps[1].Apply).To(ContainSubstring("dpkg --install '/var/lib/byoh/bundles/kubelet:v1.32.1/kubelet.deb'"))
+			// the hooks of the bundle component are replaced too
+			Expect(plan.Steps[1].Undo).NotTo(ContainSubstring("systemctl stop kubelet"))
+		})
+
+		It("should verify the checksums of the replaced components in their artifact", func() {
+			artifactDir := GinkgoT().TempDir()
+			Expect(os.WriteFile(filepath.Join(artifactDir, "kubelet.deb"), []byte("kubelet deb v2"), 0o600)).To(Succeed())
+			manifest.Replace(&bundlemanifest.Manifest{Components: []bundlemanifest.Component{
+				{Name: "kubelet", Version: "2", Type: bundlemanifest.DebComponent, File: "kubelet.deb", SHA256: checksum([]byte("kubelet deb v2"))},
+			}}, artifactDir)
+			Expect(manifest.VerifyChecksums(bundleDir)).To(Succeed())
+		})
+
+		It("should quote the bundle paths", func() {
+			script := manifest.Plan("/tmp/it's here").InstallScript()
+			Expect(script).To(ContainSubstring(`'/tmp/it'\''s here/conf.tar'`))
+		})
+	})
+})
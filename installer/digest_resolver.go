@@ -0,0 +1,257 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package installer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// dockerHubRegistry is the registry used when a bundle address has no registry host
+	dockerHubRegistry = "registry-1.docker.io"
+	// digestHeader is the header carrying the manifest digest in OCI distribution responses
+	digestHeader = "Docker-Content-Digest"
+)
+
+// manifestMediaTypes are the manifest media types accepted when resolving a bundle digest
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// DigestResolver resolves a bundle address to the digest of the manifest it currently points to.
+// dockerConfigJSON holds optional credentials for the registry hosting the bundle.
+type DigestResolver interface {
+	ResolveDigest(ctx context.Context, bundleAddr string, dockerConfigJSON []byte) (string, error)
+}
+
+// registryDigestResolver resolves digests using the OCI distribution API of the registry
+type registryDigestResolver struct {
+	client *http.Client
+}
+
+// NewRegistryDigestResolver returns a DigestResolver querying the registry hosting the bundle
+func NewRegistryDigestResolver() DigestResolver {
+	return &registryDigestResolver{client: http.DefaultClient}
+}
+
+// ResolveDigest returns the manifest digest of the bundle address.
+// If the address already carries a digest, it is returned as is.
+func (r *registryDigestResolver) ResolveDigest(ctx context.Context, bundleAddr string, dockerConfigJSON []byte) (string, error) {
+	registry, repository, reference := parseBundleAddr(bundleAddr)
+	if strings.HasPrefix(reference, "sha256:") {
+		return reference, nil
+	}
+	username, password, err := registryAuth(dockerConfigJSON, registry)
+	if err != nil {
+		return "", err
+	}
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", registry, repository, reference)
+
+	resp, err := r.getManifest(ctx, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		_ = resp.Body.Close()
+		authorization, authErr := r.authorize(ctx, resp.Header.Get("WWW-Authenticate"), username, password)
+		if authErr != nil {
+			return "", authErr
+		}
+		if resp, err = r.getManifest(ctx, manifestURL, authorization); err != nil {
+			return "", err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: unexpected status %d for %s", ErrBundleDigestResolve, resp.StatusCode, bundleAddr)
+	}
+	if digest := resp.Header.Get(digestHeader); digest != "" {
+		return digest, nil
+	}
+	// not all registries return the digest header, fall back to hashing the manifest
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrBundleDigestResolve, err)
+	}
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+func (r *registryDigestResolver) getManifest(ctx context.Context, manifestURL, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, manifestURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBundleDigestResolve, err)
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ","))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBundleDigestResolve, err)
+	}
+	return resp, nil
+}
+
+// authorize returns the Authorization header value answering the registry auth challenge
+func (r *registryDigestResolver) authorize(ctx context.Context, challenge, username, password string) (string, error) {
+	scheme, params := parseAuthChallenge(challenge)
+	switch {
+	case strings.EqualFold(scheme, "Bearer"):
+		token, err := r.fetchToken(ctx, params, username, password)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	case strings.EqualFold(scheme, "Basic") && username != "":
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password)), nil
+	default:
+		return "", fmt.Errorf("%w: unsupported auth challenge %q", ErrBundleDigestResolve, challenge)
+	}
+}
+
+// fetchToken requests a bearer token from the realm advertised by the registry,
+// authenticating with the registry credentials if any
+func (r *registryDigestResolver) fetchToken(ctx context.Context, params map[string]string, username, password string) (string, error) {
+	realm, ok := params["realm"]
+	if !ok {
+		return "", fmt.Errorf("%w: auth challenge has no realm", ErrBundleDigestResolve)
+	}
+	tokenURL, err := url.Parse(realm)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrBundleDigestResolve, err)
+	}
+	query := tokenURL.Query()
+	for _, key := range []string{"service", "scope"} {
+		if value, exists := params[key]; exists {
+			query.Set(key, value)
+		}
+	}
+	tokenURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrBundleDigestResolve, err)
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrBundleDigestResolve, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: unexpected status %d fetching registry token", ErrBundleDigestResolve, resp.StatusCode)
+	}
+
+	tokenResp := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrBundleDigestResolve, err)
+	}
+	if tokenResp.Token != "" {
+		return tokenResp.Token, nil
+	}
+	return tokenResp.AccessToken, nil
+}
+
+// parseBundleAddr splits a bundle address into registry host, repository and tag or digest
+func parseBundleAddr(bundleAddr string) (registry, repository, reference string) {
+	name := bundleAddr
+	if idx := strings.Index(name, "@"); idx >= 0 {
+		name, reference = name[:idx], name[idx+1:]
+	}
+	if idx := strings.LastIndex(name, ":"); idx > strings.LastIndex(name, "/") {
+		if reference == "" {
+			reference = name[idx+1:]
+		}
+		name = name[:idx]
+	}
+	if reference == "" {
+		reference = "latest"
+	}
+
+	registry, repository = dockerHubRegistry, name
+	if idx := strings.Index(name, "/"); idx >= 0 {
+		host := name[:idx]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			registry, repository = host, name[idx+1:]
+		}
+	}
+	if registry == dockerHubRegistry && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+	return registry, repository, reference
+}
+
+// parseAuthChallenge parses a `<scheme> key="value",...` WWW-Authenticate header
+func parseAuthChallenge(challenge string) (scheme string, params map[string]string) {
+	params = map[string]string{}
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	for _, part := range strings.Split(rest, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		params[strings.ToLower(key)] = strings.Trim(value, `"`)
+	}
+	return scheme, params
+}
+
+// registryAuth returns the username and password for the registry from a dockerconfigjson.
+// Empty values are returned if there are no credentials for the registry.
+func registryAuth(dockerConfigJSON []byte, registry string) (username, password string, err error) {
+	if len(dockerConfigJSON) == 0 {
+		return "", "", nil
+	}
+	config := struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}{}
+	if err := json.Unmarshal(dockerConfigJSON, &config); err != nil {
+		return "", "", fmt.Errorf("%w: invalid registry credentials: %v", ErrBundleDigestResolve, err)
+	}
+	for server, entry := range config.Auths {
+		if credentialsHost(server) != registry && (registry != dockerHubRegistry || credentialsHost(server) != "index.docker.io") {
+			continue
+		}
+		if entry.Auth == "" {
+			return entry.Username, entry.Password, nil
+		}
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return "", "", fmt.Errorf("%w: invalid registry credentials for %s: %v", ErrBundleDigestResolve, server, err)
+		}
+		username, password, _ = strings.Cut(string(decoded), ":")
+		return username, password, nil
+	}
+	return "", "", nil
+}
+
+// credentialsHost returns the registry host of a dockerconfigjson auths key,
+// which may be a bare host or a URL
+func credentialsHost(server string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	host, _, _ = strings.Cut(host, "/")
+	return host
+}
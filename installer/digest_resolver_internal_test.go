@@ -0,0 +1,171 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package installer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bundle digest resolver", func() {
+	Context("When parsing bundle addresses", func() {
+		It("should split registry, repository and tag", func() {
+			registry, repository, reference := parseBundleAddr("projects.registry.vmware.com/cluster_api_provider_bringyourownhost/byoh-bundle-ubuntu_20.04.1_x86-64_k8s:v1.23.5")
+			Expect(registry).To(Equal("projects.registry.vmware.com"))
+			Expect(repository).To(Equal("cluster_api_provider_bringyourownhost/byoh-bundle-ubuntu_20.04.1_x86-64_k8s"))
+			Expect(reference).To(Equal("v1.23.5"))
+		})
+
+		It("should handle registries with a port", func() {
+			registry, repository, reference := parseBundleAddr("localhost:5000/byoh-bundle:v1.23.5")
+			Expect(registry).To(Equal("localhost:5000"))
+			Expect(repository).To(Equal("byoh-bundle"))
+			Expect(reference).To(Equal("v1.23.5"))
+		})
+
+		It("should default to docker hub", func() {
+			registry, repository, reference := parseBundleAddr("byoh-bundle")
+			Expect(registry).To(Equal(dockerHubRegistry))
+			Expect(repository).To(Equal("library/byoh-bundle"))
+			Expect(reference).To(Equal("latest"))
+		})
+
+		It("should prefer the digest over the tag", func() {
+			_, _, reference := parseBundleAddr("registry.local/byoh-bundle:v1.23.5@sha256:abc")
+			Expect(reference).To(Equal("sha256:abc"))
+		})
+	})
+
+	Context("When resolving digests against a registry", func() {
+		var (
+			srv      *httptest.Server
+			resolver *registryDigestResolver
+			manifest = []byte(`{"schemaVersion":2}`)
+			digest   = "sha256:" + strings.Repeat("c", 64)
+			handler  http.HandlerFunc
+		)
+
+		BeforeEach(func() {
+			srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handler(w, r)
+			}))
+			resolver = &registryDigestResolver{client: srv.Client()}
+		})
+
+		AfterEach(func() {
+			srv.Close()
+		})
+
+		bundleAddr := func() string {
+			return strings.TrimPrefix(srv.URL, "https://") + "/byoh/byoh-bundle:v1.23.5"
+		}
+
+		It("should return the digest header of the manifest", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/v2/byoh/byoh-bundle/manifests/v1.23.5"))
+				w.Header().Set(digestHeader, digest)
+				_, _ = w.Write(manifest)
+			}
+			Expect(resolver.ResolveDigest(context.TODO(), bundleAddr(), nil)).To(Equal(digest))
+		})
+
+		It("should hash the manifest when the digest header is missing", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(manifest)
+			}
+			sum := sha256.Sum256(manifest)
+			Expect(resolver.ResolveDigest(context.TODO(), bundleAddr(), nil)).To(Equal("sha256:" + hex.EncodeToString(sum[:])))
+		})
+
+		It("should fetch a bearer token when the registry requires it", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/token":
+					Expect(r.URL.Query().Get("scope")).To(Equal("repository:byoh/byoh-bundle:pull"))
+					_, _ = w.Write([]byte(`{"token":"test-token"}`))
+				case r.Header.Get("Authorization") != "Bearer test-token":
+					w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:byoh/byoh-bundle:pull"`, srv.URL))
+					w.WriteHeader(http.StatusUnauthorized)
+				default:
+					w.Header().Set(digestHeader, digest)
+				}
+			}
+			Expect(resolver.ResolveDigest(context.TODO(), bundleAddr(), nil)).To(Equal(digest))
+		})
+
+		It("should authenticate with the registry credentials", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				username, password, ok := r.BasicAuth()
+				if !ok || username != "user" || password != "pass" {
+					w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Header().Set(digestHeader, digest)
+			}
+			host := strings.TrimPrefix(srv.URL, "https://")
+			dockerConfig := []byte(fmt.Sprintf(`{"auths":{"https://%s":{"auth":"dXNlcjpwYXNz"}}}`, host))
+			Expect(resolver.ResolveDigest(context.TODO(), bundleAddr(), dockerConfig)).To(Equal(digest))
+		})
+
+		It("should return error when the registry requires credentials that are not set", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+			_, err := resolver.ResolveDigest(context.TODO(), bundleAddr(), nil)
+			Expect(err).To(MatchError(ErrBundleDigestResolve))
+		})
+
+		It("should return error when the manifest is not found", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			}
+			_, err := resolver.ResolveDigest(context.TODO(), bundleAddr(), nil)
+			Expect(err).To(MatchError(ErrBundleDigestResolve))
+		})
+
+		It("should not query the registry when the address has a digest", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				Fail("registry should not be queried")
+			}
+			Expect(resolver.ResolveDigest(context.TODO(), bundleAddr()+"@"+digest, nil)).To(Equal(digest))
+		})
+	})
+
+	Context("When reading registry credentials", func() {
+		It("should decode the auth field", func() {
+			username, password, err := registryAuth([]byte(`{"auths":{"registry.local":{"auth":"dXNlcjpwYXNz"}}}`), "registry.local")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(username).To(Equal("user"))
+			Expect(password).To(Equal("pass"))
+		})
+
+		It("should use the username and password fields", func() {
+			username, password, err := registryAuth([]byte(`{"auths":{"https://index.docker.io/v1/":{"username":"user","password":"pass"}}}`), dockerHubRegistry)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(username).To(Equal("user"))
+			Expect(password).To(Equal("pass"))
+		})
+
+		It("should return no credentials for other registries", func() {
+			username, _, err := registryAuth([]byte(`{"auths":{"registry.local":{"auth":"dXNlcjpwYXNz"}}}`), "other.local")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(username).To(BeEmpty())
+		})
+
+		It("should return error for invalid credentials", func() {
+			_, _, err := registryAuth([]byte(`not json`), "registry.local")
+			Expect(err).To(MatchError(ErrBundleDigestResolve))
+		})
+	})
+})
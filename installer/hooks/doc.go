@@ -0,0 +1,6 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package hooks holds the site-specific scripts run by the byoh agent around the k8s installation
+// and uninstallation. The installer controller stores them in the installation secret.
+package hooks
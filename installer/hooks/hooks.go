@@ -0,0 +1,120 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidHooks is returned when hooks cannot be parsed or have invalid entries
+var ErrInvalidHooks = errors.New("invalid installer hooks")
+
+// Point is the moment of the installation a hook is run at
+type Point string
+
+const (
+	// PreInstall hooks are run before the k8s components are installed
+	PreInstall Point = "pre-install"
+	// PostInstall hooks are run once the k8s components are installed
+	PostInstall Point = "post-install"
+	// PreUninstall hooks are run before the k8s components are uninstalled
+	PreUninstall Point = "pre-uninstall"
+	// PostUninstall hooks are run once the k8s components are uninstalled
+	PostUninstall Point = "post-uninstall"
+)
+
+// CmdRunner runs a bash command on the host
+type CmdRunner interface {
+	RunCmd(ctx context.Context, cmd string) error
+}
+
+// Hook is a bash script run on the host
+type Hook struct {
+	// Name identifies the hook in the events
+	Name string `json:"name"`
+	// Script is the bash script of the hook
+	Script string `json:"script"`
+}
+
+// Hooks are the hooks of each point, run in order
+type Hooks struct {
+	PreInstall    []Hook `json:"preInstall,omitempty"`
+	PostInstall   []Hook `json:"postInstall,omitempty"`
+	PreUninstall  []Hook `json:"preUninstall,omitempty"`
+	PostUninstall []Hook `json:"postUninstall,omitempty"`
+}
+
+// Parse parses and validates hooks marshaled with Marshal
+func Parse(data []byte) (*Hooks, error) {
+	hooks := &Hooks{}
+	if err := json.Unmarshal(data, hooks); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHooks, err)
+	}
+	if err := hooks.Validate(); err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// Marshal returns the hooks serialized to be stored in the installation secret
+func (h *Hooks) Marshal() ([]byte, error) {
+	return json.Marshal(h)
+}
+
+// Validate checks the hooks of each point have unique names and a script
+func (h *Hooks) Validate() error {
+	for _, point := range []Point{PreInstall, PostInstall, PreUninstall, PostUninstall} {
+		names := map[string]bool{}
+		for i, hook := range h.At(point) {
+			if hook.Name == "" {
+				return fmt.Errorf("%w: %s[%d] has no name", ErrInvalidHooks, point, i)
+			}
+			if names[hook.Name] {
+				return fmt.Errorf("%w: duplicate %s hook %q", ErrInvalidHooks, point, hook.Name)
+			}
+			names[hook.Name] = true
+			if strings.TrimSpace(hook.Script) == "" {
+				return fmt.Errorf("%w: %s hook %q has no script", ErrInvalidHooks, point, hook.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// At returns the hooks run at the point
+func (h *Hooks) At(point Point) []Hook {
+	if h == nil {
+		return nil
+	}
+	switch point {
+	case PreInstall:
+		return h.PreInstall
+	case PostInstall:
+		return h.PostInstall
+	case PreUninstall:
+		return h.PreUninstall
+	case PostUninstall:
+		return h.PostUninstall
+	}
+	return nil
+}
+
+// Run runs the hooks of the point in order, stopping at the first failing one.
+// onResult, if set, is called with the result of each hook run.
+func (h *Hooks) Run(ctx context.Context, runner CmdRunner, point Point, onResult func(hook Hook, err error)) error {
+	for _, hook := range h.At(point) {
+		err := runner.RunCmd(ctx, hook.Script)
+		if onResult != nil {
+			onResult(hook, err)
+		}
+		if err != nil {
+			return fmt.Errorf("%s hook %s failed: %w", point, hook.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package hooks_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/hooks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeRunner records the commands it runs and fails the commands listed in failing
+type fakeRunner struct {
+	failing  map[string]bool
+	commands []string
+}
+
+func (f *fakeRunner) RunCmd(_ context.Context, cmd string) error {
+	f.commands = append(f.commands, cmd)
+	if f.failing[cmd] {
+		return errors.New("command failed")
+	}
+	return nil
+}
+
+var _ = Describe("Hooks", func() {
+	var (
+		installerHooks *hooks.Hooks
+		runner         *fakeRunner
+	)
+
+	BeforeEach(func() {
+		installerHooks = &hooks.Hooks{
+			PreInstall: []hooks.Hook{
+				{Name: "ca-bundle", Script: "update-ca-certificates"},
+				{Name: "auditd", Script: "augenrules --load"},
+			},
+			PostUninstall: []hooks.Hook{{Name: "cleanup", Script: "rm -rf /etc/site"}},
+		}
+		runner = &fakeRunner{failing: map[string]bool{}}
+	})
+
+	It("should round trip through the installation secret", func() {
+		data, err := installerHooks.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		parsed, err := hooks.Parse(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed).To(Equal(installerHooks))
+	})
+
+	It("should reject hooks without script", func() {
+		installerHooks.PostInstall = []hooks.Hook{{Name: "empty"}}
+		data, err := installerHooks.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		_, err = hooks.Parse(data)
+		Expect(err).To(MatchError(hooks.ErrInvalidHooks))
+	})
+
+	It("should run the hooks of the point in order", func() {
+		results := []string{}
+		err := installerHooks.Run(context.TODO(), runner, hooks.PreInstall, func(hook hooks.Hook, err error) {
+			results = append(results, hook.Name)
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runner.commands).To(Equal([]string{"update-ca-certificates", "augenrules --load"}))
+		Expect(results).To(Equal([]string{"ca-bundle", "auditd"}))
+	})
+
+	It("should stop at the first failing hook", func() {
+		runner.failing["update-ca-certificates"] = true
+		err := installerHooks.Run(context.TODO(), runner, hooks.PreInstall, nil)
+		Expect(err).To(MatchError(ContainSubstring("pre-install hook ca-bundle failed")))
+		Expect(runner.commands).To(Equal([]string{"update-ca-certificates"}))
+	})
+
+	It("should run nothing without hooks", func() {
+		var none *hooks.Hooks
+		Expect(none.Run(context.TODO(), runner, hooks.PostInstall, nil)).To(Succeed())
+		Expect(runner.commands).To(BeEmpty())
+	})
+})
@@ -5,15 +5,23 @@ package installer
 
 import (
 	"context"
+	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/internal/algo"
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/steps"
 )
 
 // K8sInstaller represent k8s installer interface
 type K8sInstaller interface {
 	Install() string
 	Uninstall() string
+	// Plan returns the installation steps, Install and Uninstall are rendered from it
+	Plan() *steps.Plan
+	// UpgradePlan returns the steps upgrading in place the k8s components installed from a previous bundle,
+	// nil if in-place upgrade is not supported
+	UpgradePlan() *steps.Plan
 }
 
 // Error string wrapper for errors returned by the installer
@@ -27,8 +35,14 @@ type BundleType string
 const (
 	// BundleTypeK8s represents a vanilla k8s bundle
 	BundleTypeK8s BundleType = "k8s"
+	// BundleTypeRepo represents k8s packages installed from a package repository instead of a bundle
+	BundleTypeRepo BundleType = "repo"
 )
 
+// BundleCacheMarkerFile is the file the install script writes in the bundle directory
+// once the bundle is completely downloaded. It holds the digest of the bundle.
+const BundleCacheMarkerFile = algo.BundleCacheMarkerFile
+
 const (
 	// ErrDetectOs error type when supported OS could not be detected
 	ErrDetectOs = Error("Error detecting OS")
@@ -42,29 +56,166 @@ const (
 	ErrBundleInstall = Error("Error installing bundle")
 	// ErrBundleUninstall error type when the bundle uninstallation fails
 	ErrBundleUninstall = Error("Error uninstalling bundle")
+	// ErrBundleDigestResolve error type when the bundle digest could not be resolved from the registry
+	ErrBundleDigestResolve = Error("Error resolving bundle digest")
+	// ErrPackageRepositoryMissing error type when the repo bundle type has no package repository
+	ErrPackageRepositoryMissing = Error("No package repository configured")
+	// ErrComponentNotSupported error type when a component version is not compatible with the k8s version
+	ErrComponentNotSupported = Error("Component version not supported")
+)
+
+// Components of the bundle whose version can be chosen independently of the bundle.
+// Each version is installed from its own OCI artifact, see GetComponentAddrs.
+const (
+	// ComponentContainerd is the containerd tarball
+	ComponentContainerd = "containerd"
+	// ComponentCNIPlugins is the deb package of the CNI plugins
+	ComponentCNIPlugins = "kubernetes-cni"
+	// ComponentCRITools is the deb package of crictl
+	ComponentCRITools = "cri-tools"
 )
 
+// ContainerdConfig holds the containerd settings rendered into the containerd configuration of the host
+type ContainerdConfig = algo.ContainerdConfig
+
+// Option customizes the installation
+type Option func(*algo.InstallerParams)
+
+// WithContainerd renders the containerd settings into the containerd configuration,
+// instead of using the configuration shipped in the bundle
+func WithContainerd(config *ContainerdConfig) Option {
+	return func(params *algo.InstallerParams) {
+		params.Containerd = config
+	}
+}
+
+// ProxyConfig holds the HTTP proxy used by the installation, containerd and kubelet of the host
+type ProxyConfig = algo.ProxyConfig
+
+// WithProxy makes the installation, containerd and kubelet go through the HTTP proxy
+func WithProxy(config *ProxyConfig) Option {
+	return func(params *algo.InstallerParams) {
+		params.Proxy = config
+	}
+}
+
+// PackageRepository holds the apt repository the k8s packages are installed from with the repo bundle type
+type PackageRepository = algo.PackageRepository
+
+// WithPackageRepository installs the k8s packages from the package repository, required by the repo bundle type
+func WithPackageRepository(repository *PackageRepository) Option {
+	return func(params *algo.InstallerParams) {
+		params.Repository = repository
+	}
+}
+
 // archOldNameMap keeps the mapping of architecture new name to old name mapping
 var archOldNameMap = map[string]string{
 	"amd64": "x86-64",
 }
 
 // NewInstaller will return a new installer
-func NewInstaller(ctx context.Context, osDist, arch, k8sVersion string, downloader *bundleDownloader) (K8sInstaller, error) {
+func NewInstaller(ctx context.Context, osDist, arch, k8sVersion string, downloader *bundleDownloader, opts ...Option) (K8sInstaller, error) {
+	addrs, err := GetBundleAddr(osDist, arch, k8sVersion, downloader)
+	if err != nil {
+		return nil, err
+	}
+
+	params := algo.InstallerParams{
+		Arch:                arch,
+		BundleAddrs:         addrs,
+		BundlePublicKey:     downloader.publicKey,
+		RegistryCredentials: len(downloader.registryCredentials) > 0,
+		K8sVersion:          k8sVersion,
+	}
+	for _, opt := range opts {
+		opt(&params)
+	}
+	if downloader.bundleType != BundleTypeRepo {
+		params.Repository = nil
+	} else if params.Repository == nil {
+		return nil, ErrPackageRepositoryMissing
+	}
+	if len(downloader.componentVersions) > 0 {
+		if err = ValidateComponentVersions(downloader.bundleType, k8sVersion, downloader.componentVersions); err != nil {
+			return nil, err
+		}
+		params.Components = GetComponentAddrs(osDist, arch, downloader)
+	}
+	return algo.NewUbuntu20_04Installer(ctx, params)
+}
+
+// GetBundleAddr returns the address of the bundle matching the host OS, arch and k8s version.
+// An empty address is returned for the repo bundle type, which has no bundle.
+func GetBundleAddr(osDist, arch, k8sVersion string, downloader *bundleDownloader) (string, error) {
+	osArch := normalizeOsArch(osDist, arch)
+	reg := GetSupportedRegistry()
+	if downloader.bundleType == BundleTypeRepo {
+		// any k8s version of the package repository can be installed on the supported OSes
+		if reg.ResolveOsToOsBundle(osArch) == "" {
+			return "", ErrOsK8sNotSupported
+		}
+		return "", nil
+	}
+	if len(reg.ListK8s(osArch)) == 0 {
+		return "", ErrOsK8sNotSupported
+	}
+	osbundle := reg.ResolveOsToOsBundle(osArch)
+	return downloader.GetBundleAddr(osbundle, k8sVersion), nil
+}
+
+// ResolveOsBundle returns the OS of the bundles installed on hosts with the given OS and arch,
+// empty if the OS is not supported
+func ResolveOsBundle(osDist, arch string) string {
+	reg := GetSupportedRegistry()
+	return reg.ResolveOsToOsBundle(normalizeOsArch(osDist, arch))
+}
+
+// IsK8sVersionSupported returns true if bundles of the k8s version are published for the OS and arch
+func IsK8sVersionSupported(osDist, arch, k8sVersion string) bool {
+	reg := GetSupportedRegistry()
+	for _, k8sFilter := range reg.ListK8s(normalizeOsArch(osDist, arch)) {
+		if matched, _ := regexp.MatchString("^"+k8sFilter+"$", k8sVersion); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeOsArch returns the host OS and arch in the format of the bundle names
+func normalizeOsArch(osDist, arch string) string {
 	bundleArchName := arch
 	// replacing the arch name to old name to match with the bundle name
 	if _, exists := archOldNameMap[arch]; exists {
 		bundleArchName = archOldNameMap[arch]
 	}
 	// normalizing os image name and adding arch
-	osArch := strings.ReplaceAll(osDist, " ", "_") + "_" + bundleArchName
+	return strings.ReplaceAll(osDist, " ", "_") + "_" + bundleArchName
+}
 
-	reg := GetSupportedRegistry()
-	if len(reg.ListK8s(osArch)) == 0 {
-		return nil, ErrOsK8sNotSupported
+// ValidateComponentVersions checks the versions of the components chosen independently of the bundle
+// are compatible with the k8s version
+func ValidateComponentVersions(bundleType BundleType, k8sVersion string, versions map[string]string) error {
+	if len(versions) == 0 {
+		return nil
 	}
-	osbundle := reg.ResolveOsToOsBundle(osArch)
-	addrs := downloader.GetBundleAddr(osbundle, k8sVersion)
+	if bundleType == BundleTypeRepo {
+		return fmt.Errorf("%w: the versions of the packages of a repository cannot be chosen", ErrComponentNotSupported)
+	}
+	reg := GetSupportedRegistry()
+	return reg.ValidateComponents(k8sVersion, versions)
+}
 
-	return algo.NewUbuntu20_04Installer(ctx, arch, addrs)
+// GetComponentAddrs returns the addresses of the artifacts of the components whose version is chosen
+// independently of the bundle, keyed by component
+func GetComponentAddrs(osDist, arch string, downloader *bundleDownloader) map[string]string {
+	if len(downloader.componentVersions) == 0 {
+		return nil
+	}
+	osBundle := ResolveOsBundle(osDist, arch)
+	addrs := map[string]string{}
+	for component, version := range downloader.componentVersions {
+		addrs[component] = downloader.GetComponentAddr(component, osBundle, version)
+	}
+	return addrs
 }
@@ -0,0 +1,49 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package algo
+
+import (
+	b64 "encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/steps"
+)
+
+// DownloadComponentStepPrefix prefixes the name of the component in the name of the step downloading its artifact
+const DownloadComponentStepPrefix = "download-component-"
+
+// downloadComponentStep returns the step downloading the artifact of a component installed instead of the
+// one of the bundle. The artifact is verified with the public key of the bundle, if set.
+func downloadComponentStep(component, addr, publicKey string) steps.Step {
+	var apply strings.Builder
+	apply.WriteString(fmt.Sprintf("COMPONENT_ADDR=%s\nCOMPONENT_PATH=\"$BUNDLE_DOWNLOAD_PATH/$COMPONENT_ADDR\"\n", steps.ShellQuote(addr)))
+	apply.WriteString(fmt.Sprintf(`if [ -f "$COMPONENT_PATH/%s" ]; then
+	echo "using cached %s artifact"
+else
+`, BundleCacheMarkerFile, component))
+	if publicKey != "" {
+		// cosign is installed by the step verifying the bundle
+		apply.WriteString(fmt.Sprintf(`	COMPONENT_KEY_FILE=$(mktemp)
+	echo "%s" | base64 -d > $COMPONENT_KEY_FILE
+	if ! cosign verify --key $COMPONENT_KEY_FILE "$COMPONENT_ADDR" >>/dev/null; then
+		rm -f $COMPONENT_KEY_FILE
+		echo "artifact $COMPONENT_ADDR failed signature verification, refusing to install"
+		exit 1
+	fi
+	rm -f $COMPONENT_KEY_FILE
+`, b64.StdEncoding.EncodeToString([]byte(publicKey))))
+	}
+	apply.WriteString(fmt.Sprintf(`	echo "downloading %s artifact"
+	rm -rf "$COMPONENT_PATH" && mkdir -p "$COMPONENT_PATH"
+	imgpkg pull -i "$COMPONENT_ADDR" -o "$COMPONENT_PATH"
+	{ imgpkg tag resolve -i "$COMPONENT_ADDR" || true; } | sed 's/.*@//' > "$COMPONENT_PATH/%s"
+fi`, component, BundleCacheMarkerFile))
+
+	return steps.Step{
+		Name:   DownloadComponentStepPrefix + component,
+		Apply:  apply.String(),
+		Verify: fmt.Sprintf(`[ -f "$BUNDLE_DOWNLOAD_PATH"/%s/%s ]`, steps.ShellQuote(addr), BundleCacheMarkerFile),
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package algo
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/steps"
+)
+
+const (
+	// ContainerdConfigStep is the step rendering the containerd configuration, only part of the plan if
+	// containerd settings are set
+	ContainerdConfigStep = "containerd-config"
+	// ContainerdConfigDir holds the containerd configuration rendered by the installer
+	ContainerdConfigDir = "/etc/containerd/byoh"
+	// containerdDropIn points the containerd service to the rendered configuration
+	containerdDropIn = "/etc/systemd/system/containerd.service.d/10-byoh-config.conf"
+	// bundleContainerdBinary is the path of containerd in the containerd.tar of the bundle
+	bundleContainerdBinary = "/usr/local/bin/containerd"
+	// heredocDelimiter ends the files written by the containerd configuration step
+	heredocDelimiter = "BYOH_EOF"
+)
+
+// ContainerdConfig holds the containerd settings rendered into the containerd configuration.
+// It replaces the configuration shipped in the bundle.
+type ContainerdConfig struct {
+	// RegistryMirrors are the mirror endpoints of each registry host, tried in order before the registry
+	RegistryMirrors map[string][]string
+	// InsecureRegistries are the registry hosts whose TLS certificate is not verified
+	InsecureRegistries []string
+	// SandboxImage is the image of the pod sandbox (pause) container
+	SandboxImage string
+	// SystemdCgroup makes runc use the systemd cgroup driver
+	SystemdCgroup bool
+	// Snapshotter is the snapshotter used by the CRI plugin
+	Snapshotter string
+	// DataRoot is the root directory of the containerd persistent data
+	DataRoot string
+}
+
+// containerdConfigStep returns the step writing the containerd configuration and the registry hosts
+// configurations, and a systemd drop-in making the containerd service run binary with them.
+// The registry hosts are validated by the K8sInstallerConfig webhook, they are still quoted in the commands.
+func containerdConfigStep(config *ContainerdConfig, binary string) steps.Step {
+	var apply strings.Builder
+	apply.WriteString(fmt.Sprintf("mkdir -p %s/certs.d %s\n", ContainerdConfigDir, dirOf(containerdDropIn)))
+	writeFile(&apply, ContainerdConfigDir+"/config.toml", config.toml())
+	for _, registry := range config.registries() {
+		dir := ContainerdConfigDir + "/certs.d/" + registry
+		apply.WriteString("mkdir -p " + steps.ShellQuote(dir) + "\n")
+		writeFile(&apply, dir+"/hosts.toml", config.hostsToml(registry))
+	}
+	writeFile(&apply, containerdDropIn, fmt.Sprintf("[Service]\nExecStart=\nExecStart=%s --config %s/config.toml\n", binary, ContainerdConfigDir))
+	apply.WriteString("systemctl daemon-reload")
+
+	return steps.Step{
+		Name:  ContainerdConfigStep,
+		Apply: apply.String(),
+		Undo:  fmt.Sprintf("rm -rf %s %s && systemctl daemon-reload", ContainerdConfigDir, containerdDropIn),
+	}
+}
+
+// toml renders the containerd configuration, the settings which are not set keep the containerd defaults
+func (c *ContainerdConfig) toml() string {
+	var config strings.Builder
+	config.WriteString("version = 2\n")
+	if c.DataRoot != "" {
+		config.WriteString("root = " + strconv.Quote(c.DataRoot) + "\n")
+	}
+	const cri = `[plugins."io.containerd.grpc.v1.cri"]`
+	config.WriteString("\n" + cri + "\n")
+	if c.SandboxImage != "" {
+		config.WriteString("  sandbox_image = " + strconv.Quote(c.SandboxImage) + "\n")
+	}
+	config.WriteString("\n" + strings.TrimSuffix(cri, "]") + `.containerd]` + "\n")
+	if c.Snapshotter != "" {
+		config.WriteString("  snapshotter = " + strconv.Quote(c.Snapshotter) + "\n")
+	}
+	config.WriteString(`  default_runtime_name = "runc"` + "\n")
+	config.WriteString("\n" + strings.TrimSuffix(cri, "]") + `.containerd.runtimes.runc]` + "\n")
+	config.WriteString(`  runtime_type = "io.containerd.runc.v2"` + "\n")
+	config.WriteString("\n" + strings.TrimSuffix(cri, "]") + `.containerd.runtimes.runc.options]` + "\n")
+	config.WriteString("  SystemdCgroup = " + strconv.FormatBool(c.SystemdCgroup) + "\n")
+	config.WriteString("\n" + strings.TrimSuffix(cri, "]") + `.registry]` + "\n")
+	config.WriteString("  config_path = " + strconv.Quote(ContainerdConfigDir+"/certs.d") + "\n")
+	return config.String()
+}
+
+// registries returns the registry hosts which have mirrors or are insecure, sorted
+func (c *ContainerdConfig) registries() []string {
+	registries := slices.Clone(c.InsecureRegistries)
+	for registry := range c.RegistryMirrors {
+		if !slices.Contains(registries, registry) {
+			registries = append(registries, registry)
+		}
+	}
+	sort.Strings(registries)
+	return registries
+}
+
+// hostsToml renders the hosts.toml of the registry, listing its mirrors
+func (c *ContainerdConfig) hostsToml(registry string) string {
+	var hosts strings.Builder
+	hosts.WriteString("server = " + strconv.Quote(registryServer(registry)) + "\n")
+	if slices.Contains(c.InsecureRegistries, registry) {
+		hosts.WriteString("skip_verify = true\n")
+	}
+	for _, endpoint := range c.RegistryMirrors[registry] {
+		hosts.WriteString("\n[host." + strconv.Quote(endpoint) + "]\n")
+		hosts.WriteString(`  capabilities = ["pull", "resolve"]` + "\n")
+		if u, err := url.Parse(endpoint); err == nil && slices.Contains(c.InsecureRegistries, u.Host) {
+			hosts.WriteString("  skip_verify = true\n")
+		}
+	}
+	return hosts.String()
+}
+
+// registryServer returns the URL of the registry host, docker.io being served by registry-1.docker.io
+func registryServer(registry string) string {
+	if registry == "docker.io" {
+		return "https://registry-1.docker.io"
+	}
+	return "https://" + registry
+}
+
+// writeFile writes a command creating the file with the given content, the path is quoted.
+// The heredoc delimiter is quoted, so that the content is not expanded by the shell.
+func writeFile(script *strings.Builder, path, content string) {
+	script.WriteString(fmt.Sprintf("cat > %s <<'%s'\n%s%s\n", steps.ShellQuote(path), heredocDelimiter, content, heredocDelimiter))
+}
+
+func dirOf(path string) string {
+	return path[:strings.LastIndex(path, "/")]
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package algo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/steps"
+)
+
+const (
+	// ProxyConfigStep is the step configuring the proxy of containerd and kubelet, only part of the plan if
+	// a proxy is set
+	ProxyConfigStep = "proxy-config"
+	// proxyDropIn is the name of the systemd drop-in setting the proxy of a service
+	proxyDropIn = "10-byoh-proxy.conf"
+)
+
+// proxyServices are the services whose requests go through the proxy
+var proxyServices = []string{"containerd", "kubelet"}
+
+// ProxyConfig holds the HTTP proxy used by the installation, containerd and kubelet
+type ProxyConfig struct {
+	// HTTPProxy is the proxy URL for HTTP requests
+	HTTPProxy string
+	// HTTPSProxy is the proxy URL for HTTPS requests
+	HTTPSProxy string
+	// NoProxy are the hosts, domains and CIDRs reached without the proxy
+	NoProxy []string
+}
+
+// env returns the proxy environment variables, both in upper and lower case as tools differ in
+// the ones they read
+func (p *ProxyConfig) env() [][2]string {
+	env := [][2]string{}
+	for _, v := range [][2]string{
+		{"HTTP_PROXY", p.HTTPProxy},
+		{"HTTPS_PROXY", p.HTTPSProxy},
+		{"NO_PROXY", strings.Join(p.NoProxy, ",")},
+	} {
+		if v[1] != "" {
+			env = append(env, v, [2]string{strings.ToLower(v[0]), v[1]})
+		}
+	}
+	return env
+}
+
+// exports returns the commands exporting the proxy environment to the installation commands
+func (p *ProxyConfig) exports() string {
+	var exports strings.Builder
+	for _, v := range p.env() {
+		exports.WriteString(fmt.Sprintf("export %s=%s\n", v[0], steps.ShellQuote(v[1])))
+	}
+	return exports.String()
+}
+
+// proxyConfigStep returns the step writing the systemd drop-ins setting the proxy of containerd and kubelet
+func proxyConfigStep(config *ProxyConfig) steps.Step {
+	var dropIn strings.Builder
+	dropIn.WriteString("[Service]\n")
+	for _, v := range config.env() {
+		dropIn.WriteString(fmt.Sprintf("Environment=%s\n", systemdQuote(v[0]+"="+v[1])))
+	}
+
+	var apply strings.Builder
+	dropIns := []string{}
+	for _, service := range proxyServices {
+		dir := "/etc/systemd/system/" + service + ".service.d"
+		apply.WriteString("mkdir -p " + dir + "\n")
+		writeFile(&apply, dir+"/"+proxyDropIn, dropIn.String())
+		dropIns = append(dropIns, dir+"/"+proxyDropIn)
+	}
+	apply.WriteString("systemctl daemon-reload")
+
+	return steps.Step{
+		Name:  ProxyConfigStep,
+		Apply: apply.String(),
+		Undo:  fmt.Sprintf("rm -f %s && systemctl daemon-reload", strings.Join(dropIns, " ")),
+	}
+}
+
+// systemdEscaper escapes the characters systemd interprets in a double quoted word of a unit file,
+// % starts a specifier and the new lines would end the setting
+var systemdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "\n", `\n`)
+
+// systemdQuote quotes value as a single word of a systemd unit setting
+func systemdQuote(value string) string {
+	return `"` + systemdEscaper.Replace(value) + `"`
+}
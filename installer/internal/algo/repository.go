@@ -0,0 +1,114 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package algo
+
+import (
+	b64 "encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/steps"
+)
+
+const (
+	// repoKeyring is the keyring holding the key the package repository is signed with
+	repoKeyring = "/etc/apt/keyrings/byoh-kubernetes.gpg"
+	// repoSources is the apt sources list of the package repository
+	repoSources = "/etc/apt/sources.list.d/byoh-kubernetes.list"
+	// repoContainerdBinary is the path of containerd installed from the apt package
+	repoContainerdBinary = "/usr/bin/containerd"
+)
+
+// PackageRepository holds the apt repository the k8s components are installed from, instead of a bundle
+type PackageRepository struct {
+	// URL of the repository
+	URL string
+	// Suite of the repository, "/" for flat repositories
+	Suite string
+	// Components of the repository, none for flat repositories
+	Components []string
+	// GPGKey is the ASCII armored public key the repository is signed with
+	GPGKey string
+	// ContainerdVersion pins the version of the containerd package, the latest version is installed if not set
+	ContainerdVersion string
+}
+
+// templateData returns the values of the repository rendered into the package repository steps.
+// They are encoded or quoted, so that they are not interpreted by the shell.
+func (r *PackageRepository) templateData(k8sVersion string) map[string]any {
+	suite := r.Suite
+	if suite == "" {
+		suite = "/"
+	}
+	source := strings.Join(append([]string{"deb", "[signed-by=" + repoKeyring + "]", r.URL, suite}, r.Components...), " ")
+	containerd := "containerd"
+	if r.ContainerdVersion != "" {
+		containerd = fmt.Sprintf("containerd=%s-*", r.ContainerdVersion)
+	}
+	return map[string]any{
+		"K8sVersion":        steps.ShellQuote(strings.TrimPrefix(k8sVersion, "v")),
+		"RepoSource":        b64.StdEncoding.EncodeToString([]byte(source + "\n")),
+		"RepoGPGKey":        b64.StdEncoding.EncodeToString([]byte(r.GPGKey)),
+		"ContainerdPackage": steps.ShellQuote(containerd),
+	}
+}
+
+// contains the installation and uninstallation steps of the k8s components from a package repository
+var (
+	Ubuntu20_4K8sRepoPreamble = `
+set -euox pipefail
+
+ARCH={{.Arch}}
+K8S_VERSION={{.K8sVersion}}
+CONTAINERD_PACKAGE={{.ContainerdPackage}}
+REPO_KEYRING=` + repoKeyring + `
+REPO_SOURCES=` + repoSources + `
+export DEBIAN_FRONTEND=noninteractive`
+)
+
+// Ubuntu20_4K8sRepoSteps returns the steps installing the k8s components from the package repositories,
+// with the configSteps rendering the containerd settings and the proxy before containerd is restarted
+func Ubuntu20_4K8sRepoSteps(configSteps ...steps.Step) []steps.Step {
+	return slices.Concat([]steps.Step{
+		{
+			Name: "package-repository",
+			Apply: `mkdir -p "$(dirname $REPO_KEYRING)"
+echo "{{.RepoGPGKey}}" | base64 -d | gpg --dearmor --yes -o $REPO_KEYRING
+echo "{{.RepoSource}}" | base64 -d > $REPO_SOURCES
+apt-get update`,
+			Undo: "rm -f $REPO_SOURCES $REPO_KEYRING && apt-get update",
+		},
+	}, prepareHostSteps, []steps.Step{
+		{
+			Name: "os-configuration",
+			Apply: `printf 'overlay\nbr_netfilter\n' > /etc/modules-load.d/byoh-kubernetes.conf
+printf 'net.bridge.bridge-nf-call-iptables = 1\nnet.bridge.bridge-nf-call-ip6tables = 1\nnet.ipv4.ip_forward = 1\n' > /etc/sysctl.d/99-byoh-kubernetes.conf
+sysctl --system`,
+			Undo: "rm -f /etc/modules-load.d/byoh-kubernetes.conf /etc/sysctl.d/99-byoh-kubernetes.conf && sysctl --system",
+		},
+		repoPackageStep("containerd", "$CONTAINERD_PACKAGE"),
+		repoPackageStep("kubelet", `"kubelet=$K8S_VERSION-*"`),
+		repoPackageStep("kubeadm", `"kubeadm=$K8S_VERSION-*"`),
+		repoPackageStep("kubectl", `"kubectl=$K8S_VERSION-*"`),
+	}, configSteps, []steps.Step{
+		{
+			// the package starts containerd with its default configuration
+			Name:   "containerd-service",
+			Apply:  "systemctl daemon-reload && systemctl enable containerd && systemctl restart containerd",
+			Verify: "systemctl is-active --quiet containerd",
+		},
+	})
+}
+
+// repoPackageStep returns the step installing the package pinned to a version from the package repositories
+// and holding it at that version
+func repoPackageStep(pkg, pinnedPkg string) steps.Step {
+	return steps.Step{
+		Name:   pkg,
+		Apply:  fmt.Sprintf("apt-get install -y --allow-downgrades --allow-change-held-packages %s && apt-mark hold %s", pinnedPkg, pkg),
+		Verify: "dpkg -s " + pkg + " >>/dev/null",
+		Undo:   fmt.Sprintf("if dpkg -s %s >>/dev/null 2>&1; then apt-mark unhold %s && apt-get purge -y %s; fi", pkg, pkg, pkg),
+	}
+}
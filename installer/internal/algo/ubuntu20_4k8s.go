@@ -6,79 +6,225 @@ package algo
 import (
 	"bytes"
 	"context"
+	b64 "encoding/base64"
 	"fmt"
-	"html/template"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/bundlemanifest"
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/steps"
 )
 
 const (
 	// ImgpkgVersion defines the imgpkg version that will be installed on host if imgpkg is not already installed
 	ImgpkgVersion = "v0.36.4"
+	// CosignVersion defines the cosign version that will be installed on host to verify bundle signatures,
+	// the binary is checked against the SHA256 checksums published with the release before it is installed
+	CosignVersion = "v2.4.1"
+	// BundleCacheMarkerFile is written in the bundle directory once the bundle is completely downloaded,
+	// it holds the digest the bundle address resolved to
+	BundleCacheMarkerFile = ".byoh-bundle"
 )
 
+// InstallerParams holds the values rendered into the install and uninstall scripts
+type InstallerParams struct {
+	// Arch is the host architecture
+	Arch string
+	// BundleAddrs is the address of the bundle in the OCI registry
+	BundleAddrs string
+	// BundlePublicKey is a PEM encoded cosign public key used to verify the bundle signature, if set
+	BundlePublicKey string
+	// RegistryCredentials indicates the byoh agent provides registry credentials to pull the bundle
+	RegistryCredentials bool
+	// Containerd is rendered into the containerd configuration, the bundle configuration is kept if nil
+	Containerd *ContainerdConfig
+	// Proxy is the HTTP proxy of the installation, containerd and kubelet, if set
+	Proxy *ProxyConfig
+	// K8sVersion is the version of the k8s packages installed from Repository
+	K8sVersion string
+	// Repository is the package repository the k8s components are installed from, instead of the bundle, if set
+	Repository *PackageRepository
+	// Components are the addresses of the artifacts of the components installed instead of the ones
+	// of the bundle, keyed by component
+	Components map[string]string
+}
+
 // Ubuntu20_04Installer represent the installer implementation for ubunto24.04.* os distribution
 type Ubuntu20_04Installer struct {
-	install   string
-	uninstall string
+	plan        *steps.Plan
+	upgradePlan *steps.Plan
 }
 
 // NewUbuntu20_04Installer will return new Ubuntu20_04Installer instance
-func NewUbuntu20_04Installer(ctx context.Context, arch, bundleAddrs string) (*Ubuntu20_04Installer, error) {
+func NewUbuntu20_04Installer(ctx context.Context, params InstallerParams) (*Ubuntu20_04Installer, error) {
+	data := map[string]any{
+		"BundleAddrs":            params.BundleAddrs,
+		"Arch":                   params.Arch,
+		"ImgpkgVersion":          ImgpkgVersion,
+		"CosignVersion":          CosignVersion,
+		"BundlePublicKey":        b64.StdEncoding.EncodeToString([]byte(params.BundlePublicKey)),
+		"BundleDownloadPath":     "{{.BundleDownloadPath}}",
+		"UseRegistryCredentials": params.RegistryCredentials,
+		"RegistryConfigDir":      "{{.RegistryConfigDir}}",
+		"PreviousBundleAddr":     "{{.PreviousBundleAddr}}",
+		"BundleCacheMarkerFile":  BundleCacheMarkerFile,
+		"BundleManifestFile":     bundlemanifest.FileName,
+	}
 	parseFn := func(script string) (string, error) {
 		parser, err := template.New("parser").Parse(script)
 		if err != nil {
 			return "", fmt.Errorf("unable to parse install script")
 		}
 		var tpl bytes.Buffer
-		if err = parser.Execute(&tpl, map[string]string{
-			"BundleAddrs":        bundleAddrs,
-			"Arch":               arch,
-			"ImgpkgVersion":      ImgpkgVersion,
-			"BundleDownloadPath": "{{.BundleDownloadPath}}",
-		}); err != nil {
+		if err = parser.Execute(&tpl, data); err != nil {
 			return "", fmt.Errorf("unable to apply install parsed template to the data object")
 		}
 		return tpl.String(), nil
 	}
 
-	install, err := parseFn(DoUbuntu20_4K8s1_22)
+	containerd, containerdBinary := params.Containerd, bundleContainerdBinary
+	if params.Repository != nil {
+		maps.Copy(data, params.Repository.templateData(params.K8sVersion))
+		containerdBinary = repoContainerdBinary
+		if containerd == nil {
+			// the default configuration of the containerd package does not use the systemd cgroup driver
+			containerd = &ContainerdConfig{SystemdCgroup: true}
+		}
+	}
+
+	// the steps rendering the containerd settings and the proxy are only part of the plan if they are set
+	var configSteps []steps.Step
+	if containerd != nil {
+		configSteps = append(configSteps, containerdConfigStep(containerd, containerdBinary))
+	}
+	if params.Proxy != nil {
+		configSteps = append(configSteps, proxyConfigStep(params.Proxy))
+	}
+
+	newPlan := func(preamble string, planSteps []steps.Step) (*steps.Plan, error) {
+		preamble, err := parseFn(preamble)
+		if err != nil {
+			return nil, err
+		}
+		if params.Proxy != nil {
+			preamble += "\n" + params.Proxy.exports()
+		}
+		plan := &steps.Plan{Preamble: preamble}
+		for _, step := range planSteps {
+			if step.Name == VerifyBundleSignatureStep && params.BundlePublicKey == "" {
+				continue
+			}
+			if slices.ContainsFunc(configSteps, func(configStep steps.Step) bool { return configStep.Name == step.Name }) {
+				// the rendered configurations are not templates
+				plan.Steps = append(plan.Steps, step)
+				continue
+			}
+			for _, field := range []*string{&step.When, &step.Apply, &step.Verify, &step.Undo} {
+				if *field, err = parseFn(*field); err != nil {
+					return nil, err
+				}
+			}
+			if addr, ok := params.Components[step.Name]; ok {
+				// the component is installed from the file of its own artifact
+				for _, field := range []*string{&step.Apply, &step.Undo} {
+					*field = strings.ReplaceAll(*field, "$BUNDLE_PATH/", "$BUNDLE_DOWNLOAD_PATH/"+addr+"/")
+				}
+			}
+			plan.Steps = append(plan.Steps, step)
+			if step.Name == downloadBundleStep.Name {
+				for _, component := range slices.Sorted(maps.Keys(params.Components)) {
+					plan.Steps = append(plan.Steps, downloadComponentStep(component, params.Components[component], params.BundlePublicKey))
+				}
+			}
+		}
+		return plan, nil
+	}
+
+	if params.Repository != nil {
+		// in-place upgrade is not supported for the packages of a repository
+		plan, err := newPlan(Ubuntu20_4K8sRepoPreamble, Ubuntu20_4K8sRepoSteps(configSteps...))
+		if err != nil {
+			return nil, err
+		}
+		return &Ubuntu20_04Installer{plan: plan}, nil
+	}
+	plan, err := newPlan(Ubuntu20_4K8s1_22Preamble, Ubuntu20_4K8s1_22Steps(configSteps...))
 	if err != nil {
 		return nil, err
 	}
-	uninstall, err := parseFn(UndoUbuntu20_4K8s1_22)
+	if len(params.Components) > 0 {
+		// the upgrade steps would replace the components with the ones of the new bundle
+		return &Ubuntu20_04Installer{plan: plan}, nil
+	}
+	upgradePlan, err := newPlan(Ubuntu20_4K8s1_22Preamble+Ubuntu20_4K8s1_22UpgradePreamble, Ubuntu20_4K8s1_22UpgradeSteps)
 	if err != nil {
 		return nil, err
 	}
-	return &Ubuntu20_04Installer{
-		install:   install,
-		uninstall: uninstall,
-	}, nil
+	return &Ubuntu20_04Installer{plan: plan, upgradePlan: upgradePlan}, nil
 }
 
 // Install will return k8s install script
 func (s *Ubuntu20_04Installer) Install() string {
-	return s.install
+	return s.plan.InstallScript()
 }
 
 // Uninstall will return k8s uninstall script
 func (s *Ubuntu20_04Installer) Uninstall() string {
-	return s.uninstall
+	return s.plan.UninstallScript()
 }
 
-// contains the installation and uninstallation steps for the supported os and k8s
+// Plan will return the steps of the k8s installation
+func (s *Ubuntu20_04Installer) Plan() *steps.Plan {
+	return s.plan
+}
+
+// UpgradePlan will return the steps upgrading the k8s components installed from a previous bundle,
+// nil if the components are installed from a package repository or from their own artifacts
+func (s *Ubuntu20_04Installer) UpgradePlan() *steps.Plan {
+	return s.upgradePlan
+}
+
+// VerifyBundleSignatureStep is the step verifying the bundle signature, only part of the plan if a public key is set
+const VerifyBundleSignatureStep = "verify-bundle-signature"
+
+// withoutBundleManifest is the condition of the steps installing the bundle components,
+// bundles with a manifest have their components installed by the byoh agent
+const withoutBundleManifest = `[ ! -f "$BUNDLE_PATH/{{.BundleManifestFile}}" ]`
+
+// steps shared by the installations and the upgrade
 var (
-	DoUbuntu20_4K8s1_22 = `
-set -euox pipefail
+	installImgpkgStep = steps.Step{
+		Name: "install-imgpkg",
+		When: "! command -v imgpkg >>/dev/null",
+		Apply: `echo "installing imgpkg"
 
-BUNDLE_DOWNLOAD_PATH={{.BundleDownloadPath}}
-BUNDLE_ADDR={{.BundleAddrs}}
-IMGPKG_VERSION={{.ImgpkgVersion}}
-ARCH={{.Arch}}
-BUNDLE_PATH=$BUNDLE_DOWNLOAD_PATH/$BUNDLE_ADDR
+if command -v wget >>/dev/null; then
+	dl_bin="wget -nv -O-"
+elif command -v curl >>/dev/null; then
+	dl_bin="curl -s -L"
+else
+	echo "installing curl"
+	apt-get install -y curl
+	dl_bin="curl -s -L"
+fi
+
+$dl_bin github.com/vmware-tanzu/carvel-imgpkg/releases/download/$IMGPKG_VERSION/imgpkg-linux-$ARCH > /tmp/imgpkg
+mv /tmp/imgpkg /usr/local/bin/imgpkg
+chmod +x /usr/local/bin/imgpkg`,
+		Verify: "command -v imgpkg >>/dev/null",
+	}
 
+	verifyBundleSignatureStep = steps.Step{
+		Name: VerifyBundleSignatureStep,
+		Apply: `COSIGN_VERSION={{.CosignVersion}}
+BUNDLE_KEY_FILE=$(mktemp)
+trap 'rm -f $BUNDLE_KEY_FILE' EXIT
+
+if ! command -v cosign >>/dev/null; then
+	echo "installing cosign"
 
-if ! command -v imgpkg >>/dev/null; then
-	echo "installing imgpkg"	
-	
 	if command -v wget >>/dev/null; then
 		dl_bin="wget -nv -O-"
 	elif command -v curl >>/dev/null; then
@@ -88,73 +234,178 @@ if ! command -v imgpkg >>/dev/null; then
 		apt-get install -y curl
 		dl_bin="curl -s -L"
 	fi
-	
-	$dl_bin github.com/vmware-tanzu/carvel-imgpkg/releases/download/$IMGPKG_VERSION/imgpkg-linux-$ARCH > /tmp/imgpkg
-	mv /tmp/imgpkg /usr/local/bin/imgpkg
-	chmod +x /usr/local/bin/imgpkg
-fi
-
-echo "downloading bundle"
-mkdir -p $BUNDLE_PATH
-imgpkg pull -i $BUNDLE_ADDR -o $BUNDLE_PATH
-
 
-## disable swap
-swapoff -a && sed -ri '/\sswap\s/s/^#?/#/' /etc/fstab
-
-## disable firewall
-if command -v ufw >>/dev/null; then
-	ufw disable
+	COSIGN_DIR=$(mktemp -d)
+	COSIGN_RELEASE=github.com/sigstore/cosign/releases/download/$COSIGN_VERSION
+	$dl_bin $COSIGN_RELEASE/cosign-linux-$ARCH > $COSIGN_DIR/cosign-linux-$ARCH
+	$dl_bin $COSIGN_RELEASE/cosign_checksums.txt | grep " cosign-linux-$ARCH\$" > $COSIGN_DIR/cosign_checksums.txt
+	if ! (cd $COSIGN_DIR && sha256sum --status -c cosign_checksums.txt); then
+		rm -rf $COSIGN_DIR
+		echo "cosign $COSIGN_VERSION does not match its published SHA256 checksum, refusing to install it"
+		exit 1
+	fi
+	mv $COSIGN_DIR/cosign-linux-$ARCH /usr/local/bin/cosign
+	rm -rf $COSIGN_DIR
+	chmod +x /usr/local/bin/cosign
 fi
 
-## load kernal modules
-modprobe overlay && modprobe br_netfilter
-
-## adding os configuration
-tar -C / -xvf "$BUNDLE_PATH/conf.tar" && sysctl --system 
-
-## installing deb packages
-for pkg in cri-tools kubernetes-cni kubectl kubelet kubeadm; do
-	dpkg --install "$BUNDLE_PATH/$pkg.deb" && apt-mark hold $pkg
-done
+echo "verifying bundle signature"
+echo "{{.BundlePublicKey}}" | base64 -d > $BUNDLE_KEY_FILE
+if ! cosign verify --key $BUNDLE_KEY_FILE $BUNDLE_ADDR >>/dev/null; then
+	echo "bundle $BUNDLE_ADDR failed signature verification, refusing to install"
+	exit 1
+fi`,
+	}
 
-## intalling containerd
-tar -C / -xvf "$BUNDLE_PATH/containerd.tar"
+	// prepareHostSteps are not undone, the byoh agent restores the swap, firewall and kernel modules
+	// state they had before installation from its host state snapshot. Undoing them to the defaults of a host
+	// would enable the firewall during the uninstallation, which may cut off the access to the host.
+	prepareHostSteps = []steps.Step{
+		{
+			Name:   "disable-swap",
+			Apply:  `swapoff -a && sed -ri '/\sswap\s/s/^#?/#/' /etc/fstab`,
+			Verify: `[ -z "$(swapon --show --noheadings)" ]`,
+		},
+		{
+			Name:  "disable-firewall",
+			When:  "command -v ufw >>/dev/null",
+			Apply: "ufw disable",
+		},
+		{
+			Name:  "load-kernel-modules",
+			Apply: "modprobe overlay && modprobe br_netfilter",
+		},
+	}
 
-## starting containerd service
-systemctl daemon-reload && systemctl enable containerd && systemctl start containerd`
+	downloadBundleStep = steps.Step{
+		Name: "download-bundle",
+		Apply: `if [ -f "$BUNDLE_PATH/{{.BundleCacheMarkerFile}}" ]; then
+	echo "using cached bundle"
+else
+	echo "downloading bundle"
+	rm -rf $BUNDLE_PATH && mkdir -p $BUNDLE_PATH
+	imgpkg pull -i $BUNDLE_ADDR -o $BUNDLE_PATH
+	# record the digest the bundle resolved to, the marker must exist even if it cannot be resolved
+	{ imgpkg tag resolve -i $BUNDLE_ADDR || true; } | sed 's/.*@//' > "$BUNDLE_PATH/{{.BundleCacheMarkerFile}}"
+fi`,
+		Verify: `[ -f "$BUNDLE_PATH/{{.BundleCacheMarkerFile}}" ]`,
+	}
+)
 
-	UndoUbuntu20_4K8s1_22 = `
+// contains the installation and uninstallation steps for the supported os and k8s
+var (
+	Ubuntu20_4K8s1_22Preamble = `
 set -euox pipefail
 
 BUNDLE_DOWNLOAD_PATH={{.BundleDownloadPath}}
 BUNDLE_ADDR={{.BundleAddrs}}
+IMGPKG_VERSION={{.ImgpkgVersion}}
+ARCH={{.Arch}}
 BUNDLE_PATH=$BUNDLE_DOWNLOAD_PATH/$BUNDLE_ADDR
+{{- if .UseRegistryCredentials}}
 
-## disabling containerd service
-systemctl stop containerd && systemctl disable containerd && systemctl daemon-reload
+## registry credentials written by the byoh agent, used by imgpkg and cosign
+export DOCKER_CONFIG={{.RegistryConfigDir}}
+{{- end}}`
 
-## removing containerd configurations and cni plugins
-rm -rf /opt/cni/ && rm -rf /opt/containerd/ &&  tar tf "$BUNDLE_PATH/containerd.tar" | xargs -n 1 echo '/' | sed 's/ //g'  | grep -e '[^/]$' | xargs rm -f
+	// Ubuntu20_4K8s1_22UpgradePreamble is appended to the preamble of the upgrade steps,
+	// the byoh agent provides the address of the bundle the host was installed from
+	Ubuntu20_4K8s1_22UpgradePreamble = `
+PREVIOUS_BUNDLE_PATH=$BUNDLE_DOWNLOAD_PATH/{{.PreviousBundleAddr}}`
 
-## removing deb packages
-for pkg in kubeadm kubelet kubectl kubernetes-cni cri-tools; do
-	dpkg --purge $pkg
-done
-
-## removing os configuration
-tar tf "$BUNDLE_PATH/conf.tar" | xargs -n 1 echo '/' | sed 's/ //g' | grep -e "[^/]$" | xargs rm -f
-
-## remove kernal modules
-modprobe -rq overlay && modprobe -r br_netfilter
+	// Ubuntu20_4K8s1_22UpgradeSteps upgrade the k8s components of a bootstrapped node in place.
+	// Undoing them reinstalls the components of the previous bundle.
+	Ubuntu20_4K8s1_22UpgradeSteps = []steps.Step{
+		installImgpkgStep,
+		verifyBundleSignatureStep,
+		downloadBundleStep,
+		{
+			Name: "check-bundle-manifest",
+			Apply: `if [ -f "$BUNDLE_PATH/{{.BundleManifestFile}}" ] || [ -f "$PREVIOUS_BUNDLE_PATH/{{.BundleManifestFile}}" ]; then
+	echo "in-place upgrade of bundles with a manifest is not supported"
+	exit 1
+fi`,
+		},
+		upgradeDebStep("cri-tools"),
+		upgradeDebStep("kubernetes-cni"),
+		upgradeDebStep("kubeadm"),
+		{
+			// the kubelet configuration and the static pod manifests are backed up to be restored by the undo
+			Name: "kubeadm-upgrade-node",
+			Apply: `BACKUP_DIR=/etc/kubernetes/tmp/byoh-upgrade-backup
+rm -rf $BACKUP_DIR && mkdir -p $BACKUP_DIR
+cp -a /var/lib/kubelet/config.yaml $BACKUP_DIR/
+if [ -d /etc/kubernetes/manifests ]; then cp -a /etc/kubernetes/manifests $BACKUP_DIR/; fi
+kubeadm upgrade node`,
+			Undo: `BACKUP_DIR=/etc/kubernetes/tmp/byoh-upgrade-backup
+if [ -f $BACKUP_DIR/config.yaml ]; then cp -a $BACKUP_DIR/config.yaml /var/lib/kubelet/config.yaml; fi
+if [ -d $BACKUP_DIR/manifests ]; then rm -rf /etc/kubernetes/manifests && cp -a $BACKUP_DIR/manifests /etc/kubernetes/manifests; fi
+systemctl restart kubelet`,
+		},
+		upgradeDebStep("kubectl"),
+		{
+			Name:   "kubelet",
+			Apply:  `dpkg --force-hold --install "$BUNDLE_PATH/kubelet.deb" && systemctl daemon-reload && systemctl restart kubelet`,
+			Verify: "systemctl is-active --quiet kubelet",
+			Undo:   `dpkg --force-hold --install "$PREVIOUS_BUNDLE_PATH/kubelet.deb" && systemctl daemon-reload && systemctl restart kubelet`,
+		},
+	}
+)
 
-## enable firewall
-if command -v ufw >>/dev/null; then
-	ufw enable
-fi
+// Ubuntu20_4K8s1_22Steps returns the steps installing the k8s components from the bundle,
+// with the configSteps rendering the containerd settings and the proxy before containerd is started
+func Ubuntu20_4K8s1_22Steps(configSteps ...steps.Step) []steps.Step {
+	return slices.Concat([]steps.Step{
+		installImgpkgStep,
+		verifyBundleSignatureStep,
+		downloadBundleStep,
+	}, prepareHostSteps, []steps.Step{
+		{
+			Name:  "os-configuration",
+			When:  withoutBundleManifest,
+			Apply: `tar -C / -xvf "$BUNDLE_PATH/conf.tar" && sysctl --system`,
+			Undo:  `tar tf "$BUNDLE_PATH/conf.tar" | xargs -n 1 echo '/' | sed 's/ //g' | grep -e "[^/]$" | xargs rm -f`,
+		},
+		debStep("cri-tools"),
+		debStep("kubernetes-cni"),
+		debStep("kubectl"),
+		debStep("kubelet"),
+		debStep("kubeadm"),
+		{
+			Name:  "containerd",
+			When:  withoutBundleManifest,
+			Apply: `tar -C / -xvf "$BUNDLE_PATH/containerd.tar"`,
+			Undo:  `rm -rf /opt/cni/ && rm -rf /opt/containerd/ &&  tar tf "$BUNDLE_PATH/containerd.tar" | xargs -n 1 echo '/' | sed 's/ //g'  | grep -e '[^/]$' | xargs rm -f`,
+		},
+	}, configSteps, []steps.Step{
+		{
+			Name:   "containerd-service",
+			When:   withoutBundleManifest,
+			Apply:  "systemctl daemon-reload && systemctl enable containerd && systemctl start containerd",
+			Verify: "systemctl is-active --quiet containerd",
+			Undo:   "systemctl stop containerd && systemctl disable containerd && systemctl daemon-reload",
+		},
+	})
+}
 
-## enable swap
-swapon -a && sed -ri '/\sswap\s/s/^#?//' /etc/fstab
+// debStep returns the step installing the deb package shipped in the bundle
+func debStep(pkg string) steps.Step {
+	return steps.Step{
+		Name:   pkg,
+		When:   withoutBundleManifest,
+		Apply:  fmt.Sprintf(`dpkg --install "$BUNDLE_PATH/%s.deb" && apt-mark hold %s`, pkg, pkg),
+		Verify: "dpkg -s " + pkg + " >>/dev/null",
+		Undo:   "dpkg --purge " + pkg,
+	}
+}
 
-rm -rf $BUNDLE_PATH`
-)
+// upgradeDebStep returns the step replacing the held deb package with the one shipped in the bundle,
+// undoing it reinstalls the package of the previous bundle
+func upgradeDebStep(pkg string) steps.Step {
+	return steps.Step{
+		Name:   pkg,
+		Apply:  fmt.Sprintf(`dpkg --force-hold --install "$BUNDLE_PATH/%s.deb"`, pkg),
+		Verify: "dpkg -s " + pkg + " >>/dev/null",
+		Undo:   fmt.Sprintf(`dpkg --force-hold --install "$PREVIOUS_BUNDLE_PATH/%s.deb"`, pkg),
+	}
+}
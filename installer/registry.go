@@ -5,7 +5,9 @@ package installer
 
 import (
 	"fmt"
+	"maps"
 	"regexp"
+	"slices"
 )
 
 type (
@@ -22,19 +24,30 @@ type filterK8sBundle struct {
 	k8sFilter string
 }
 
+// filterComponent associates the versions of a component with the k8s versions they are compatible with
+type filterComponent struct {
+	k8sFilter     string
+	component     string
+	versionFilter string
+}
+
 type (
 	filterOSBundleList  []filterOsBundlePair
 	filterK8sBundleList []filterK8sBundle
+	filterComponentList []filterComponent
 )
 
 // Registry contains
 // 1. Entries associating BYOH Bundle i.e. (OS,K8sVersion) in the Repository with Installer in Host Agent
 // 2. Entries that match a concrete OS to a BYOH Bundle OS from the Repository
 // 3. Entries that match a Major & Minor versions of K8s to any of their patch sub-versions (e.g.: 1.22.3 -> 1.22.*)
+// 4. Entries that match the versions of the components installed independently of the bundle to the K8s versions
+// they are compatible with
 type registry struct {
 	osk8sInstallerMap
 	filterOSBundleList
 	filterK8sBundleList
+	filterComponentList
 }
 
 func newRegistry() registry {
@@ -65,6 +78,41 @@ func (r *registry) AddK8sFilter(k8sFilter string) {
 	r.filterK8sBundleList = append(r.filterK8sBundleList, filterK8sBundle{k8sFilter: k8sFilter})
 }
 
+// AddComponentFilter adds the versions of a component compatible with the K8s versions of the filter
+func (r *registry) AddComponentFilter(k8sFilter, component, versionFilter string) {
+	r.filterComponentList = append(r.filterComponentList, filterComponent{k8sFilter: k8sFilter, component: component, versionFilter: versionFilter})
+}
+
+// ListComponentVersions returns the filters of the component versions compatible with the K8s version
+func (r *registry) ListComponentVersions(k8sVersion, component string) []string {
+	var result []string
+	for _, fc := range r.filterComponentList {
+		if fc.component == component && matchVersion(fc.k8sFilter, k8sVersion) {
+			result = append(result, fc.versionFilter)
+		}
+	}
+	return result
+}
+
+// ValidateComponents checks the versions of the components are compatible with the K8s version
+func (r *registry) ValidateComponents(k8sVersion string, versions map[string]string) error {
+	for _, component := range slices.Sorted(maps.Keys(versions)) {
+		compatible := slices.ContainsFunc(r.ListComponentVersions(k8sVersion, component), func(versionFilter string) bool {
+			return matchVersion(versionFilter, versions[component])
+		})
+		if !compatible {
+			return fmt.Errorf("%w: %s %s is not compatible with k8s %s", ErrComponentNotSupported, component, versions[component], k8sVersion)
+		}
+	}
+	return nil
+}
+
+// matchVersion returns true if the version matches the whole filter
+func matchVersion(filter, version string) bool {
+	matched, _ := regexp.MatchString("^"+filter+"$", version)
+	return matched
+}
+
 // ListOS returns a list of OSes supported by the registry
 func (r *registry) ListOS() (osFilter, osBundle []string) {
 	osFilter = make([]string, 0, len(r.filterOSBundleList))
@@ -132,6 +180,19 @@ func GetSupportedRegistry() registry {
 		reg.AddK8sFilter("v1.29.*")
 		reg.AddK8sFilter("v1.30.*")
 
+		// Match the component versions to the K8s versions they are compatible with
+		reg.AddComponentFilter("v1.28.*", ComponentContainerd, `v1\.7\..*`)
+		reg.AddComponentFilter("v1.29.*", ComponentContainerd, `v1\.7\..*`)
+		reg.AddComponentFilter("v1.29.*", ComponentContainerd, `v2\.0\..*`)
+		reg.AddComponentFilter("v1.30.*", ComponentContainerd, `v1\.7\..*`)
+		reg.AddComponentFilter("v1.30.*", ComponentContainerd, `v2\.0\..*`)
+		reg.AddComponentFilter("v1.28.*", ComponentCNIPlugins, `v1\..*`)
+		reg.AddComponentFilter("v1.29.*", ComponentCNIPlugins, `v1\..*`)
+		reg.AddComponentFilter("v1.30.*", ComponentCNIPlugins, `v1\..*`)
+		reg.AddComponentFilter("v1.28.*", ComponentCRITools, `v1\.28\..*`)
+		reg.AddComponentFilter("v1.29.*", ComponentCRITools, `v1\.29\..*`)
+		reg.AddComponentFilter("v1.30.*", ComponentCRITools, `v1\.30\..*`)
+
 		// Match concrete os version to repository os version
 		reg.AddOsFilter("Ubuntu_24.04.*_x86-64", linuxDistro)
 
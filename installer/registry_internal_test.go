@@ -114,5 +114,13 @@ var _ = Describe("Byohost Installer Tests", func() {
 			Expect(osBundleResult).To(ContainElements("v1.28.*", "v1.29.*", "v1.30.*"))
 			Expect(osBundleResult).To(HaveLen(3))
 		})
+
+		It("Should validate the component versions against the k8s version", func() {
+			Expect(r.ListComponentVersions("v1.30.2", ComponentContainerd)).To(HaveLen(2))
+			Expect(r.ValidateComponents("v1.29.3", map[string]string{ComponentContainerd: "v2.0.1", ComponentCNIPlugins: "v1.5.1"})).To(Succeed())
+			Expect(r.ValidateComponents("v1.28.3", map[string]string{ComponentContainerd: "v2.0.1"})).To(MatchError(ErrComponentNotSupported))
+			Expect(r.ValidateComponents("v1.29.3", map[string]string{ComponentCRITools: "v1.30.0"})).To(MatchError(ErrComponentNotSupported))
+			Expect(r.ValidateComponents("v1.22.3", map[string]string{ComponentCNIPlugins: "v1.5.1"})).To(MatchError(ErrComponentNotSupported))
+		})
 	})
 })
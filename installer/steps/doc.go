@@ -0,0 +1,7 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package steps expresses installers as ordered steps, each with apply, verify and undo commands.
+// A plan of steps is either rendered to install and uninstall scripts, or executed step by step
+// by the Engine, which rolls back the applied steps when one of them fails.
+package steps
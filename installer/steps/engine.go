@@ -0,0 +1,115 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package steps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Phase is the state of a step executed by the Engine
+type Phase string
+
+const (
+	// PhaseApplied means the step was applied and verified
+	PhaseApplied Phase = "Applied"
+	// PhaseSkipped means the step condition was not met
+	PhaseSkipped Phase = "Skipped"
+	// PhaseFailed means the step could not be applied, verified or undone
+	PhaseFailed Phase = "Failed"
+	// PhaseRolledBack means the step was undone
+	PhaseRolledBack Phase = "RolledBack"
+)
+
+// CmdRunner runs a bash command on the host
+type CmdRunner interface {
+	RunCmd(ctx context.Context, cmd string) error
+}
+
+// StepError is returned when a step fails
+type StepError struct {
+	// Step is the name of the failed step
+	Step string
+	// Err is the reason the step failed
+	Err error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("step %s failed: %v", e.Step, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// Engine executes plans step by step
+type Engine struct {
+	// Runner runs the step commands
+	Runner CmdRunner
+	// OnProgress, if set, is called each time a step changes phase.
+	// err is the reason of the failure for PhaseFailed.
+	OnProgress func(step string, phase Phase, err error)
+}
+
+// Install applies and verifies the steps of the plan in order. If a step fails, it and the steps
+// applied before it are undone in reverse order, and the StepError of the failed step is returned.
+func (e *Engine) Install(ctx context.Context, plan *Plan) error {
+	applied := []string{}
+	for _, step := range plan.Steps {
+		if step.When != "" && e.Runner.RunCmd(ctx, plan.command(step.When)) != nil {
+			e.progress(step.Name, PhaseSkipped, nil)
+			continue
+		}
+		err := e.Runner.RunCmd(ctx, plan.command(step.Apply))
+		if err == nil && step.Verify != "" {
+			if err = e.Runner.RunCmd(ctx, plan.command(step.Verify)); err != nil {
+				err = fmt.Errorf("verification failed: %w", err)
+			}
+		}
+		if err != nil {
+			e.progress(step.Name, PhaseFailed, err)
+			stepErr := &StepError{Step: step.Name, Err: err}
+			// the failed step may be partially applied
+			if rollbackErr := e.Rollback(ctx, plan, append(applied, step.Name)); rollbackErr != nil {
+				return errors.Join(stepErr, fmt.Errorf("rollback failed: %w", rollbackErr))
+			}
+			return stepErr
+		}
+		applied = append(applied, step.Name)
+		e.progress(step.Name, PhaseApplied, nil)
+	}
+	return nil
+}
+
+// Rollback undoes the applied steps of the plan in reverse order. All the steps are undone
+// even if some of them fail, the errors of the failed steps are returned.
+func (e *Engine) Rollback(ctx context.Context, plan *Plan, applied []string) error {
+	isApplied := map[string]bool{}
+	for _, name := range applied {
+		isApplied[name] = true
+	}
+	var errs []error
+	for i := len(plan.Steps) - 1; i >= 0; i-- {
+		step := plan.Steps[i]
+		if !isApplied[step.Name] {
+			continue
+		}
+		if step.Undo != "" {
+			if err := e.Runner.RunCmd(ctx, plan.command(step.Undo)); err != nil {
+				e.progress(step.Name, PhaseFailed, err)
+				errs = append(errs, &StepError{Step: step.Name, Err: err})
+				continue
+			}
+		}
+		e.progress(step.Name, PhaseRolledBack, nil)
+	}
+	return errors.Join(errs...)
+}
+
+func (e *Engine) progress(step string, phase Phase, err error) {
+	if e.OnProgress != nil {
+		e.OnProgress(step, phase, err)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package steps
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidPlan is returned when a plan cannot be parsed or has invalid steps
+var ErrInvalidPlan = errors.New("invalid installer plan")
+
+// Step is a unit of installation. Commands are bash snippets run after the plan preamble.
+type Step struct {
+	// Name identifies the step in the plan
+	Name string `json:"name"`
+	// When is a condition, the step is skipped if it fails
+	When string `json:"when,omitempty"`
+	// Apply performs the step
+	Apply string `json:"apply"`
+	// Verify checks the step was applied, it is run after Apply
+	Verify string `json:"verify,omitempty"`
+	// Undo reverts the step, it must tolerate a partially applied step
+	Undo string `json:"undo,omitempty"`
+}
+
+// Plan is an ordered list of steps. Steps are applied in order and undone in the reverse order.
+type Plan struct {
+	// Preamble is prepended to every command of the plan, it sets the shell options and variables
+	Preamble string `json:"preamble,omitempty"`
+	// Steps of the plan
+	Steps []Step `json:"steps"`
+}
+
+// Parse parses and validates a plan marshaled with Marshal
+func Parse(data []byte) (*Plan, error) {
+	plan := &Plan{}
+	if err := json.Unmarshal(data, plan); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPlan, err)
+	}
+	if err := plan.Validate(); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// Marshal returns the plan serialized to be stored in the installation secret
+func (p *Plan) Marshal() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+// Validate checks the steps of the plan have unique names and an apply command
+func (p *Plan) Validate() error {
+	names := map[string]bool{}
+	for i, step := range p.Steps {
+		if step.Name == "" {
+			return fmt.Errorf("%w: steps[%d] has no name", ErrInvalidPlan, i)
+		}
+		if names[step.Name] {
+			return fmt.Errorf("%w: duplicate step %q", ErrInvalidPlan, step.Name)
+		}
+		names[step.Name] = true
+		if strings.TrimSpace(step.Apply) == "" {
+			return fmt.Errorf("%w: step %q has no apply command", ErrInvalidPlan, step.Name)
+		}
+	}
+	return nil
+}
+
+// Step returns the step of the plan with the given name
+func (p *Plan) Step(name string) (Step, bool) {
+	for _, step := range p.Steps {
+		if step.Name == name {
+			return step, true
+		}
+	}
+	return Step{}, false
+}
+
+// InstallScript renders the plan to a script applying and verifying the steps in order
+func (p *Plan) InstallScript() string {
+	var script strings.Builder
+	script.WriteString(strings.TrimRight(p.Preamble, "\n") + "\n")
+	for _, step := range p.Steps {
+		writeStep(&script, step.Name, step.When, step.Apply, step.Verify)
+	}
+	return script.String()
+}
+
+// UninstallScript renders the plan to a script undoing the steps in reverse order
+func (p *Plan) UninstallScript() string {
+	var script strings.Builder
+	script.WriteString(strings.TrimRight(p.Preamble, "\n") + "\n")
+	for i := len(p.Steps) - 1; i >= 0; i-- {
+		if step := p.Steps[i]; step.Undo != "" {
+			writeStep(&script, "undo "+step.Name, step.When, step.Undo)
+		}
+	}
+	return script.String()
+}
+
+// ShellQuote quotes value as a single bash word, without expansion, for the commands of the steps
+func ShellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// command returns the command running snippet after the plan preamble
+func (p *Plan) command(snippet string) string {
+	return p.Preamble + "\n" + snippet
+}
+
+// writeStep writes the commands of a step, guarded by its condition.
+// Commands are not indented, as that would change the content of heredocs.
+func writeStep(script *strings.Builder, name, when string, commands ...string) {
+	script.WriteString("\n## " + name + "\n")
+	if when != "" {
+		script.WriteString("if " + when + "; then\n")
+	}
+	for _, command := range commands {
+		if command = strings.TrimSpace(command); command != "" {
+			script.WriteString(command + "\n")
+		}
+	}
+	if when != "" {
+		script.WriteString("fi\n")
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package steps_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/steps"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeRunner records the commands it runs, without the plan preamble,
+// and fails the commands listed in failing
+type fakeRunner struct {
+	preamble string
+	failing  map[string]bool
+	commands []string
+}
+
+func (f *fakeRunner) RunCmd(_ context.Context, cmd string) error {
+	cmd = strings.TrimPrefix(cmd, f.preamble+"\n")
+	f.commands = append(f.commands, cmd)
+	if f.failing[cmd] {
+		return errors.New("command failed")
+	}
+	return nil
+}
+
+var _ = Describe("Steps", func() {
+	var (
+		plan     *steps.Plan
+		runner   *fakeRunner
+		engine   *steps.Engine
+		progress []string
+	)
+
+	BeforeEach(func() {
+		plan = &steps.Plan{
+			Preamble: "set -euox pipefail",
+			Steps: []steps.Step{
+				{Name: "swap", Apply: "swapoff -a", Undo: "swapon -a"},
+				{Name: "conf", When: "[ -f conf.tar ]", Apply: "tar -xf conf.tar", Undo: "rm conf"},
+				{Name: "kubelet", Apply: "dpkg --install kubelet.deb", Verify: "dpkg -s kubelet", Undo: "dpkg --purge kubelet"},
+			},
+		}
+		runner = &fakeRunner{preamble: plan.Preamble, failing: map[string]bool{}}
+		progress = nil
+		engine = &steps.Engine{
+			Runner: runner,
+			OnProgress: func(step string, phase steps.Phase, _ error) {
+				progress = append(progress, step+"="+string(phase))
+			},
+		}
+	})
+
+	Context("When rendering a plan", func() {
+		It("should apply and verify the steps in order", func() {
+			script := plan.InstallScript()
+			Expect(script).To(HavePrefix("set -euox pipefail"))
+			Expect(script).To(ContainSubstring("if [ -f conf.tar ]; then\ntar -xf conf.tar\nfi\n"))
+			Expect(strings.Index(script, "swapoff -a")).To(BeNumerically("<", strings.Index(script, "tar -xf conf.tar")))
+			Expect(strings.Index(script, "dpkg --install kubelet.deb")).To(BeNumerically("<", strings.Index(script, "dpkg -s kubelet")))
+		})
+
+		It("should undo the steps in reverse order", func() {
+			script := plan.UninstallScript()
+			Expect(script).NotTo(ContainSubstring("swapoff"))
+			Expect(strings.Index(script, "dpkg --purge kubelet")).To(BeNumerically("<", strings.Index(script, "rm conf")))
+			Expect(strings.Index(script, "rm conf")).To(BeNumerically("<", strings.Index(script, "swapon -a")))
+		})
+
+		It("should round trip through the installation secret", func() {
+			data, err := plan.Marshal()
+			Expect(err).NotTo(HaveOccurred())
+			parsed, err := steps.Parse(data)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal(plan))
+		})
+
+		It("should reject duplicate steps", func() {
+			plan.Steps = append(plan.Steps, steps.Step{Name: "swap", Apply: "true"})
+			data, err := plan.Marshal()
+			Expect(err).NotTo(HaveOccurred())
+			_, err = steps.Parse(data)
+			Expect(err).To(MatchError(steps.ErrInvalidPlan))
+		})
+
+		It("should quote values as a single word", func() {
+			Expect(steps.ShellQuote("/opt/it's $(here)")).To(Equal(`'/opt/it'\''s $(here)'`))
+		})
+	})
+
+	Context("When installing a plan", func() {
+		It("should apply the steps and report their progress", func() {
+			Expect(engine.Install(context.TODO(), plan)).To(Succeed())
+			Expect(runner.commands).To(Equal([]string{"swapoff -a", "[ -f conf.tar ]", "tar -xf conf.tar", "dpkg --install kubelet.deb", "dpkg -s kubelet"}))
+			Expect(progress).To(Equal([]string{"swap=Applied", "conf=Applied", "kubelet=Applied"}))
+		})
+
+		It("should skip the steps whose condition is not met", func() {
+			runner.failing["[ -f conf.tar ]"] = true
+			Expect(engine.Install(context.TODO(), plan)).To(Succeed())
+			Expect(runner.commands).NotTo(ContainElement("tar -xf conf.tar"))
+			Expect(progress).To(ContainElement("conf=Skipped"))
+		})
+
+		It("should roll back the applied steps when a step fails verification", func() {
+			runner.failing["dpkg -s kubelet"] = true
+			err := engine.Install(context.TODO(), plan)
+			var stepErr *steps.StepError
+			Expect(errors.As(err, &stepErr)).To(BeTrue())
+			Expect(stepErr.Step).To(Equal("kubelet"))
+			Expect(runner.commands[len(runner.commands)-3:]).To(Equal([]string{"dpkg --purge kubelet", "rm conf", "swapon -a"}))
+			Expect(progress).To(Equal([]string{
+				"swap=Applied", "conf=Applied", "kubelet=Failed",
+				"kubelet=RolledBack", "conf=RolledBack", "swap=RolledBack",
+			}))
+		})
+
+		It("should report the steps that could not be rolled back", func() {
+			runner.failing["dpkg --install kubelet.deb"] = true
+			runner.failing["rm conf"] = true
+			err := engine.Install(context.TODO(), plan)
+			Expect(err).To(MatchError(ContainSubstring("step kubelet failed")))
+			Expect(err).To(MatchError(ContainSubstring("rollback failed")))
+			Expect(runner.commands).To(ContainElement("swapon -a"))
+			Expect(progress).To(ContainElement("conf=Failed"))
+		})
+	})
+
+	Context("When rolling back a plan", func() {
+		It("should only undo the applied steps", func() {
+			Expect(engine.Rollback(context.TODO(), plan, []string{"swap", "kubelet"})).To(Succeed())
+			Expect(runner.commands).To(Equal([]string{"dpkg --purge kubelet", "swapon -a"}))
+		})
+	})
+})
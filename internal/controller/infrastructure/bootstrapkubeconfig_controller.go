@@ -6,16 +6,26 @@ package infrastructure
 import (
 	"context"
 	b64 "encoding/base64"
+	"errors"
+	"fmt"
+	"os"
 	"time"
 
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/common/bootstraptoken"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	clientcmdlatest "k8s.io/client-go/tools/clientcmd/api/latest"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
 	bootstraputil "k8s.io/cluster-bootstrap/token/util"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
@@ -25,23 +35,31 @@ import (
 type BootstrapKubeconfigReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// ClientSet reads the cluster-info ConfigMap discovering the API server and CA data not set in the spec
+	ClientSet clientset.Interface
+	// RestConfig of the manager discovers the API server and CA data in the absence of the cluster-info ConfigMap
+	RestConfig *rest.Config
 }
 
 const (
-	// ttl is the time to live for the generated bootstrap token
-	ttl = time.Minute * 30
+	// DefaultBootstrapTokenTTL is the time to live for the generated bootstrap token if not set
+	DefaultBootstrapTokenTTL = time.Minute * 30
 )
 
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=bootstrapkubeconfigs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=bootstrapkubeconfigs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=bootstrapkubeconfigs/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;create;update;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get
 
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
+// Reconcile generates the bootstrap token of the BootstrapKubeconfig and writes its bootstrap kubeconfig
+// in a Secret owned by the BootstrapKubeconfig, referenced by its status. The token is revoked once expired
+// or once its registrations are exhausted, regenerated on demand with the RegenerateTokenAnnotation and
+// deleted with the BootstrapKubeconfig.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.21.0/pkg/reconcile
-func (r *BootstrapKubeconfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *BootstrapKubeconfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
 	logger := log.FromContext(ctx)
 	logger.Info("Reconcile request received")
 
@@ -55,28 +73,92 @@ func (r *BootstrapKubeconfigReconciler) Reconcile(ctx context.Context, req ctrl.
 		return ctrl.Result{}, err
 	}
 
-	// There already is bootstrap-kubeconfig data associated with this object
-	// Do not create secrets again
-	if bootstrapKubeconfig.Status.BootstrapKubeconfigData != nil {
+	helper, err := patch.NewHelper(bootstrapKubeconfig, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		if err := helper.Patch(ctx, bootstrapKubeconfig); err != nil && reterr == nil {
+			reterr = err
+		}
+	}()
+
+	tokenID := bootstrapKubeconfig.Status.TokenID
+	if !bootstrapKubeconfig.DeletionTimestamp.IsZero() {
+		if err = r.deleteBootstrapTokenSecret(ctx, tokenID); err != nil {
+			return ctrl.Result{}, err
+		}
+		controllerutil.RemoveFinalizer(bootstrapKubeconfig, infrastructurev1beta1.BootstrapKubeconfigFinalizer)
 		return ctrl.Result{}, nil
 	}
+	controllerutil.AddFinalizer(bootstrapKubeconfig, infrastructurev1beta1.BootstrapKubeconfigFinalizer)
+
+	now := time.Now()
+	_, regenerate := bootstrapKubeconfig.Annotations[infrastructurev1beta1.RegenerateTokenAnnotation]
+	switch {
+	case regenerate || bootstrapKubeconfig.Status.BootstrapKubeconfigData != nil:
+		// the token of the deprecated status field is regenerated in the Secret
+		logger.Info("Regenerating bootstrap token", "tokenID", tokenID)
+		if err = r.deleteBootstrapTokenSecret(ctx, tokenID); err != nil {
+			return ctrl.Result{}, err
+		}
+		result, err := r.generateBootstrapToken(ctx, bootstrapKubeconfig, now)
+		if err != nil {
+			return result, err
+		}
+		delete(bootstrapKubeconfig.Annotations, infrastructurev1beta1.RegenerateTokenAnnotation)
+		bootstrapKubeconfig.Status.BootstrapKubeconfigData = nil
+		return result, nil
+	case bootstrapKubeconfig.Status.BootstrapKubeconfigSecretRef == nil:
+		// a revoked token is only regenerated on demand
+		if tokenID != "" {
+			return ctrl.Result{}, nil
+		}
+		return r.generateBootstrapToken(ctx, bootstrapKubeconfig, now)
+	case bootstrapKubeconfig.TokenExpired(now) || bootstrapKubeconfig.RegistrationsExhausted():
+		logger.Info("Revoking bootstrap token", "tokenID", tokenID, "registrations", bootstrapKubeconfig.Status.Registrations)
+		if err = r.deleteBootstrapTokenSecret(ctx, tokenID); err != nil {
+			return ctrl.Result{}, err
+		}
+		kubeconfigSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:      bootstrapKubeconfig.Status.BootstrapKubeconfigSecretRef.Name,
+			Namespace: bootstrapKubeconfig.Namespace,
+		}}
+		if err = client.IgnoreNotFound(r.Client.Delete(ctx, kubeconfigSecret)); err != nil {
+			return ctrl.Result{}, err
+		}
+		bootstrapKubeconfig.Status.BootstrapKubeconfigSecretRef = nil
+		return ctrl.Result{}, nil
+	case bootstrapKubeconfig.Status.ExpiresAt != nil:
+		// revoke the token once expired
+		return ctrl.Result{RequeueAfter: bootstrapKubeconfig.Status.ExpiresAt.Sub(now)}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// generateBootstrapToken creates a new bootstrap token secret and writes the bootstrap kubeconfig of the token
+// in the Secret of the BootstrapKubeconfig. The bootstrap kubeconfig is rendered before the token secret is created,
+// and the token secret is deleted if the kubeconfig cannot be written, so that failed attempts do not leave valid tokens.
+func (r *BootstrapKubeconfigReconciler) generateBootstrapToken(ctx context.Context, bootstrapKubeconfig *infrastructurev1beta1.BootstrapKubeconfig, now time.Time) (ctrl.Result, error) {
+	ttl := DefaultBootstrapTokenTTL
+	if bootstrapKubeconfig.Spec.TTL != nil {
+		ttl = bootstrapKubeconfig.Spec.TTL.Duration
+	}
+	// the expiration of bootstrap tokens has a precision of seconds
+	expiresAt := metav1.NewTime(now.Add(ttl).Truncate(time.Second))
 
 	tokenStr, err := bootstraputil.GenerateBootstrapToken()
 	if err != nil {
 		return ctrl.Result{}, err
 	}
-
-	bootstrapKubeconfigSecret, err := bootstraptoken.GenerateSecretFromBootstrapToken(tokenStr, ttl)
+	tokenID, _, err := bootstraptoken.GetTokenIDSecretFromBootstrapToken(tokenStr)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	// create secret
-	err = r.Client.Create(ctx, bootstrapKubeconfigSecret)
-	if err != nil {
+	if err = r.resolveAPIServer(ctx, bootstrapKubeconfig); err != nil {
 		return ctrl.Result{}, err
 	}
-
 	bootstrapKubeconfigData, err := bootstraptoken.GenerateBootstrapKubeconfigFromBootstrapToken(tokenStr, bootstrapKubeconfig)
 	if err != nil {
 		return ctrl.Result{}, err
@@ -94,15 +176,115 @@ func (r *BootstrapKubeconfigReconciler) Reconcile(ctx context.Context, req ctrl.
 		return ctrl.Result{}, err
 	}
 
-	helper, err := patch.NewHelper(bootstrapKubeconfig, r.Client)
+	bootstrapKubeconfigSecret, err := bootstraptoken.GenerateSecretFromBootstrapToken(tokenStr, expiresAt.Time)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	bootstrapKubeconfigDataStr := string(runtimeEncodedBootstrapKubeConfig)
-	bootstrapKubeconfig.Status.BootstrapKubeconfigData = &bootstrapKubeconfigDataStr
+	// create secret
+	err = r.Client.Create(ctx, bootstrapKubeconfigSecret)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	kubeconfigSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:      bootstrapKubeconfig.SecretName(),
+		Namespace: bootstrapKubeconfig.Namespace,
+	}}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, kubeconfigSecret, func() error {
+		// the bootstrap kubeconfig is not written to a Secret of another owner or of the user
+		if kubeconfigSecret.ResourceVersion != "" && !metav1.IsControlledBy(kubeconfigSecret, bootstrapKubeconfig) {
+			return fmt.Errorf("secret %s/%s is not controlled by BootstrapKubeconfig %s", kubeconfigSecret.Namespace, kubeconfigSecret.Name, bootstrapKubeconfig.Name)
+		}
+		kubeconfigSecret.Type = corev1.SecretTypeOpaque
+		kubeconfigSecret.Data = map[string][]byte{infrastructurev1beta1.BootstrapKubeconfigSecretKey: runtimeEncodedBootstrapKubeConfig}
+		return controllerutil.SetControllerReference(bootstrapKubeconfig, kubeconfigSecret, r.Client.Scheme())
+	})
+	if err != nil {
+		// the token is regenerated on retry
+		return ctrl.Result{}, errors.Join(err, r.deleteBootstrapTokenSecret(ctx, tokenID))
+	}
+
+	bootstrapKubeconfig.Status.BootstrapKubeconfigSecretRef = &corev1.LocalObjectReference{Name: kubeconfigSecret.Name}
+	bootstrapKubeconfig.Status.TokenID = tokenID
+	bootstrapKubeconfig.Status.ExpiresAt = &expiresAt
+	bootstrapKubeconfig.Status.Registrations = 0
+	bootstrapKubeconfig.Status.RegisteredCSRs = nil
+
+	return ctrl.Result{RequeueAfter: ttl}, nil
+}
+
+// resolveAPIServer resolves in the status the API server and CA data of the bootstrap kubeconfig, the ones of
+// the spec or else the ones discovered from the cluster-info ConfigMap or else from the manager
+func (r *BootstrapKubeconfigReconciler) resolveAPIServer(ctx context.Context, bootstrapKubeconfig *infrastructurev1beta1.BootstrapKubeconfig) error {
+	server, caData := bootstrapKubeconfig.Spec.APIServer, bootstrapKubeconfig.Spec.CertificateAuthorityData
+	// the CA is not used when the server's certificate is not verified
+	discoverCAData := caData == "" && !bootstrapKubeconfig.Spec.InsecureSkipTLSVerify
+	if server == "" || discoverCAData {
+		discoveredServer, discoveredCAData, err := r.discoverAPIServer(ctx)
+		if err != nil {
+			return err
+		}
+		if server == "" {
+			server = discoveredServer
+		}
+		if discoverCAData {
+			caData = b64.StdEncoding.EncodeToString(discoveredCAData)
+		}
+	}
+	if server == "" {
+		return errors.New("API server is neither set nor discovered")
+	}
+	bootstrapKubeconfig.Status.APIServer = server
+	bootstrapKubeconfig.Status.CertificateAuthorityData = caData
+	return nil
+}
 
-	return ctrl.Result{}, helper.Patch(ctx, bootstrapKubeconfig)
+// discoverAPIServer returns the API server and the PEM encoded CA data of the cluster-info ConfigMap in kube-public,
+// published for the bootstrap of the nodes, or else the ones of the rest config of the manager.
+// When the manager runs in the cluster, its rest config is the in-cluster service address (e.g. https://10.96.0.1:443),
+// which is usually unreachable from the hosts, so the spec or the cluster-info ConfigMap should provide the API server.
+func (r *BootstrapKubeconfigReconciler) discoverAPIServer(ctx context.Context) (string, []byte, error) {
+	if r.ClientSet != nil {
+		clusterInfo, err := r.ClientSet.CoreV1().ConfigMaps(metav1.NamespacePublic).Get(ctx, bootstrapapi.ConfigMapClusterInfo, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return "", nil, err
+		}
+		if err == nil {
+			config, err := clientcmd.Load([]byte(clusterInfo.Data[bootstrapapi.KubeConfigKey]))
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid %s ConfigMap: %w", bootstrapapi.ConfigMapClusterInfo, err)
+			}
+			for _, cluster := range config.Clusters {
+				if cluster.Server != "" {
+					return cluster.Server, cluster.CertificateAuthorityData, nil
+				}
+			}
+		}
+	}
+	if r.RestConfig == nil {
+		return "", nil, nil
+	}
+	caData := r.RestConfig.CAData
+	if len(caData) == 0 && r.RestConfig.CAFile != "" {
+		var err error
+		if caData, err = os.ReadFile(r.RestConfig.CAFile); err != nil {
+			return "", nil, err
+		}
+	}
+	return r.RestConfig.Host, caData, nil
+}
+
+// deleteBootstrapTokenSecret deletes the secret of the bootstrap token, if any
+func (r *BootstrapKubeconfigReconciler) deleteBootstrapTokenSecret(ctx context.Context, tokenID string) error {
+	if tokenID == "" {
+		return nil
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:      bootstraputil.BootstrapTokenSecretName(tokenID),
+		Namespace: metav1.NamespaceSystem,
+	}}
+	return client.IgnoreNotFound(r.Client.Delete(ctx, secret))
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -5,16 +5,24 @@ package infrastructure_test
 
 import (
 	"context"
+	"time"
 
 	b64 "encoding/base64"
 
 	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
+	controllers "github.com/cohesity/cluster-api-provider-bringyourownhost/internal/controller/infrastructure"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+	bootstraputil "k8s.io/cluster-bootstrap/token/util"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -23,13 +31,13 @@ import (
 
 var _ = Describe("BootstrapKubeconfig Controller", func() {
 	var (
-		ctx                             = context.Background()
-		k8sClientUncached               client.Client
-		bootstrapKubeconfigLookupKey    types.NamespacedName
-		bootstrapKubeConfig             *infrastructurev1beta1.BootstrapKubeconfig
-		testServer                      = "123.123.123.123:1234"
-		testCAData                      = "test-ca-data"
-		existingBootstrapKubeconfigData = "i am already present"
+		ctx                               = context.Background()
+		k8sClientUncached                 client.Client
+		bootstrapKubeconfigLookupKey      types.NamespacedName
+		bootstrapKubeConfig               *infrastructurev1beta1.BootstrapKubeconfig
+		testServer                        = "123.123.123.123:1234"
+		testCAData                        = "test-ca-data"
+		existingBootstrapKubeconfigSecret = "i-am-already-present"
 	)
 
 	It("should ignore bootstrapkubeconfig if it is not found", func() {
@@ -63,33 +71,71 @@ var _ = Describe("BootstrapKubeconfig Controller", func() {
 			bootstrapKubeconfigLookupKey = types.NamespacedName{Name: bootstrapKubeConfig.Name, Namespace: bootstrapKubeConfig.Namespace}
 		})
 
-		It("should return empty result if BootstrapKubeconfigData is already present", func() {
-			helper, err := patch.NewHelper(bootstrapKubeConfig, k8sClientUncached)
-			Expect(err).NotTo(HaveOccurred())
-			bootstrapKubeConfig.Status.BootstrapKubeconfigData = &existingBootstrapKubeconfigData
-			Expect(helper.Patch(ctx, bootstrapKubeConfig)).NotTo(HaveOccurred())
-
+		reconcileBootstrapKubeconfig := func() ctrl.Result {
 			res, err := bootstrapKubeconfigReconciler.Reconcile(ctx, reconcile.Request{
 				NamespacedName: bootstrapKubeconfigLookupKey,
 			})
 			Expect(err).NotTo(HaveOccurred())
-			Expect(res).To(Equal(ctrl.Result{}))
+			return res
+		}
+
+		getBootstrapKubeconfig := func() *infrastructurev1beta1.BootstrapKubeconfig {
+			createdBootstrapKubeconfig := &infrastructurev1beta1.BootstrapKubeconfig{}
+			Expect(k8sClientUncached.Get(ctx, bootstrapKubeconfigLookupKey, createdBootstrapKubeconfig)).To(Succeed())
+			return createdBootstrapKubeconfig
+		}
+
+		// patchBootstrapKubeconfig patches the BootstrapKubeconfig and waits for the cache of the reconciler
+		patchBootstrapKubeconfig := func(mutate func(*infrastructurev1beta1.BootstrapKubeconfig)) {
+			createdBootstrapKubeconfig := getBootstrapKubeconfig()
+			helper, err := patch.NewHelper(createdBootstrapKubeconfig, k8sClientUncached)
+			Expect(err).NotTo(HaveOccurred())
+			mutate(createdBootstrapKubeconfig)
+			Expect(helper.Patch(ctx, createdBootstrapKubeconfig)).NotTo(HaveOccurred())
+			resourceVersion := getBootstrapKubeconfig().ResourceVersion
+			WaitForObjectToBeUpdatedInCache(createdBootstrapKubeconfig, func(object client.Object) bool {
+				return object.GetResourceVersion() == resourceVersion
+			})
+		}
+
+		kubeconfigSecretKey := func(b *infrastructurev1beta1.BootstrapKubeconfig) types.NamespacedName {
+			return types.NamespacedName{Name: b.SecretName(), Namespace: b.Namespace}
+		}
+
+		tokenSecretKey := func(tokenID string) types.NamespacedName {
+			return types.NamespacedName{Name: bootstraputil.BootstrapTokenSecretName(tokenID), Namespace: metav1.NamespaceSystem}
+		}
+
+		listTokenSecrets := func() []corev1.Secret {
+			secrets := &corev1.SecretList{}
+			Expect(k8sClientUncached.List(ctx, secrets, client.InNamespace(metav1.NamespaceSystem),
+				client.MatchingFields{"type": string(bootstrapapi.SecretTypeBootstrapToken)})).To(Succeed())
+			return secrets.Items
+		}
+
+		It("should return empty result if BootstrapKubeconfigSecretRef is already present", func() {
+			patchBootstrapKubeconfig(func(b *infrastructurev1beta1.BootstrapKubeconfig) {
+				b.Status.BootstrapKubeconfigSecretRef = &corev1.LocalObjectReference{Name: existingBootstrapKubeconfigSecret}
+			})
+
+			Expect(reconcileBootstrapKubeconfig()).To(Equal(ctrl.Result{}))
+			Expect(getBootstrapKubeconfig().Status.BootstrapKubeconfigSecretRef.Name).To(Equal(existingBootstrapKubeconfigSecret))
 		})
 
 		It("should generate the bootstrap kubeconfig data", func() {
-			_, err := bootstrapKubeconfigReconciler.Reconcile(ctx, reconcile.Request{
-				NamespacedName: bootstrapKubeconfigLookupKey,
-			})
-			Expect(err).NotTo(HaveOccurred())
+			res := reconcileBootstrapKubeconfig()
+			Expect(res.RequeueAfter).To(Equal(controllers.DefaultBootstrapTokenTTL))
 
-			createdBootstrapKubeconfig := &infrastructurev1beta1.BootstrapKubeconfig{}
-			err = k8sClientUncached.Get(ctx, bootstrapKubeconfigLookupKey, createdBootstrapKubeconfig)
-			Expect(err).ToNot(HaveOccurred())
+			createdBootstrapKubeconfig := getBootstrapKubeconfig()
+			Expect(createdBootstrapKubeconfig.Status.BootstrapKubeconfigSecretRef).ShouldNot(BeNil())
+			Expect(createdBootstrapKubeconfig.Status.BootstrapKubeconfigSecretRef.Name).To(Equal(createdBootstrapKubeconfig.SecretName()))
 
-			kubeconfigData := createdBootstrapKubeconfig.Status.BootstrapKubeconfigData
-			Expect(kubeconfigData).ShouldNot(BeNil())
+			// assert the kubeconfig is in a Secret owned by the BootstrapKubeconfig
+			kubeconfigSecret := &corev1.Secret{}
+			Expect(k8sClientUncached.Get(ctx, kubeconfigSecretKey(createdBootstrapKubeconfig), kubeconfigSecret)).To(Succeed())
+			Expect(metav1.IsControlledBy(kubeconfigSecret, createdBootstrapKubeconfig)).To(BeTrue())
 
-			bootstrapKubeconfigFileData, err := clientcmd.Load([]byte(*kubeconfigData))
+			bootstrapKubeconfigFileData, err := clientcmd.Load(kubeconfigSecret.Data[infrastructurev1beta1.BootstrapKubeconfigSecretKey])
 			Expect(err).NotTo(HaveOccurred())
 
 			// assert Server and CertificateAuthorityData are the same as that we have passed
@@ -97,10 +143,220 @@ var _ = Describe("BootstrapKubeconfig Controller", func() {
 
 			caDataFromStatus := bootstrapKubeconfigFileData.Clusters[infrastructurev1beta1.DefaultClusterName].CertificateAuthorityData
 			Expect(string(caDataFromStatus)).To(Equal(testCAData))
+
+			// assert the token of the kubeconfig is the one reported in the status
+			tokenID := createdBootstrapKubeconfig.Status.TokenID
+			Expect(bootstrapKubeconfigFileData.AuthInfos[infrastructurev1beta1.DefaultAuth].Token).To(HavePrefix(tokenID + "."))
+			Expect(createdBootstrapKubeconfig.Status.ExpiresAt.Time).To(BeTemporally("~", time.Now().Add(controllers.DefaultBootstrapTokenTTL), 5*time.Second))
+
+			secret := &corev1.Secret{}
+			Expect(k8sClientUncached.Get(ctx, tokenSecretKey(tokenID), secret)).To(Succeed())
+			Expect(string(secret.Data[bootstrapapi.BootstrapTokenExpirationKey])).To(Equal(createdBootstrapKubeconfig.Status.ExpiresAt.UTC().Format(time.RFC3339)))
+		})
+
+		It("should report the API server and CA data of the bootstrap kubeconfig in the status", func() {
+			reconcileBootstrapKubeconfig()
+
+			createdBootstrapKubeconfig := getBootstrapKubeconfig()
+			Expect(createdBootstrapKubeconfig.Status.APIServer).To(Equal(testServer))
+			Expect(createdBootstrapKubeconfig.Status.CertificateAuthorityData).To(Equal(b64.StdEncoding.EncodeToString([]byte(testCAData))))
+		})
+
+		Context("When the API server and CA data are not set", func() {
+			BeforeEach(func() {
+				patchBootstrapKubeconfig(func(b *infrastructurev1beta1.BootstrapKubeconfig) {
+					b.Spec.APIServer = ""
+					b.Spec.CertificateAuthorityData = ""
+				})
+			})
+
+			It("should discover them from the cluster-info ConfigMap", func() {
+				clusterInfoKubeconfig, err := clientcmd.Write(clientcmdapi.Config{
+					Clusters: map[string]*clientcmdapi.Cluster{"": {
+						Server:                   "https://10.0.0.1:6443",
+						CertificateAuthorityData: []byte("cluster-info-ca-data"),
+					}},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				clusterInfo := &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: bootstrapapi.ConfigMapClusterInfo, Namespace: metav1.NamespacePublic},
+					Data:       map[string]string{bootstrapapi.KubeConfigKey: string(clusterInfoKubeconfig)},
+				}
+				_, err = clientSetFake.CoreV1().ConfigMaps(metav1.NamespacePublic).Create(ctx, clusterInfo, metav1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				defer func() {
+					Expect(clientSetFake.CoreV1().ConfigMaps(metav1.NamespacePublic).Delete(ctx, clusterInfo.Name, metav1.DeleteOptions{})).To(Succeed())
+				}()
+
+				reconcileBootstrapKubeconfig()
+
+				createdBootstrapKubeconfig := getBootstrapKubeconfig()
+				Expect(createdBootstrapKubeconfig.Status.APIServer).To(Equal("https://10.0.0.1:6443"))
+				Expect(createdBootstrapKubeconfig.Status.CertificateAuthorityData).To(Equal(b64.StdEncoding.EncodeToString([]byte("cluster-info-ca-data"))))
+
+				kubeconfigSecret := &corev1.Secret{}
+				Expect(k8sClientUncached.Get(ctx, kubeconfigSecretKey(createdBootstrapKubeconfig), kubeconfigSecret)).To(Succeed())
+				bootstrapKubeconfigFileData, err := clientcmd.Load(kubeconfigSecret.Data[infrastructurev1beta1.BootstrapKubeconfigSecretKey])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bootstrapKubeconfigFileData.Clusters[infrastructurev1beta1.DefaultClusterName].Server).To(Equal("https://10.0.0.1:6443"))
+				Expect(string(bootstrapKubeconfigFileData.Clusters[infrastructurev1beta1.DefaultClusterName].CertificateAuthorityData)).To(Equal("cluster-info-ca-data"))
+			})
+
+			It("should discover them from the manager without the cluster-info ConfigMap", func() {
+				reconcileBootstrapKubeconfig()
+
+				createdBootstrapKubeconfig := getBootstrapKubeconfig()
+				Expect(createdBootstrapKubeconfig.Status.APIServer).To(Equal(cfg.Host))
+				Expect(createdBootstrapKubeconfig.Status.CertificateAuthorityData).To(Equal(b64.StdEncoding.EncodeToString(cfg.CAData)))
+			})
+
+			It("should not create a bootstrap token when the API server is not discovered", func() {
+				tokenSecrets := listTokenSecrets()
+				reconciler := &controllers.BootstrapKubeconfigReconciler{Client: bootstrapKubeconfigReconciler.Client}
+
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: bootstrapKubeconfigLookupKey})
+				Expect(err).To(MatchError(ContainSubstring("API server is neither set nor discovered")))
+				Expect(listTokenSecrets()).To(Equal(tokenSecrets))
+			})
+		})
+
+		It("should generate the bootstrap token with the TTL of the spec", func() {
+			patchBootstrapKubeconfig(func(b *infrastructurev1beta1.BootstrapKubeconfig) {
+				b.Spec.TTL = &metav1.Duration{Duration: time.Hour}
+			})
+
+			Expect(reconcileBootstrapKubeconfig().RequeueAfter).To(Equal(time.Hour))
+			Expect(getBootstrapKubeconfig().Status.ExpiresAt.Time).To(BeTemporally("~", time.Now().Add(time.Hour), 5*time.Second))
+		})
+
+		It("should revoke the expired bootstrap token", func() {
+			reconcileBootstrapKubeconfig()
+			tokenID := getBootstrapKubeconfig().Status.TokenID
+			patchBootstrapKubeconfig(func(b *infrastructurev1beta1.BootstrapKubeconfig) {
+				b.Status.ExpiresAt = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+			})
+
+			reconcileBootstrapKubeconfig()
+			createdBootstrapKubeconfig := getBootstrapKubeconfig()
+			Expect(createdBootstrapKubeconfig.Status.BootstrapKubeconfigSecretRef).To(BeNil())
+			Expect(createdBootstrapKubeconfig.Status.TokenID).To(Equal(tokenID))
+			Expect(apierrors.IsNotFound(k8sClientUncached.Get(ctx, tokenSecretKey(tokenID), &corev1.Secret{}))).To(BeTrue())
+			Expect(apierrors.IsNotFound(k8sClientUncached.Get(ctx, kubeconfigSecretKey(createdBootstrapKubeconfig), &corev1.Secret{}))).To(BeTrue())
+
+			// the revoked token is not regenerated until requested
+			WaitForObjectToBeUpdatedInCache(createdBootstrapKubeconfig, func(object client.Object) bool {
+				return object.(*infrastructurev1beta1.BootstrapKubeconfig).Status.BootstrapKubeconfigSecretRef == nil
+			})
+			reconcileBootstrapKubeconfig()
+			Expect(getBootstrapKubeconfig().Status.BootstrapKubeconfigSecretRef).To(BeNil())
+		})
+
+		It("should revoke the bootstrap token once its registrations are exhausted", func() {
+			patchBootstrapKubeconfig(func(b *infrastructurev1beta1.BootstrapKubeconfig) {
+				b.Spec.MaxRegistrations = ptr.To(int32(2))
+			})
+			reconcileBootstrapKubeconfig()
+			tokenID := getBootstrapKubeconfig().Status.TokenID
+
+			patchBootstrapKubeconfig(func(b *infrastructurev1beta1.BootstrapKubeconfig) {
+				b.Status.Registrations = 1
+			})
+			reconcileBootstrapKubeconfig()
+			Expect(getBootstrapKubeconfig().Status.BootstrapKubeconfigSecretRef).NotTo(BeNil())
+
+			patchBootstrapKubeconfig(func(b *infrastructurev1beta1.BootstrapKubeconfig) {
+				b.Status.Registrations = 2
+			})
+			reconcileBootstrapKubeconfig()
+			Expect(getBootstrapKubeconfig().Status.BootstrapKubeconfigSecretRef).To(BeNil())
+			Expect(apierrors.IsNotFound(k8sClientUncached.Get(ctx, tokenSecretKey(tokenID), &corev1.Secret{}))).To(BeTrue())
+		})
+
+		It("should regenerate the bootstrap token on demand", func() {
+			reconcileBootstrapKubeconfig()
+			tokenID := getBootstrapKubeconfig().Status.TokenID
+			patchBootstrapKubeconfig(func(b *infrastructurev1beta1.BootstrapKubeconfig) {
+				b.Status.Registrations = 3
+				b.Annotations = map[string]string{infrastructurev1beta1.RegenerateTokenAnnotation: ""}
+			})
+
+			reconcileBootstrapKubeconfig()
+			createdBootstrapKubeconfig := getBootstrapKubeconfig()
+			Expect(createdBootstrapKubeconfig.Annotations).NotTo(HaveKey(infrastructurev1beta1.RegenerateTokenAnnotation))
+			Expect(createdBootstrapKubeconfig.Status.TokenID).NotTo(Equal(tokenID))
+			Expect(createdBootstrapKubeconfig.Status.BootstrapKubeconfigSecretRef).NotTo(BeNil())
+			Expect(createdBootstrapKubeconfig.Status.Registrations).To(BeZero())
+			Expect(apierrors.IsNotFound(k8sClientUncached.Get(ctx, tokenSecretKey(tokenID), &corev1.Secret{}))).To(BeTrue())
+			Expect(k8sClientUncached.Get(ctx, tokenSecretKey(createdBootstrapKubeconfig.Status.TokenID), &corev1.Secret{})).To(Succeed())
+
+			kubeconfigSecret := &corev1.Secret{}
+			Expect(k8sClientUncached.Get(ctx, kubeconfigSecretKey(createdBootstrapKubeconfig), kubeconfigSecret)).To(Succeed())
+			Expect(string(kubeconfigSecret.Data[infrastructurev1beta1.BootstrapKubeconfigSecretKey])).To(ContainSubstring("token: " + createdBootstrapKubeconfig.Status.TokenID + "."))
+		})
+
+		It("should regenerate the bootstrap token reported in the deprecated status field in a Secret", func() {
+			reconcileBootstrapKubeconfig()
+			tokenID := getBootstrapKubeconfig().Status.TokenID
+			patchBootstrapKubeconfig(func(b *infrastructurev1beta1.BootstrapKubeconfig) {
+				b.Status.BootstrapKubeconfigData = ptr.To("bootstrap-kubeconfig-of-" + tokenID)
+				b.Status.BootstrapKubeconfigSecretRef = nil
+			})
+
+			reconcileBootstrapKubeconfig()
+			createdBootstrapKubeconfig := getBootstrapKubeconfig()
+			Expect(createdBootstrapKubeconfig.Status.BootstrapKubeconfigData).To(BeNil())
+			Expect(createdBootstrapKubeconfig.Status.BootstrapKubeconfigSecretRef).NotTo(BeNil())
+			Expect(createdBootstrapKubeconfig.Status.TokenID).NotTo(Equal(tokenID))
+			Expect(apierrors.IsNotFound(k8sClientUncached.Get(ctx, tokenSecretKey(tokenID), &corev1.Secret{}))).To(BeTrue())
+		})
+
+		It("should not write the bootstrap kubeconfig to a Secret it does not control", func() {
+			userSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: bootstrapKubeConfig.SecretName(), Namespace: bootstrapKubeConfig.Namespace},
+				Data:       map[string][]byte{"user": []byte("data")},
+			}
+			Expect(k8sClientUncached.Create(ctx, userSecret)).To(Succeed())
+			defer func() {
+				Expect(k8sClientUncached.Delete(ctx, userSecret)).To(Succeed())
+			}()
+			WaitForObjectsToBePopulatedInCache(userSecret)
+			tokenSecrets := listTokenSecrets()
+
+			_, err := bootstrapKubeconfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: bootstrapKubeconfigLookupKey})
+			Expect(err).To(MatchError(ContainSubstring("is not controlled by BootstrapKubeconfig")))
+			Expect(getBootstrapKubeconfig().Status.BootstrapKubeconfigSecretRef).To(BeNil())
+			Expect(k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(userSecret), userSecret)).To(Succeed())
+			Expect(userSecret.Data).To(Equal(map[string][]byte{"user": []byte("data")}))
+			Expect(userSecret.OwnerReferences).To(BeEmpty())
+			Expect(listTokenSecrets()).To(Equal(tokenSecrets))
+		})
+
+		It("should delete the bootstrap token secret with the BootstrapKubeconfig", func() {
+			reconcileBootstrapKubeconfig()
+			createdBootstrapKubeconfig := getBootstrapKubeconfig()
+			Expect(createdBootstrapKubeconfig.Finalizers).To(ContainElement(infrastructurev1beta1.BootstrapKubeconfigFinalizer))
+
+			Expect(k8sClientUncached.Delete(ctx, createdBootstrapKubeconfig)).To(Succeed())
+			WaitForObjectToBeUpdatedInCache(createdBootstrapKubeconfig, func(object client.Object) bool {
+				return !object.GetDeletionTimestamp().IsZero()
+			})
+			reconcileBootstrapKubeconfig()
+			Expect(apierrors.IsNotFound(k8sClientUncached.Get(ctx, tokenSecretKey(createdBootstrapKubeconfig.Status.TokenID), &corev1.Secret{}))).To(BeTrue())
+			Eventually(func() bool {
+				return apierrors.IsNotFound(k8sClientUncached.Get(ctx, bootstrapKubeconfigLookupKey, &infrastructurev1beta1.BootstrapKubeconfig{}))
+			}).Should(BeTrue())
 		})
 
 		AfterEach(func() {
-			Expect(k8sClientUncached.Delete(ctx, bootstrapKubeConfig)).ToNot(HaveOccurred())
+			err := k8sClientUncached.Delete(ctx, bootstrapKubeConfig)
+			if apierrors.IsNotFound(err) {
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+			WaitForObjectToBeUpdatedInCache(bootstrapKubeConfig, func(object client.Object) bool {
+				return !object.GetDeletionTimestamp().IsZero()
+			})
+			reconcileBootstrapKubeconfig()
 		})
 	})
 })
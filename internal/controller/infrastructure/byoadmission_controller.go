@@ -5,30 +5,79 @@ package infrastructure
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"path"
+	"slices"
 	"strings"
 
+	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
 	certv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	clientset "k8s.io/client-go/kubernetes"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
+const (
+	// byohCSRNamePrefix prefixes the name of the CSRs of the hosts
+	byohCSRNamePrefix = "byoh-csr-"
+	// byohHostUsernamePrefix prefixes the hostname in the common name of the host certificates,
+	// which is the username of the hosts
+	byohHostUsernamePrefix = "byoh:host:"
+	// byohHostsGroup is the organization of the host certificates, which is the group of the hosts
+	byohHostsGroup = "byoh:hosts"
+
+	// DefaultMaxExpirationSeconds is the default limit of the duration of the host certificates,
+	// one year as requested by the agent by default
+	DefaultMaxExpirationSeconds int32 = 86400 * 365
+
+	// csrApprovedReason is the reason of the Approved condition of the approved CSRs
+	csrApprovedReason = "Approved by ByoAdmission Controller"
+	// csrDeniedReason is the reason of the Denied condition of the CSRs failing the checks
+	csrDeniedReason = "DeniedByByoAdmissionController"
+)
+
+// allowedHostKeyUsages are the key usages host certificates may request, client auth being required
+var allowedHostKeyUsages = []certv1.KeyUsage{certv1.UsageClientAuth, certv1.UsageDigitalSignature, certv1.UsageKeyEncipherment}
+
 // ByoAdmissionReconciler reconciles a ByoAdmission object
 type ByoAdmissionReconciler struct {
 	ClientSet clientset.Interface
+	// Client reads the ByoAdmissionPolicies
+	Client client.Client
+	// MaxExpirationSeconds limits the duration of the host certificates, DefaultMaxExpirationSeconds if not set
+	MaxExpirationSeconds int32
+	// ManualHostAdmission holds the CSRs of the hosts until their ByoHostAdmission is approved
+	ManualHostAdmission bool
 }
 
 // +kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests,verbs=create;get;list;watch
 // +kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests/approval,verbs=update
 // +kubebuilder:rbac:groups=certificates.k8s.io,resources=signers,resourceNames=kubernetes.io/kube-apiserver-client,verbs=approve
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byoadmissionpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohostadmissions,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohostadmissions/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=bootstrapkubeconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=bootstrapkubeconfigs/status,verbs=get;update;patch
 
-// Reconcile continuosuly checks for CSRs and approves them
+// Reconcile continuosuly checks for CSRs and approves the ones matching the requesting host,
+// denying the others with the reason of the failed check
 func (r *ByoAdmissionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	var err error
 	logger := log.FromContext(ctx)
@@ -50,6 +99,8 @@ func (r *ByoAdmissionReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	if csrApproved || csrDenied {
 		if csrApproved {
 			logger.Info("CertificateSigningRequest is already approved", "CSR", csr.Name)
+			// the registration is not counted yet if the count failed after the approval
+			return ctrl.Result{}, r.recordApprovedRegistration(ctx, csr)
 		}
 		if csrDenied {
 			logger.Info("CertificateSigningRequest is already denied", "CSR", csr.Name)
@@ -57,25 +108,335 @@ func (r *ByoAdmissionReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, nil
 	}
 
+	bootstrapKubeconfig, err := r.getBootstrapKubeconfigOfToken(ctx, csr)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	hostname, err := validateHostCSR(csr, bootstrapKubeconfig)
+	if err != nil {
+		return reconcile.Result{}, r.denyCSR(ctx, csr, err.Error())
+	}
+	namespace, err := csrHostNamespace(csr)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	// the hosts bound to a namespace are only subject to its policies, the others to the policies of all namespaces
+	policies := &infrastructurev1beta1.ByoAdmissionPolicyList{}
+	if err = r.Client.List(ctx, policies, client.InNamespace(namespace)); err != nil {
+		return reconcile.Result{}, err
+	}
+	if err = r.validateAdmissionPolicies(csr, hostname, policies.Items); err != nil {
+		return reconcile.Result{}, r.denyCSR(ctx, csr, err.Error())
+	}
+
+	if r.ManualHostAdmission {
+		if namespace == "" {
+			// the admission of the host is held in its namespace, a host bound to none may register in all of them
+			return reconcile.Result{}, r.denyCSR(ctx, csr, fmt.Sprintf("host %s is not bound to a namespace to hold its ByoHostAdmission", hostname))
+		}
+		phase, err := r.reconcileHostAdmission(ctx, csr, hostname, namespace)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		switch phase {
+		case infrastructurev1beta1.HostAdmissionPendingApproval:
+			logger.Info("CSR is pending the approval of the ByoHostAdmission", "object", req.NamespacedName, "host", hostname)
+			return ctrl.Result{}, nil
+		case infrastructurev1beta1.HostAdmissionPhaseRejected:
+			return reconcile.Result{}, r.denyCSR(ctx, csr, fmt.Sprintf("host %s is rejected by its ByoHostAdmission", hostname))
+		}
+	}
+
+	if bootstrapKubeconfig != nil && bootstrapKubeconfig.RegistrationsExhausted() {
+		return reconcile.Result{}, r.denyCSR(ctx, csr, fmt.Sprintf("registrations of %s reached the maximum of its BootstrapKubeconfig", csr.Spec.Username))
+	}
+
 	// Update the CSR to the "Approved" condition
 	csr.Status.Conditions = append(csr.Status.Conditions, certv1.CertificateSigningRequestCondition{
 		Type:   certv1.CertificateApproved,
 		Status: corev1.ConditionTrue,
-		Reason: "Approved by ByoAdmission Controller",
+		Reason: csrApprovedReason,
 	})
 
 	// Approve the CSR
-	logger.Info("Approving CSR", "object", req.NamespacedName)
-	_, err = r.ClientSet.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{})
+	logger.Info("Approving CSR", "object", req.NamespacedName, "host", hostname)
+	approvedCSR, err := r.ClientSet.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{})
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
 	logger.Info("CSR Approved", "object", req.NamespacedName)
+	if err = r.countBootstrapTokenRegistration(ctx, bootstrapKubeconfig, approvedCSR); err != nil {
+		return reconcile.Result{}, err
+	}
 
 	return ctrl.Result{}, nil
 }
 
+// denyCSR denies the CSR with the reason of the failed check
+func (r *ByoAdmissionReconciler) denyCSR(ctx context.Context, csr *certv1.CertificateSigningRequest, message string) error {
+	log.FromContext(ctx).Info("Denying CSR", "CSR", csr.Name, "reason", message)
+	csr.Status.Conditions = append(csr.Status.Conditions, certv1.CertificateSigningRequestCondition{
+		Type:    certv1.CertificateDenied,
+		Status:  corev1.ConditionTrue,
+		Reason:  csrDeniedReason,
+		Message: message,
+	})
+	_, err := r.ClientSet.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{})
+	return err
+}
+
+// getBootstrapKubeconfigOfToken returns the BootstrapKubeconfig of the bootstrap token requesting the CSR,
+// nil if the CSR is not requested with a bootstrap token of a BootstrapKubeconfig
+func (r *ByoAdmissionReconciler) getBootstrapKubeconfigOfToken(ctx context.Context, csr *certv1.CertificateSigningRequest) (*infrastructurev1beta1.BootstrapKubeconfig, error) {
+	tokenID, found := strings.CutPrefix(csr.Spec.Username, bootstrapapi.BootstrapUserPrefix)
+	if !found {
+		// the hosts renew their certificate with their own certificate
+		return nil, nil
+	}
+	bootstrapKubeconfigs := &infrastructurev1beta1.BootstrapKubeconfigList{}
+	if err := r.Client.List(ctx, bootstrapKubeconfigs); err != nil {
+		return nil, err
+	}
+	for i := range bootstrapKubeconfigs.Items {
+		if bootstrapKubeconfigs.Items[i].Status.TokenID == tokenID {
+			return &bootstrapKubeconfigs.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// recordApprovedRegistration counts the registration of a CSR approved by the controller
+// in the BootstrapKubeconfig of its bootstrap token, if not counted yet
+func (r *ByoAdmissionReconciler) recordApprovedRegistration(ctx context.Context, csr *certv1.CertificateSigningRequest) error {
+	approvedByController := slices.ContainsFunc(csr.Status.Conditions, func(condition certv1.CertificateSigningRequestCondition) bool {
+		return condition.Type == certv1.CertificateApproved && condition.Reason == csrApprovedReason
+	})
+	if !approvedByController {
+		return nil
+	}
+	bootstrapKubeconfig, err := r.getBootstrapKubeconfigOfToken(ctx, csr)
+	if err != nil {
+		return err
+	}
+	return r.countBootstrapTokenRegistration(ctx, bootstrapKubeconfig, csr)
+}
+
+// countBootstrapTokenRegistration counts the registration of a host approved with a bootstrap token
+// in the BootstrapKubeconfig of the token, if any. The CSR is recorded with the count, so that a CSR
+// is counted once however many times its approval is reconciled.
+func (r *ByoAdmissionReconciler) countBootstrapTokenRegistration(ctx context.Context, bootstrapKubeconfig *infrastructurev1beta1.BootstrapKubeconfig, csr *certv1.CertificateSigningRequest) error {
+	if bootstrapKubeconfig == nil || slices.Contains(bootstrapKubeconfig.Status.RegisteredCSRs, string(csr.UID)) {
+		return nil
+	}
+	original := bootstrapKubeconfig.DeepCopy()
+	bootstrapKubeconfig.Status.Registrations++
+	bootstrapKubeconfig.Status.RegisteredCSRs = append(bootstrapKubeconfig.Status.RegisteredCSRs, string(csr.UID))
+	// conflicts with a stale count rather than losing a registration
+	return r.Client.Status().Patch(ctx, bootstrapKubeconfig, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{}))
+}
+
+// reconcileHostAdmission creates the ByoHostAdmission of the host in the namespace it is bound to if missing,
+// reports the CSR in its status and returns the phase decided by the administrator. The approval is reset when
+// a bootstrap token requests a certificate for another key than the approved one, as any holder of the token
+// may request the hostname.
+func (r *ByoAdmissionReconciler) reconcileHostAdmission(ctx context.Context, csr *certv1.CertificateSigningRequest, hostname, namespace string) (infrastructurev1beta1.HostAdmissionPhase, error) {
+	publicKeySHA256, err := csrPublicKeySHA256(csr)
+	if err != nil {
+		return "", err
+	}
+	admission := &infrastructurev1beta1.ByoHostAdmission{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: hostname}, admission)
+	if apierrors.IsNotFound(err) {
+		admission = &infrastructurev1beta1.ByoHostAdmission{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: hostname}}
+		err = r.Client.Create(ctx, admission)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	helper, err := patch.NewHelper(admission, r.Client)
+	if err != nil {
+		return "", err
+	}
+	if admission.Decision() == infrastructurev1beta1.HostAdmissionPhaseApproved &&
+		slices.Contains(csr.Spec.Groups, infrastructurev1beta1.BootstrapTokenExtraGroups) &&
+		admission.Status.PublicKeySHA256 != "" && admission.Status.PublicKeySHA256 != publicKeySHA256 {
+		log.FromContext(ctx).Info("Resetting the approval of the host requesting a certificate for another key", "host", hostname, "CSR", csr.Name)
+		delete(admission.Annotations, infrastructurev1beta1.HostAdmissionAnnotation)
+	}
+	admission.Status.Phase = admission.Decision()
+	admission.Status.CSRName = csr.Name
+	admission.Status.PublicKeySHA256 = publicKeySHA256
+	admission.Status.Requester = csr.Spec.Username
+	admission.Status.RequestedAt = csr.CreationTimestamp.DeepCopy()
+	return admission.Status.Phase, helper.Patch(ctx, admission)
+}
+
+// csrPublicKeySHA256 returns the hex encoded SHA-256 digest of the public key of the certificate request of the CSR
+func csrPublicKeySHA256(csr *certv1.CertificateSigningRequest) (string, error) {
+	request, err := parseCertificateRequest(csr)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(request.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(digest[:]), nil
+}
+
+// csrHostNamespace returns the namespace the certificate request of the CSR binds the host to, empty if none
+func csrHostNamespace(csr *certv1.CertificateSigningRequest) (string, error) {
+	request, err := parseCertificateRequest(csr)
+	if err != nil {
+		return "", err
+	}
+	namespace, _ := infrastructurev1beta1.HostBinding(request.Subject.Organization)
+	return namespace, nil
+}
+
+// parseCertificateRequest returns the certificate request of the CSR
+func parseCertificateRequest(csr *certv1.CertificateSigningRequest) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil {
+		return nil, fmt.Errorf("request is not a PEM encoded certificate request")
+	}
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate request: %w", err)
+	}
+	return request, nil
+}
+
+// validateHostCSR checks the CSR requests a client certificate of a host, for the host itself and
+// its current bindings when requested with the certificate of a host, within the bindings of the
+// BootstrapKubeconfig of the bootstrap token requesting it, if any, and returns the hostname
+func validateHostCSR(csr *certv1.CertificateSigningRequest, bootstrapKubeconfig *infrastructurev1beta1.BootstrapKubeconfig) (string, error) {
+	if csr.Spec.SignerName != certv1.KubeAPIServerClientSignerName {
+		return "", fmt.Errorf("signer %s is not %s", csr.Spec.SignerName, certv1.KubeAPIServerClientSignerName)
+	}
+	if !slices.Contains(csr.Spec.Usages, certv1.UsageClientAuth) {
+		return "", fmt.Errorf("key usage %s is not requested", certv1.UsageClientAuth)
+	}
+	for _, usage := range csr.Spec.Usages {
+		if !slices.Contains(allowedHostKeyUsages, usage) {
+			return "", fmt.Errorf("key usage %s is not allowed", usage)
+		}
+	}
+
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return "", fmt.Errorf("request is not a PEM encoded certificate request")
+	}
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("invalid certificate request: %w", err)
+	}
+	if err = request.CheckSignature(); err != nil {
+		return "", fmt.Errorf("invalid certificate request signature: %w", err)
+	}
+	hostname, found := strings.CutPrefix(request.Subject.CommonName, byohHostUsernamePrefix)
+	if !found || hostname == "" {
+		return "", fmt.Errorf("subject common name %q is not %s<hostname>", request.Subject.CommonName, byohHostUsernamePrefix)
+	}
+	organizations := request.Subject.Organization
+	if !slices.Contains(organizations, byohHostsGroup) {
+		return "", fmt.Errorf("subject organization %v does not include %s", organizations, byohHostsGroup)
+	}
+	var bindingGroups []string
+	namespaces := 0
+	for _, group := range organizations {
+		if group == byohHostsGroup {
+			continue
+		}
+		bindingGroups = append(bindingGroups, group)
+		if !infrastructurev1beta1.IsHostBindingGroup(group) {
+			return "", fmt.Errorf("subject organization %s is neither %s<namespace> nor %s<key>=<value>",
+				group, infrastructurev1beta1.HostNamespaceGroupPrefix, infrastructurev1beta1.HostLabelGroupPrefix)
+		}
+		if strings.HasPrefix(group, infrastructurev1beta1.HostNamespaceGroupPrefix) {
+			namespaces++
+		}
+	}
+	if namespaces > 1 {
+		return "", fmt.Errorf("subject organization %v binds the host to more than one namespace", organizations)
+	}
+	if len(request.DNSNames) > 0 || len(request.IPAddresses) > 0 || len(request.EmailAddresses) > 0 || len(request.URIs) > 0 {
+		return "", fmt.Errorf("subject alternative names are not allowed in host certificates")
+	}
+
+	// hosts bootstrap with a byoh bootstrap token, then renew their certificate with it
+	switch {
+	case slices.Contains(csr.Spec.Groups, infrastructurev1beta1.BootstrapTokenExtraGroups):
+		// the bindings of the host are only known from the BootstrapKubeconfig of the bootstrap token
+		if bootstrapKubeconfig == nil {
+			return "", fmt.Errorf("bootstrap token of %s is not generated for any BootstrapKubeconfig", csr.Spec.Username)
+		}
+		// the hosts may bind themselves further than the BootstrapKubeconfig of their bootstrap token
+		for _, group := range bootstrapKubeconfig.HostBindingGroups() {
+			if !slices.Contains(bindingGroups, group) {
+				return "", fmt.Errorf("subject organization %v lacks %s required by BootstrapKubeconfig %s/%s",
+					organizations, group, bootstrapKubeconfig.Namespace, bootstrapKubeconfig.Name)
+			}
+		}
+	case slices.Contains(csr.Spec.Groups, byohHostsGroup) && csr.Spec.Username == request.Subject.CommonName:
+		// the hosts keep their bindings when renewing their certificate
+		var currentBindingGroups []string
+		for _, group := range csr.Spec.Groups {
+			if infrastructurev1beta1.IsHostBindingGroup(group) {
+				currentBindingGroups = append(currentBindingGroups, group)
+			}
+		}
+		if !sets.New(bindingGroups...).Equal(sets.New(currentBindingGroups...)) {
+			return "", fmt.Errorf("subject organization %v changes the bindings %v of host %s", organizations, currentBindingGroups, hostname)
+		}
+	default:
+		return "", fmt.Errorf("requester %s is neither a byoh bootstrap token nor host %s", csr.Spec.Username, hostname)
+	}
+	return hostname, nil
+}
+
+// validateAdmissionPolicies checks the hostname is allowed by the ByoAdmissionPolicies, if any,
+// and the requested duration is within the limits of the manager and of the matching policies
+func (r *ByoAdmissionReconciler) validateAdmissionPolicies(csr *certv1.CertificateSigningRequest, hostname string, policies []infrastructurev1beta1.ByoAdmissionPolicy) error {
+	maxExpirationSeconds := r.MaxExpirationSeconds
+	if maxExpirationSeconds == 0 {
+		maxExpirationSeconds = DefaultMaxExpirationSeconds
+	}
+	if len(policies) > 0 {
+		// the most permissive matching policy applies
+		var policyMaxExpirationSeconds int32
+		for i := range policies {
+			if !matchesHostname(policies[i].Spec.HostnamePatterns, hostname) {
+				continue
+			}
+			limit := maxExpirationSeconds
+			if policies[i].Spec.MaxExpirationSeconds != nil {
+				limit = min(limit, *policies[i].Spec.MaxExpirationSeconds)
+			}
+			policyMaxExpirationSeconds = max(policyMaxExpirationSeconds, limit)
+		}
+		if policyMaxExpirationSeconds == 0 {
+			return fmt.Errorf("hostname %s is not allowed by any ByoAdmissionPolicy", hostname)
+		}
+		maxExpirationSeconds = policyMaxExpirationSeconds
+	}
+	if csr.Spec.ExpirationSeconds == nil {
+		// the duration of the certificate would be the default of the signer, which cannot be checked
+		return fmt.Errorf("no expiration is requested: CSRs without expirationSeconds are deliberately denied, request at most %d seconds", maxExpirationSeconds)
+	}
+	if *csr.Spec.ExpirationSeconds > maxExpirationSeconds {
+		return fmt.Errorf("requested expiration of %d seconds exceeds the %d seconds allowed", *csr.Spec.ExpirationSeconds, maxExpirationSeconds)
+	}
+	return nil
+}
+
+// matchesHostname returns true if the hostname matches one of the shell patterns
+func matchesHostname(patterns []string, hostname string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		matched, _ := path.Match(pattern, hostname)
+		return matched
+	})
+}
+
 // Check if the CSR has the given condition.
 func checkCSRCondition(conditions []certv1.CertificateSigningRequestCondition, conditionType certv1.RequestConditionType) bool {
 	for _, condition := range conditions {
@@ -88,16 +449,30 @@ func checkCSRCondition(conditions []certv1.CertificateSigningRequestCondition, c
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ByoAdmissionReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&certv1.CertificateSigningRequest{}).WithEventFilter(
-		// watch only BYOH created CSRs
-		predicate.Funcs{
-			CreateFunc: func(e event.CreateEvent) bool {
-				return strings.HasPrefix(e.Object.GetName(), "byoh-csr-")
-			},
-			UpdateFunc: func(e event.UpdateEvent) bool {
-				return strings.HasPrefix(e.ObjectOld.GetName(), "byoh-csr-")
-			},
-		}).
-		Complete(r)
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&certv1.CertificateSigningRequest{}, builder.WithPredicates(
+			// watch only BYOH created CSRs
+			predicate.Funcs{
+				CreateFunc: func(e event.CreateEvent) bool {
+					return strings.HasPrefix(e.Object.GetName(), byohCSRNamePrefix)
+				},
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					return strings.HasPrefix(e.ObjectOld.GetName(), byohCSRNamePrefix)
+				},
+			}))
+	if r.ManualHostAdmission {
+		// approve or deny the pending CSR of the host once the administrator decides
+		b = b.Watches(&infrastructurev1beta1.ByoHostAdmission{},
+			handler.EnqueueRequestsFromMapFunc(ByoHostAdmissionToCSR))
+	}
+	return b.Complete(r)
+}
+
+// ByoHostAdmissionToCSR returns the reconcile request of the last CSR of the host
+func ByoHostAdmissionToCSR(_ context.Context, o client.Object) []ctrl.Request {
+	admission, ok := o.(*infrastructurev1beta1.ByoHostAdmission)
+	if !ok || admission.Status.CSRName == "" {
+		return nil
+	}
+	return []ctrl.Request{{NamespacedName: types.NamespacedName{Name: admission.Status.CSRName}}}
 }
@@ -5,7 +5,11 @@ package infrastructure_test
 
 import (
 	"context"
+	b64 "encoding/base64"
+	"fmt"
 
+	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
+	controllers "github.com/cohesity/cluster-api-provider-bringyourownhost/internal/controller/infrastructure"
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/test/builder"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -13,6 +17,9 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -20,8 +27,33 @@ var _ = Describe("Controllers/ByoadmissionController", func() {
 	var (
 		err error
 		CSR *certv1.CertificateSigningRequest
+		// bootstrapUser is the user of the bootstrap token of a BootstrapKubeconfig binding the hosts to nothing
+		bootstrapUser string
 	)
 
+	hostCN := fmt.Sprintf("byoh:host:%s", defaultByoHostName)
+
+	reconcileAndGetCSR := func() *certv1.CertificateSigningRequest {
+		_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, CSR, v1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		objectKey := types.NamespacedName{Name: defaultByoHostName}
+		_, err = byoAdmissionReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: objectKey})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		var updatedCSR *certv1.CertificateSigningRequest
+		updatedCSR, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Get(ctx, defaultByoHostName, v1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		return updatedCSR
+	}
+
+	expectDenied := func(updatedCSR *certv1.CertificateSigningRequest, message string) {
+		Expect(updatedCSR.Status.Conditions).To(HaveLen(1))
+		Expect(updatedCSR.Status.Conditions[0].Type).To(Equal(certv1.CertificateDenied))
+		Expect(updatedCSR.Status.Conditions[0].Reason).To(Equal("DeniedByByoAdmissionController"))
+		Expect(updatedCSR.Status.Conditions[0].Message).To(ContainSubstring(message))
+	}
+
 	It("should return error for non-existent CSR", func() {
 		// Call Reconcile method for a non-existing CSR
 		objectKey := types.NamespacedName{Name: defaultByoHostName}
@@ -30,28 +62,44 @@ var _ = Describe("Controllers/ByoadmissionController", func() {
 	})
 
 	Context("When a CSR is created", func() {
+		var anyHostBootstrapKubeconfig *infrastructurev1beta1.BootstrapKubeconfig
+
 		BeforeEach(func() {
 			ctx = context.Background()
 
+			anyHostBootstrapKubeconfig = builder.BootstrapKubeconfig(defaultNamespace, "any-host").
+				WithServer("https://abc.com:1234").
+				WithCAData(b64.StdEncoding.EncodeToString([]byte("test-ca-data"))).
+				Build()
+			Expect(k8sManager.GetClient().Create(ctx, anyHostBootstrapKubeconfig)).Should(Succeed())
+			WaitForObjectsToBePopulatedInCache(anyHostBootstrapKubeconfig)
+			_, err = bootstrapKubeconfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(anyHostBootstrapKubeconfig)})
+			Expect(err).NotTo(HaveOccurred())
+			WaitForObjectToBeUpdatedInCache(anyHostBootstrapKubeconfig, func(object client.Object) bool {
+				return object.(*infrastructurev1beta1.BootstrapKubeconfig).Status.TokenID != ""
+			})
+			Expect(k8sManager.GetClient().Get(ctx, client.ObjectKeyFromObject(anyHostBootstrapKubeconfig), anyHostBootstrapKubeconfig)).Should(Succeed())
+			bootstrapUser = "system:bootstrap:" + anyHostBootstrapKubeconfig.Status.TokenID
+
 			// Create a CSR resource for each test
-			CSR, err = builder.CertificateSigningRequest(defaultByoHostName, "test-cn", "test-org", 2048).Build()
+			CSR, err = builder.CertificateSigningRequest(defaultByoHostName, hostCN, "byoh:hosts", 2048).
+				WithRequester(bootstrapUser, infrastructurev1beta1.BootstrapTokenExtraGroups).
+				WithExpirationSeconds(86400).
+				Build()
 			Expect(err).NotTo(HaveOccurred())
 		})
 
-		It("should approve the Byoh CSR", func() {
-			// Create a dummy CSR request
-			_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, CSR, v1.CreateOptions{})
-			Expect(err).ToNot(HaveOccurred())
-
-			// Call Reconcile method
-			objectKey := types.NamespacedName{Name: defaultByoHostName}
-			_, err = byoAdmissionReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: objectKey})
-			Expect(err).ShouldNot(HaveOccurred())
+		AfterEach(func() {
+			Expect(k8sManager.GetClient().Delete(ctx, anyHostBootstrapKubeconfig)).Should(Succeed())
+			WaitForObjectToBeUpdatedInCache(anyHostBootstrapKubeconfig, func(object client.Object) bool {
+				return !object.GetDeletionTimestamp().IsZero()
+			})
+			_, err = bootstrapKubeconfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(anyHostBootstrapKubeconfig)})
+			Expect(err).NotTo(HaveOccurred())
+		})
 
-			// Fetch the updated CSR
-			var updateByohCSR *certv1.CertificateSigningRequest
-			updateByohCSR, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Get(ctx, defaultByoHostName, v1.GetOptions{})
-			Expect(err).ToNot(HaveOccurred())
+		It("should approve the Byoh CSR", func() {
+			updateByohCSR := reconcileAndGetCSR()
 			Expect(updateByohCSR.Status.Conditions).Should(ContainElement(certv1.CertificateSigningRequestCondition{
 				Type:   certv1.CertificateApproved,
 				Reason: "Approved by ByoAdmission Controller",
@@ -59,6 +107,167 @@ var _ = Describe("Controllers/ByoadmissionController", func() {
 			}))
 		})
 
+		It("should approve the CSR renewing the certificate of the same host", func() {
+			CSR.Spec.Username = hostCN
+			CSR.Spec.Groups = []string{"byoh:hosts", "system:authenticated"}
+
+			updateByohCSR := reconcileAndGetCSR()
+			Expect(updateByohCSR.Status.Conditions[0].Type).To(Equal(certv1.CertificateApproved))
+		})
+
+		It("should deny the CSR requested by another host", func() {
+			CSR.Spec.Username = "byoh:host:other-host"
+			CSR.Spec.Groups = []string{"byoh:hosts"}
+
+			expectDenied(reconcileAndGetCSR(), "is neither a byoh bootstrap token nor host my-host")
+		})
+
+		It("should approve the CSR renewing the certificate of a host with its bindings", func() {
+			CSR, err = builder.CertificateSigningRequest(defaultByoHostName, hostCN, "byoh:hosts", 2048).
+				WithOrganizations("byoh:namespace:tenant-a", "byoh:label:site=apac").
+				WithRequester(hostCN, "byoh:hosts", "byoh:label:site=apac", "byoh:namespace:tenant-a", "system:authenticated").
+				WithExpirationSeconds(86400).
+				Build()
+			Expect(err).NotTo(HaveOccurred())
+
+			updateByohCSR := reconcileAndGetCSR()
+			Expect(updateByohCSR.Status.Conditions[0].Type).To(Equal(certv1.CertificateApproved))
+		})
+
+		It("should deny the CSR changing the bindings of the host", func() {
+			CSR, err = builder.CertificateSigningRequest(defaultByoHostName, hostCN, "byoh:hosts", 2048).
+				WithOrganizations("byoh:namespace:tenant-b").
+				WithRequester(hostCN, "byoh:hosts", "byoh:namespace:tenant-a", "system:authenticated").
+				Build()
+			Expect(err).NotTo(HaveOccurred())
+
+			expectDenied(reconcileAndGetCSR(), "changes the bindings [byoh:namespace:tenant-a] of host my-host")
+		})
+
+		It("should deny the CSR binding the host to more than one namespace", func() {
+			CSR, err = builder.CertificateSigningRequest(defaultByoHostName, hostCN, "byoh:hosts", 2048).
+				WithOrganizations("byoh:namespace:tenant-a", "byoh:namespace:tenant-b").
+				WithRequester(bootstrapUser, infrastructurev1beta1.BootstrapTokenExtraGroups).
+				Build()
+			Expect(err).NotTo(HaveOccurred())
+
+			expectDenied(reconcileAndGetCSR(), "binds the host to more than one namespace")
+		})
+
+		It("should deny the CSR with an unexpected organization", func() {
+			CSR, err = builder.CertificateSigningRequest(defaultByoHostName, hostCN, "byoh:hosts", 2048).
+				WithOrganizations("system:masters").
+				WithRequester(bootstrapUser, infrastructurev1beta1.BootstrapTokenExtraGroups).
+				Build()
+			Expect(err).NotTo(HaveOccurred())
+
+			expectDenied(reconcileAndGetCSR(), "subject organization system:masters is neither")
+		})
+
+		It("should deny the CSR with an unexpected subject", func() {
+			CSR, err = builder.CertificateSigningRequest(defaultByoHostName, "test-cn", "test-org", 2048).
+				WithRequester(bootstrapUser, infrastructurev1beta1.BootstrapTokenExtraGroups).
+				WithExpirationSeconds(86400).
+				Build()
+			Expect(err).NotTo(HaveOccurred())
+
+			expectDenied(reconcileAndGetCSR(), `subject common name "test-cn"`)
+		})
+
+		It("should deny the CSR with a disallowed key usage", func() {
+			CSR.Spec.Usages = append(CSR.Spec.Usages, certv1.UsageServerAuth)
+
+			expectDenied(reconcileAndGetCSR(), "key usage server auth is not allowed")
+		})
+
+		It("should deny the CSR exceeding the maximum expiration", func() {
+			CSR.Spec.ExpirationSeconds = ptr.To(int32(86400 * 366))
+
+			expectDenied(reconcileAndGetCSR(), "exceeds the 31536000 seconds allowed")
+		})
+
+		It("should deny the CSR requesting no expiration", func() {
+			CSR.Spec.ExpirationSeconds = nil
+
+			expectDenied(reconcileAndGetCSR(), "CSRs without expirationSeconds are deliberately denied, request at most 31536000 seconds")
+		})
+
+		It("should deny the CSR of a bootstrap token generated for no BootstrapKubeconfig", func() {
+			CSR.Spec.Username = "system:bootstrap:abcdef"
+
+			expectDenied(reconcileAndGetCSR(), "bootstrap token of system:bootstrap:abcdef is not generated for any BootstrapKubeconfig")
+		})
+
+		Context("When ByoAdmissionPolicies exist", func() {
+			var policy *infrastructurev1beta1.ByoAdmissionPolicy
+
+			BeforeEach(func() {
+				policy = &infrastructurev1beta1.ByoAdmissionPolicy{
+					ObjectMeta: v1.ObjectMeta{Name: "workers", Namespace: defaultNamespace},
+					Spec: infrastructurev1beta1.ByoAdmissionPolicySpec{
+						HostnamePatterns:     []string{"my-*"},
+						MaxExpirationSeconds: ptr.To(int32(3600)),
+					},
+				}
+			})
+
+			JustBeforeEach(func() {
+				Expect(k8sManager.GetClient().Create(ctx, policy)).Should(Succeed())
+				WaitForObjectsToBePopulatedInCache(policy)
+			})
+
+			AfterEach(func() {
+				Expect(k8sManager.GetClient().Delete(ctx, policy)).Should(Succeed())
+				Eventually(func() bool {
+					return k8sManager.GetClient().Get(ctx, client.ObjectKeyFromObject(policy), &infrastructurev1beta1.ByoAdmissionPolicy{}) != nil
+				}).Should(BeTrue())
+			})
+
+			It("should approve the CSR of a matching hostname within the policy expiration", func() {
+				CSR.Spec.ExpirationSeconds = ptr.To(int32(3600))
+
+				updateByohCSR := reconcileAndGetCSR()
+				Expect(updateByohCSR.Status.Conditions[0].Type).To(Equal(certv1.CertificateApproved))
+			})
+
+			It("should deny the CSR exceeding the policy expiration", func() {
+				expectDenied(reconcileAndGetCSR(), "exceeds the 3600 seconds allowed")
+			})
+
+			Context("When the hostname does not match", func() {
+				BeforeEach(func() {
+					policy.Spec.HostnamePatterns = []string{"worker-*"}
+				})
+
+				It("should deny the CSR", func() {
+					expectDenied(reconcileAndGetCSR(), "hostname my-host is not allowed by any ByoAdmissionPolicy")
+				})
+
+				It("should deny the CSR of a host bound to the namespace of the policy", func() {
+					CSR, err = builder.CertificateSigningRequest(defaultByoHostName, hostCN, "byoh:hosts", 2048).
+						WithOrganizations(infrastructurev1beta1.HostNamespaceGroupPrefix+defaultNamespace).
+						WithRequester(hostCN, "byoh:hosts", infrastructurev1beta1.HostNamespaceGroupPrefix+defaultNamespace, "system:authenticated").
+						WithExpirationSeconds(3600).
+						Build()
+					Expect(err).NotTo(HaveOccurred())
+
+					expectDenied(reconcileAndGetCSR(), "hostname my-host is not allowed by any ByoAdmissionPolicy")
+				})
+
+				It("should approve the CSR of a host bound to another namespace", func() {
+					CSR, err = builder.CertificateSigningRequest(defaultByoHostName, hostCN, "byoh:hosts", 2048).
+						WithOrganizations(infrastructurev1beta1.HostNamespaceGroupPrefix+"tenant-a").
+						WithRequester(hostCN, "byoh:hosts", infrastructurev1beta1.HostNamespaceGroupPrefix+"tenant-a", "system:authenticated").
+						WithExpirationSeconds(86400).
+						Build()
+					Expect(err).NotTo(HaveOccurred())
+
+					updateByohCSR := reconcileAndGetCSR()
+					Expect(updateByohCSR.Status.Conditions[0].Type).To(Equal(certv1.CertificateApproved))
+				})
+			})
+		})
+
 		It("should not approve a denied CSR", func() {
 			// Create a fake denied CSR request
 			CSR.Status.Conditions = append(CSR.Status.Conditions, certv1.CertificateSigningRequestCondition{
@@ -89,6 +298,221 @@ var _ = Describe("Controllers/ByoadmissionController", func() {
 			Expect(err).To(BeNil())
 		})
 
+		Context("When the bootstrap token is generated for a BootstrapKubeconfig", func() {
+			var bootstrapKubeconfig *infrastructurev1beta1.BootstrapKubeconfig
+
+			BeforeEach(func() {
+				bootstrapKubeconfig = builder.BootstrapKubeconfig(defaultNamespace, "registrations").
+					WithServer("https://abc.com:1234").
+					WithCAData(b64.StdEncoding.EncodeToString([]byte("test-ca-data"))).
+					WithMaxRegistrations(1).
+					WithTarget(defaultNamespace, map[string]string{"site": "apac"}).
+					Build()
+				Expect(k8sManager.GetClient().Create(ctx, bootstrapKubeconfig)).Should(Succeed())
+				WaitForObjectsToBePopulatedInCache(bootstrapKubeconfig)
+				_, err = bootstrapKubeconfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(bootstrapKubeconfig)})
+				Expect(err).NotTo(HaveOccurred())
+				WaitForObjectToBeUpdatedInCache(bootstrapKubeconfig, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.BootstrapKubeconfig).Status.TokenID != ""
+				})
+				Expect(k8sManager.GetClient().Get(ctx, client.ObjectKeyFromObject(bootstrapKubeconfig), bootstrapKubeconfig)).Should(Succeed())
+				CSR, err = builder.CertificateSigningRequest(defaultByoHostName, hostCN, "byoh:hosts", 2048).
+					WithOrganizations("byoh:namespace:"+defaultNamespace, "byoh:label:cores=2", "byoh:label:site=apac").
+					WithRequester("system:bootstrap:"+bootstrapKubeconfig.Status.TokenID, infrastructurev1beta1.BootstrapTokenExtraGroups).
+					WithExpirationSeconds(86400).
+					Build()
+				Expect(err).NotTo(HaveOccurred())
+				// the fake clientset does not set the UID of the created objects
+				CSR.UID = "registration-csr-uid"
+			})
+
+			AfterEach(func() {
+				Expect(k8sManager.GetClient().Delete(ctx, bootstrapKubeconfig)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(bootstrapKubeconfig, func(object client.Object) bool {
+					return !object.GetDeletionTimestamp().IsZero()
+				})
+				_, err = bootstrapKubeconfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(bootstrapKubeconfig)})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should count the registrations and deny the CSRs once the maximum is reached", func() {
+				updateByohCSR := reconcileAndGetCSR()
+				Expect(updateByohCSR.Status.Conditions[0].Type).To(Equal(certv1.CertificateApproved))
+				WaitForObjectToBeUpdatedInCache(bootstrapKubeconfig, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.BootstrapKubeconfig).Status.Registrations == 1
+				})
+
+				By("counting the approval of the CSR once")
+				_, err = byoAdmissionReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+				Expect(err).ShouldNot(HaveOccurred())
+				Consistently(func() []string {
+					counted := &infrastructurev1beta1.BootstrapKubeconfig{}
+					Expect(k8sManager.GetClient().Get(ctx, client.ObjectKeyFromObject(bootstrapKubeconfig), counted)).Should(Succeed())
+					return counted.Status.RegisteredCSRs
+				}).Should(Equal([]string{"registration-csr-uid"}))
+
+				Expect(clientSetFake.CertificatesV1().CertificateSigningRequests().Delete(ctx, defaultByoHostName, v1.DeleteOptions{})).ShouldNot(HaveOccurred())
+				expectDenied(reconcileAndGetCSR(), "reached the maximum of its BootstrapKubeconfig")
+			})
+
+			It("should count the registration of a CSR approved before it was counted", func() {
+				CSR.Status.Conditions = append(CSR.Status.Conditions, certv1.CertificateSigningRequestCondition{
+					Type:   certv1.CertificateApproved,
+					Status: corev1.ConditionTrue,
+					Reason: "Approved by ByoAdmission Controller",
+				})
+				reconcileAndGetCSR()
+				WaitForObjectToBeUpdatedInCache(bootstrapKubeconfig, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.BootstrapKubeconfig).Status.Registrations == 1
+				})
+			})
+
+			It("should deny the CSR of a host not bound to the target namespace", func() {
+				CSR, err = builder.CertificateSigningRequest(defaultByoHostName, hostCN, "byoh:hosts", 2048).
+					WithOrganizations("byoh:namespace:other-tenant", "byoh:label:site=apac").
+					WithRequester(CSR.Spec.Username, infrastructurev1beta1.BootstrapTokenExtraGroups).
+					Build()
+				Expect(err).NotTo(HaveOccurred())
+
+				expectDenied(reconcileAndGetCSR(), "lacks byoh:namespace:"+defaultNamespace+" required by BootstrapKubeconfig")
+			})
+
+			It("should deny the CSR of a host not bound to the target labels", func() {
+				CSR, err = builder.CertificateSigningRequest(defaultByoHostName, hostCN, "byoh:hosts", 2048).
+					WithOrganizations("byoh:namespace:"+defaultNamespace).
+					WithRequester(CSR.Spec.Username, infrastructurev1beta1.BootstrapTokenExtraGroups).
+					Build()
+				Expect(err).NotTo(HaveOccurred())
+
+				expectDenied(reconcileAndGetCSR(), "lacks byoh:label:site=apac required by BootstrapKubeconfig")
+			})
+		})
+
+		Context("When the host admission is manual", func() {
+			var (
+				manualAdmissionReconciler *controllers.ByoAdmissionReconciler
+				admission                 *infrastructurev1beta1.ByoHostAdmission
+			)
+
+			BeforeEach(func() {
+				manualAdmissionReconciler = &controllers.ByoAdmissionReconciler{
+					ClientSet:           clientSetFake,
+					Client:              k8sManager.GetClient(),
+					ManualHostAdmission: true,
+				}
+				admission = &infrastructurev1beta1.ByoHostAdmission{ObjectMeta: v1.ObjectMeta{Namespace: defaultNamespace, Name: defaultByoHostName}}
+
+				// the admission of the host is held in the namespace it is bound to
+				CSR, err = builder.CertificateSigningRequest(defaultByoHostName, hostCN, "byoh:hosts", 2048).
+					WithOrganizations(infrastructurev1beta1.HostNamespaceGroupPrefix+defaultNamespace).
+					WithRequester(bootstrapUser, infrastructurev1beta1.BootstrapTokenExtraGroups).
+					WithExpirationSeconds(86400).
+					Build()
+				Expect(err).NotTo(HaveOccurred())
+				_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, CSR, v1.CreateOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				_, err = manualAdmissionReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+				Expect(err).ShouldNot(HaveOccurred())
+				WaitForObjectsToBePopulatedInCache(admission)
+			})
+
+			AfterEach(func() {
+				Expect(k8sManager.GetClient().Delete(ctx, admission)).Should(Succeed())
+				Eventually(func() bool {
+					return k8sManager.GetClient().Get(ctx, client.ObjectKeyFromObject(admission), &infrastructurev1beta1.ByoHostAdmission{}) != nil
+				}).Should(BeTrue())
+			})
+
+			decideAndReconcile := func(decision string) *certv1.CertificateSigningRequest {
+				Expect(k8sManager.GetClient().Get(ctx, client.ObjectKeyFromObject(admission), admission)).Should(Succeed())
+				patchHelper, err := patch.NewHelper(admission, k8sManager.GetClient())
+				Expect(err).NotTo(HaveOccurred())
+				admission.Annotations = map[string]string{infrastructurev1beta1.HostAdmissionAnnotation: decision}
+				Expect(patchHelper.Patch(ctx, admission)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(admission, func(object client.Object) bool {
+					return object.GetAnnotations()[infrastructurev1beta1.HostAdmissionAnnotation] == decision
+				})
+
+				_, err = manualAdmissionReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+				Expect(err).ShouldNot(HaveOccurred())
+				updatedCSR, err := clientSetFake.CertificatesV1().CertificateSigningRequests().Get(ctx, defaultByoHostName, v1.GetOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				return updatedCSR
+			}
+
+			It("should hold the CSR pending the approval of the ByoHostAdmission", func() {
+				updatedCSR, err := clientSetFake.CertificatesV1().CertificateSigningRequests().Get(ctx, defaultByoHostName, v1.GetOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(updatedCSR.Status.Conditions).To(BeEmpty())
+
+				Expect(k8sManager.GetClient().Get(ctx, client.ObjectKeyFromObject(admission), admission)).Should(Succeed())
+				Expect(admission.Status.Phase).To(Equal(infrastructurev1beta1.HostAdmissionPendingApproval))
+				Expect(admission.Status.CSRName).To(Equal(defaultByoHostName))
+				Expect(admission.Status.Requester).To(Equal(bootstrapUser))
+			})
+
+			It("should approve the CSR once the ByoHostAdmission is approved", func() {
+				updatedCSR := decideAndReconcile(infrastructurev1beta1.HostAdmissionApproved)
+				Expect(updatedCSR.Status.Conditions[0].Type).To(Equal(certv1.CertificateApproved))
+			})
+
+			It("should reset the approval when a bootstrap token requests a certificate for another key", func() {
+				Expect(decideAndReconcile(infrastructurev1beta1.HostAdmissionApproved).Status.Conditions[0].Type).To(Equal(certv1.CertificateApproved))
+				Expect(k8sManager.GetClient().Get(ctx, client.ObjectKeyFromObject(admission), admission)).Should(Succeed())
+				approvedKey := admission.Status.PublicKeySHA256
+				Expect(approvedKey).NotTo(BeEmpty())
+
+				otherKeyCSR, err := builder.CertificateSigningRequest(defaultByoHostName+"-other-key", hostCN, "byoh:hosts", 2048).
+					WithOrganizations(infrastructurev1beta1.HostNamespaceGroupPrefix+defaultNamespace).
+					WithRequester(bootstrapUser, infrastructurev1beta1.BootstrapTokenExtraGroups).
+					WithExpirationSeconds(86400).
+					Build()
+				Expect(err).NotTo(HaveOccurred())
+				_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, otherKeyCSR, v1.CreateOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				defer func() {
+					Expect(clientSetFake.CertificatesV1().CertificateSigningRequests().Delete(ctx, otherKeyCSR.Name, v1.DeleteOptions{})).Should(Succeed())
+				}()
+
+				_, err = manualAdmissionReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: otherKeyCSR.Name}})
+				Expect(err).ShouldNot(HaveOccurred())
+				updatedCSR, err := clientSetFake.CertificatesV1().CertificateSigningRequests().Get(ctx, otherKeyCSR.Name, v1.GetOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(updatedCSR.Status.Conditions).To(BeEmpty())
+
+				WaitForObjectToBeUpdatedInCache(admission, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoHostAdmission).Status.CSRName == otherKeyCSR.Name
+				})
+				Expect(k8sManager.GetClient().Get(ctx, client.ObjectKeyFromObject(admission), admission)).Should(Succeed())
+				Expect(admission.Annotations).NotTo(HaveKey(infrastructurev1beta1.HostAdmissionAnnotation))
+				Expect(admission.Status.Phase).To(Equal(infrastructurev1beta1.HostAdmissionPendingApproval))
+				Expect(admission.Status.PublicKeySHA256).NotTo(Equal(approvedKey))
+			})
+
+			It("should deny the CSR once the ByoHostAdmission is rejected", func() {
+				expectDenied(decideAndReconcile(infrastructurev1beta1.HostAdmissionRejected), "host my-host is rejected by its ByoHostAdmission")
+			})
+
+			It("should deny the CSR of a host which is not bound to a namespace", func() {
+				unboundCSR, err := builder.CertificateSigningRequest(defaultByoHostName+"-unbound", hostCN, "byoh:hosts", 2048).
+					WithRequester(bootstrapUser, infrastructurev1beta1.BootstrapTokenExtraGroups).
+					WithExpirationSeconds(86400).
+					Build()
+				Expect(err).NotTo(HaveOccurred())
+				_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, unboundCSR, v1.CreateOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				defer func() {
+					Expect(clientSetFake.CertificatesV1().CertificateSigningRequests().Delete(ctx, unboundCSR.Name, v1.DeleteOptions{})).Should(Succeed())
+				}()
+
+				_, err = manualAdmissionReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: unboundCSR.Name}})
+				Expect(err).ShouldNot(HaveOccurred())
+				updatedCSR, err := clientSetFake.CertificatesV1().CertificateSigningRequests().Get(ctx, unboundCSR.Name, v1.GetOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				expectDenied(updatedCSR, "host my-host is not bound to a namespace to hold its ByoHostAdmission")
+			})
+		})
+
 		AfterEach(func() {
 			Expect(clientSetFake.CertificatesV1().CertificateSigningRequests().Delete(ctx, defaultByoHostName, v1.DeleteOptions{})).ShouldNot(HaveOccurred())
 		})
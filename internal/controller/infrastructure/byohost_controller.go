@@ -6,9 +6,17 @@ package infrastructure
 import (
 	"context"
 
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
@@ -18,34 +26,105 @@ import (
 type ByoHostReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// ManualHostAdmission holds the ByoHosts until their ByoHostAdmission is approved
+	ManualHostAdmission bool
 }
 
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohosts,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohosts/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohosts/finalizers,verbs=update
 // +kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests,verbs=create;get;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohostadmissions,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohostadmissions/status,verbs=get;update;patch
 
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the ByoHost object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
-//
-// For more details, check Reconcile and its Result here:
-// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.21.0/pkg/reconcile
+// Reconcile reports the facts of the ByoHost in its ByoHostAdmission in manual host admission mode,
+// creating it if missing, and sets the HostAdmitted condition of the ByoHost from the decision
+// of the administrator.
 func (r *ByoHostReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = logf.FromContext(ctx)
+	logger := logf.FromContext(ctx)
+	if !r.ManualHostAdmission {
+		return ctrl.Result{}, nil
+	}
 
-	// TODO(user): your logic here
+	byoHost := &infrastructurev1beta1.ByoHost{}
+	if err := r.Client.Get(ctx, req.NamespacedName, byoHost); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !byoHost.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
 
-	return ctrl.Result{}, nil
+	// the hosts of the same name in other namespaces have their own ByoHostAdmission
+	admission := &infrastructurev1beta1.ByoHostAdmission{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: byoHost.Namespace, Name: byoHost.Name}, admission)
+	if apierrors.IsNotFound(err) {
+		// the host registered with a certificate approved before the manual host admission mode
+		logger.Info("Creating ByoHostAdmission of the ByoHost", "ByoHost", byoHost.Name)
+		admission = &infrastructurev1beta1.ByoHostAdmission{ObjectMeta: metav1.ObjectMeta{Namespace: byoHost.Namespace, Name: byoHost.Name}}
+		err = r.Client.Create(ctx, admission)
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	admissionHelper, err := patch.NewHelper(admission, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	admission.Status.Phase = admission.Decision()
+	admission.Status.ByoHostRef = &corev1.ObjectReference{
+		APIVersion: infrastructurev1beta1.GroupVersion.String(),
+		Kind:       "ByoHost",
+		Namespace:  byoHost.Namespace,
+		Name:       byoHost.Name,
+		UID:        byoHost.UID,
+	}
+	admission.Status.HostDetails = byoHost.Status.HostDetails
+	admission.Status.Network = byoHost.Status.Network
+	if err = admissionHelper.Patch(ctx, admission); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	byoHostHelper, err := patch.NewHelper(byoHost, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	switch admission.Status.Phase {
+	case infrastructurev1beta1.HostAdmissionPhaseApproved:
+		conditions.MarkTrue(byoHost, infrastructurev1beta1.HostAdmittedCondition)
+	case infrastructurev1beta1.HostAdmissionPhaseRejected:
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.HostAdmittedCondition, infrastructurev1beta1.HostAdmissionRejectedReason,
+			clusterv1.ConditionSeverityWarning, "ByoHostAdmission %s is rejected", admission.Name)
+	default:
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.HostAdmittedCondition, infrastructurev1beta1.HostAdmissionPendingReason,
+			clusterv1.ConditionSeverityInfo, "ByoHostAdmission %s is pending approval", admission.Name)
+	}
+	// the agent owns the other conditions of the ByoHost
+	return ctrl.Result{}, byoHostHelper.Patch(ctx, byoHost, patch.WithOwnedConditions{
+		Conditions: []clusterv1.ConditionType{infrastructurev1beta1.HostAdmittedCondition},
+	})
+}
+
+// ByoHostAdmissionToByoHost returns the reconcile request of the ByoHost registered by the host
+func ByoHostAdmissionToByoHost(_ context.Context, o client.Object) []ctrl.Request {
+	admission, ok := o.(*infrastructurev1beta1.ByoHostAdmission)
+	if !ok || admission.Status.ByoHostRef == nil {
+		return nil
+	}
+	return []ctrl.Request{{NamespacedName: types.NamespacedName{
+		Namespace: admission.Status.ByoHostRef.Namespace,
+		Name:      admission.Status.ByoHostRef.Name,
+	}}}
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ByoHostReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&infrastructurev1beta1.ByoHost{}).
-		Named("infrastructure-byohost").
-		Complete(r)
+		Named("infrastructure-byohost")
+	if r.ManualHostAdmission {
+		b = b.Watches(&infrastructurev1beta1.ByoHostAdmission{},
+			handler.EnqueueRequestsFromMapFunc(ByoHostAdmissionToByoHost))
+	}
+	return b.Complete(r)
 }
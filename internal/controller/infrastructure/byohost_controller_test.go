@@ -6,14 +6,18 @@ package infrastructure_test
 import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
 	. "github.com/cohesity/cluster-api-provider-bringyourownhost/internal/controller/infrastructure"
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/test/builder"
 )
 
 var _ = Describe("ByoHost Controller", func() {
@@ -65,4 +69,110 @@ var _ = Describe("ByoHost Controller", func() {
 			// Example: If you expect a certain status condition after reconciliation, verify it here.
 		})
 	})
+
+	Context("When the host admission is manual", func() {
+		var (
+			controllerReconciler *ByoHostReconciler
+			byoHost              *infrastructurev1beta1.ByoHost
+			admission            *infrastructurev1beta1.ByoHostAdmission
+		)
+
+		reconcileByoHost := func(ctx SpecContext) {
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(byoHost)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sManager.GetClient().Get(ctx, client.ObjectKeyFromObject(byoHost), byoHost)).Should(Succeed())
+		}
+
+		BeforeEach(func(ctx SpecContext) {
+			controllerReconciler = &ByoHostReconciler{
+				Client:              k8sManager.GetClient(),
+				Scheme:              k8sManager.GetScheme(),
+				ManualHostAdmission: true,
+			}
+			byoHost = builder.ByoHost(defaultNamespace, "admitted-host").Build()
+			Expect(k8sManager.GetClient().Create(ctx, byoHost)).Should(Succeed())
+			byoHost.Status.HostDetails = infrastructurev1beta1.HostInfo{OSName: "linux", OSImage: "Ubuntu 22.04", Architecture: "amd64"}
+			Expect(k8sManager.GetClient().Status().Update(ctx, byoHost)).Should(Succeed())
+			WaitForObjectToBeUpdatedInCache(byoHost, func(object client.Object) bool {
+				return object.(*infrastructurev1beta1.ByoHost).Status.HostDetails.OSName == "linux"
+			})
+			admission = &infrastructurev1beta1.ByoHostAdmission{ObjectMeta: metav1.ObjectMeta{Namespace: byoHost.Namespace, Name: byoHost.Name}}
+
+			reconcileByoHost(ctx)
+			WaitForObjectsToBePopulatedInCache(admission)
+		})
+
+		AfterEach(func(ctx SpecContext) {
+			Expect(k8sManager.GetClient().Delete(ctx, byoHost)).Should(Succeed())
+			Expect(k8sManager.GetClient().Delete(ctx, admission)).Should(Succeed())
+			Eventually(func() bool {
+				return k8sManager.GetClient().Get(ctx, client.ObjectKeyFromObject(admission), &infrastructurev1beta1.ByoHostAdmission{}) != nil
+			}).Should(BeTrue())
+		})
+
+		It("should report the facts of the ByoHost in its ByoHostAdmission pending approval", func(ctx SpecContext) {
+			Expect(k8sManager.GetClient().Get(ctx, client.ObjectKeyFromObject(admission), admission)).Should(Succeed())
+			Expect(admission.Status.Phase).To(Equal(infrastructurev1beta1.HostAdmissionPendingApproval))
+			Expect(admission.Status.ByoHostRef.Name).To(Equal(byoHost.Name))
+			Expect(admission.Status.HostDetails.OSImage).To(Equal("Ubuntu 22.04"))
+
+			condition := conditions.Get(byoHost, infrastructurev1beta1.HostAdmittedCondition)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(infrastructurev1beta1.HostAdmissionPendingReason))
+		})
+
+		It("should mark the ByoHost admitted once the ByoHostAdmission is approved", func(ctx SpecContext) {
+			Expect(k8sManager.GetClient().Get(ctx, client.ObjectKeyFromObject(admission), admission)).Should(Succeed())
+			admission.Annotations = map[string]string{infrastructurev1beta1.HostAdmissionAnnotation: infrastructurev1beta1.HostAdmissionApproved}
+			Expect(k8sManager.GetClient().Update(ctx, admission)).Should(Succeed())
+			WaitForObjectToBeUpdatedInCache(admission, func(object client.Object) bool {
+				return object.GetAnnotations()[infrastructurev1beta1.HostAdmissionAnnotation] == infrastructurev1beta1.HostAdmissionApproved
+			})
+
+			reconcileByoHost(ctx)
+			Expect(conditions.IsTrue(byoHost, infrastructurev1beta1.HostAdmittedCondition)).To(BeTrue())
+		})
+
+		It("should mark the ByoHost not admitted once the ByoHostAdmission is rejected", func(ctx SpecContext) {
+			Expect(k8sManager.GetClient().Get(ctx, client.ObjectKeyFromObject(admission), admission)).Should(Succeed())
+			admission.Annotations = map[string]string{infrastructurev1beta1.HostAdmissionAnnotation: infrastructurev1beta1.HostAdmissionRejected}
+			Expect(k8sManager.GetClient().Update(ctx, admission)).Should(Succeed())
+			WaitForObjectToBeUpdatedInCache(admission, func(object client.Object) bool {
+				return object.GetAnnotations()[infrastructurev1beta1.HostAdmissionAnnotation] == infrastructurev1beta1.HostAdmissionRejected
+			})
+
+			reconcileByoHost(ctx)
+			Expect(conditions.GetReason(byoHost, infrastructurev1beta1.HostAdmittedCondition)).To(Equal(infrastructurev1beta1.HostAdmissionRejectedReason))
+		})
+
+		It("should not admit a ByoHost of the same name in another namespace", func(ctx SpecContext) {
+			Expect(k8sManager.GetClient().Get(ctx, client.ObjectKeyFromObject(admission), admission)).Should(Succeed())
+			admission.Annotations = map[string]string{infrastructurev1beta1.HostAdmissionAnnotation: infrastructurev1beta1.HostAdmissionApproved}
+			Expect(k8sManager.GetClient().Update(ctx, admission)).Should(Succeed())
+			WaitForObjectToBeUpdatedInCache(admission, func(object client.Object) bool {
+				return object.GetAnnotations()[infrastructurev1beta1.HostAdmissionAnnotation] == infrastructurev1beta1.HostAdmissionApproved
+			})
+
+			otherNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "byoh-other-hosts"}}
+			Expect(client.IgnoreAlreadyExists(k8sManager.GetClient().Create(ctx, otherNamespace))).Should(Succeed())
+			otherByoHost := builder.ByoHost(otherNamespace.Name, byoHost.Name).Build()
+			Expect(k8sManager.GetClient().Create(ctx, otherByoHost)).Should(Succeed())
+			otherAdmission := &infrastructurev1beta1.ByoHostAdmission{ObjectMeta: metav1.ObjectMeta{Namespace: otherNamespace.Name, Name: byoHost.Name}}
+			DeferCleanup(func(ctx SpecContext) {
+				Expect(k8sManager.GetClient().Delete(ctx, otherByoHost)).Should(Succeed())
+				Expect(k8sManager.GetClient().Delete(ctx, otherAdmission)).Should(Succeed())
+			})
+			WaitForObjectsToBePopulatedInCache(otherByoHost)
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(otherByoHost)})
+			Expect(err).NotTo(HaveOccurred())
+			WaitForObjectsToBePopulatedInCache(otherAdmission)
+			Expect(k8sManager.GetClient().Get(ctx, client.ObjectKeyFromObject(otherByoHost), otherByoHost)).Should(Succeed())
+			Expect(conditions.GetReason(otherByoHost, infrastructurev1beta1.HostAdmittedCondition)).To(Equal(infrastructurev1beta1.HostAdmissionPendingReason))
+
+			Expect(k8sManager.GetClient().Get(ctx, client.ObjectKeyFromObject(admission), admission)).Should(Succeed())
+			Expect(admission.Status.ByoHostRef.Namespace).To(Equal(byoHost.Namespace))
+		})
+	})
 })
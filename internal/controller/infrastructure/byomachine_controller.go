@@ -9,12 +9,15 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -34,6 +37,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -57,6 +61,10 @@ type ByoMachineReconciler struct {
 	Scheme   *runtime.Scheme
 	Tracker  *remote.ClusterCacheTracker
 	Recorder record.EventRecorder
+	// ManualHostAdmission only attaches the ByoHosts admitted by the administrator
+	ManualHostAdmission bool
+	// NamespacedHostAttachment only attaches the ByoHosts of the ByoMachine namespace
+	NamespacedHostAttachment bool
 }
 
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byomachines,verbs=get;list;watch;create;update;patch;delete
@@ -68,7 +76,8 @@ type ByoMachineReconciler struct {
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;machines,verbs=get;list;watch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;update;patch
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -221,6 +230,9 @@ func (r *ByoMachineReconciler) reconcileDelete(ctx context.Context, machineScope
 		if err := r.markHostForCleanup(ctx, machineScope); err != nil {
 			return ctrl.Result{}, err
 		}
+		if err := r.revokeHostSecrets(ctx, machineScope); err != nil {
+			return ctrl.Result{}, err
+		}
 		r.Recorder.Eventf(machineScope.ByoHost, corev1.EventTypeNormal, "ByoHostReleaseSucceeded", "ByoHost Released by %s", machineScope.ByoMachine.Name)
 		r.Recorder.Eventf(machineScope.ByoMachine, corev1.EventTypeNormal, "ByoHostReleaseSucceeded", "Released ByoHost %s", machineScope.ByoHost.Name)
 	}
@@ -243,7 +255,7 @@ func (r *ByoMachineReconciler) reconcileNormal(ctx context.Context, machineScope
 		}
 	}
 
-	if machineScope.ByoMachine.Spec.InstallerRef != nil {
+	if machineScope.HasInstaller() {
 		if err := r.createInstallerConfig(ctx, machineScope); err != nil {
 			logger.Error(err, "create installer config failed")
 			return ctrl.Result{}, err
@@ -278,7 +290,7 @@ func (r *ByoMachineReconciler) reconcileNormal(ctx context.Context, machineScope
 		machineScope.ByoMachine.Status.HostInfo = machineScope.ByoHost.Status.HostDetails
 	}
 
-	if machineScope.ByoMachine.Spec.InstallerRef != nil && machineScope.ByoHost.Spec.InstallationSecret == nil {
+	if machineScope.HasInstaller() {
 		res, err := r.setInstallationSecretForByoHost(ctx, machineScope)
 		if err != nil {
 			logger.Error(err, "failed to set installation secret on byohost")
@@ -334,6 +346,15 @@ func (r *ByoMachineReconciler) SetupWithManager(c context.Context, mgr ctrl.Mana
 		Watches(&clusterv1.Machine{},
 			handler.EnqueueRequestsFromMapFunc(util.MachineToInfrastructureMapFunc(controlledTypeGVK)),
 		).
+		Watches(&infrastructurev1beta1.K8sInstallerConfigTemplate{},
+			handler.EnqueueRequestsFromMapFunc(r.K8sInstallerConfigTemplateToByoMachines),
+		).
+		Watches(&infrastructurev1beta1.ByoCluster{},
+			handler.EnqueueRequestsFromMapFunc(r.ByoClusterToByoMachines),
+		).
+		Watches(&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.InstallationSecretToByoMachine),
+		).
 		Watches(&clusterv1.Cluster{},
 			handler.EnqueueRequestsFromMapFunc(ClusterToByoMachines),
 			builder.WithPredicates(predicates.ClusterUnpausedAndInfrastructureReady(mgr.GetScheme(), ctrl.LoggerFrom(c))),
@@ -441,14 +462,25 @@ func (r *ByoMachineReconciler) setPausedConditionForByoHost(ctx context.Context,
 	return helper.Patch(ctx, machineScope.ByoHost)
 }
 
+// setInstallationSecretForByoHost sets the installation secret of the installer config on the ByoHost and
+// grants it to the host agent. It runs on every reconcile so the grant follows the registry credentials
+// Secret of a regenerated installation secret.
 func (r *ByoMachineReconciler) setInstallationSecretForByoHost(ctx context.Context, machineScope *byoMachineScope) (ctrl.Result, error) {
 	logger := log.FromContext(ctx).WithValues("cluster", machineScope.Cluster.Name)
 	installerConfig, ready, err := r.getInstallerConfigAndStatus(ctx, machineScope)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
+	if !ready && machineScope.ByoHost.Spec.InstallationSecret != nil {
+		// the installation secret is being regenerated, the host keeps the grant of the current one
+		return ctrl.Result{}, nil
+	}
 	if !ready {
 		logger.Info("Installer config is not ready, requeuing")
+		// mirror why the installer is not ready, e.g. the OS of the host is not supported
+		if c := conditions.Get(conditions.UnstructuredGetter(installerConfig), clusterv1.ReadyCondition); c != nil && c.Status == corev1.ConditionFalse {
+			conditions.MarkFalse(machineScope.ByoMachine, infrastructurev1beta1.BYOHostReady, c.Reason, c.Severity, "%s", c.Message)
+		}
 		return ctrl.Result{RequeueAfter: RequeueInstallerConfigTime}, nil
 	}
 
@@ -467,12 +499,40 @@ func (r *ByoMachineReconciler) setInstallationSecretForByoHost(ctx context.Conte
 	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(secret.(map[string]any), secretRef); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to convert unstructured field, %s", err.Error())
 	}
+	secretNames, err := r.installationSecretNames(ctx, secretRef)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	host := machineScope.ByoHost
+	if secretRef.Namespace == host.Namespace {
+		err = r.grantHostSecrets(ctx, host, host, host.Namespace, append([]string{host.BootstrapSecretName()}, secretNames...)...)
+	} else {
+		// the ByoHost cannot own the grant of the installation secret in the namespace of the ByoMachine
+		err = r.grantHostSecrets(ctx, host, machineScope.ByoMachine, secretRef.Namespace, secretNames...)
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
 	machineScope.ByoHost.Spec.InstallationSecret = secretRef
 	return ctrl.Result{}, helper.Patch(ctx, machineScope.ByoHost)
 }
 
+// installationSecretNames returns the names of the installation secret and of the registry credentials
+// Secret it references, both read by the host agent
+func (r *ByoMachineReconciler) installationSecretNames(ctx context.Context, secretRef *corev1.ObjectReference) ([]string, error) {
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: secretRef.Namespace, Name: secretRef.Name}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get installation secret %s: %w", secretRef.Name, err)
+	}
+	secretNames := []string{secretRef.Name}
+	if credentialsSecretName := secret.Data[infrastructurev1beta1.RegistryCredentialsSecretNameKey]; len(credentialsSecretName) > 0 {
+		secretNames = append(secretNames, string(credentialsSecretName))
+	}
+	return secretNames, nil
+}
+
 func (r *ByoMachineReconciler) getInstallerConfigAndStatus(ctx context.Context, machineScope *byoMachineScope) (*unstructured.Unstructured, bool, error) {
-	installerConfig, err := r.getInstallerConfig(ctx, machineScope.ByoMachine)
+	installerConfig, err := r.getInstallerConfig(ctx, machineScope)
 	if err != nil {
 		return nil, false, err
 	}
@@ -506,11 +566,20 @@ func (r *ByoMachineReconciler) attachByoHost(ctx context.Context, machineScope *
 	byohostLabels, _ := labels.NewRequirement(clusterv1.ClusterNameLabel, selection.DoesNotExist, nil)
 	selector = selector.Add(*byohostLabels)
 
-	err = r.Client.List(ctx, hostsList, &client.ListOptions{LabelSelector: selector})
+	listOptions := &client.ListOptions{LabelSelector: selector}
+	if r.NamespacedHostAttachment {
+		listOptions.Namespace = machineScope.ByoMachine.Namespace
+	}
+	err = r.Client.List(ctx, hostsList, listOptions)
 	if err != nil {
 		logger.Error(err, "failed to list byohosts")
 		return ctrl.Result{RequeueAfter: RequeueForbyohost}, err
 	}
+	if r.ManualHostAdmission {
+		hostsList.Items = slices.DeleteFunc(hostsList.Items, func(host infrastructurev1beta1.ByoHost) bool {
+			return !conditions.IsTrue(&host, infrastructurev1beta1.HostAdmittedCondition)
+		})
+	}
 	if len(hostsList.Items) == 0 {
 		logger.Info("No hosts found, waiting..")
 		r.Recorder.Eventf(machineScope.ByoMachine, corev1.EventTypeWarning, "ByoHostSelectionFailed", "No available ByoHost")
@@ -541,10 +610,10 @@ func (r *ByoMachineReconciler) attachByoHost(ctx context.Context, machineScope *
 	hostLabels[infrastructurev1beta1.AttachedByoMachineLabel] = machineScope.ByoMachine.Namespace + "." + machineScope.ByoMachine.Name
 	host.Labels = hostLabels
 
-	host.Spec.BootstrapSecret = &corev1.ObjectReference{
-		Kind:      "Secret",
-		Namespace: machineScope.ByoMachine.Namespace,
-		Name:      *machineScope.Machine.Spec.Bootstrap.DataSecretName,
+	host.Spec.BootstrapSecret, err = r.setBootstrapSecretForByoHost(ctx, machineScope, &host)
+	if err != nil {
+		logger.Error(err, "failed to set bootstrap secret on byohost")
+		return ctrl.Result{}, err
 	}
 	if host.Annotations == nil {
 		host.Annotations = make(map[string]string)
@@ -563,6 +632,156 @@ func (r *ByoMachineReconciler) attachByoHost(ctx context.Context, machineScope *
 	return ctrl.Result{}, nil
 }
 
+// setBootstrapSecretForByoHost projects the bootstrap data of the machine into the bootstrap Secret of the
+// ByoHost, so that the host agent reads no other Secret than the ones of its ByoHost
+func (r *ByoMachineReconciler) setBootstrapSecretForByoHost(ctx context.Context, machineScope *byoMachineScope, host *infrastructurev1beta1.ByoHost) (*corev1.ObjectReference, error) {
+	dataSecret := &corev1.Secret{}
+	dataSecretName := client.ObjectKey{
+		Namespace: machineScope.ByoMachine.Namespace,
+		Name:      *machineScope.Machine.Spec.Bootstrap.DataSecretName,
+	}
+	if err := r.Client.Get(ctx, dataSecretName, dataSecret); err != nil {
+		return nil, fmt.Errorf("failed to get bootstrap data secret %s: %w", dataSecretName.Name, err)
+	}
+
+	hostSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:      host.BootstrapSecretName(),
+		Namespace: host.Namespace,
+	}}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, hostSecret, func() error {
+		hostSecret.Type = corev1.SecretTypeOpaque
+		hostSecret.Data = map[string][]byte{
+			infrastructurev1beta1.BootstrapDataSecretKey: dataSecret.Data[infrastructurev1beta1.BootstrapDataSecretKey],
+		}
+		return r.setController(host, hostSecret)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create or update bootstrap secret %s: %w", hostSecret.Name, err)
+	}
+	// the installation secret is granted once the installer config is ready
+	if err = r.grantHostSecrets(ctx, host, host, host.Namespace, hostSecret.Name); err != nil {
+		return nil, err
+	}
+	return &corev1.ObjectReference{
+		Kind:      "Secret",
+		Namespace: hostSecret.Namespace,
+		Name:      hostSecret.Name,
+	}, nil
+}
+
+// grantHostSecrets grants the agent of the ByoHost to get the given Secrets of the namespace and no other,
+// with a Role and a RoleBinding controlled by the owner
+func (r *ByoMachineReconciler) grantHostSecrets(ctx context.Context, host *infrastructurev1beta1.ByoHost, owner client.Object, namespace string, secretNames ...string) error {
+	role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{
+		Name:      hostAgentRoleName(host),
+		Namespace: namespace,
+	}}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, role, func() error {
+		role.Rules = []rbacv1.PolicyRule{{
+			APIGroups:     []string{""},
+			Resources:     []string{"secrets"},
+			Verbs:         []string{"get"},
+			ResourceNames: secretNames,
+		}}
+		return r.setController(owner, role)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create or update role %s: %w", role.Name, err)
+	}
+
+	roleBinding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{
+		Name:      role.Name,
+		Namespace: role.Namespace,
+	}}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, roleBinding, func() error {
+		roleBinding.RoleRef = rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     role.Name,
+		}
+		roleBinding.Subjects = []rbacv1.Subject{{
+			APIGroup: rbacv1.GroupName,
+			Kind:     rbacv1.UserKind,
+			Name:     byohHostUsernamePrefix + host.Name,
+		}}
+		return r.setController(owner, roleBinding)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create or update role binding %s: %w", roleBinding.Name, err)
+	}
+	return nil
+}
+
+// revokeHostSecrets deletes the bootstrap Secret of the released ByoHost and the grants of its agent,
+// so that the host reads no Secret of the ByoMachine once released
+func (r *ByoMachineReconciler) revokeHostSecrets(ctx context.Context, machineScope *byoMachineScope) error {
+	host := machineScope.ByoHost
+	grants := []client.Object{
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: host.BootstrapSecretName(), Namespace: host.Namespace}},
+		&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: hostAgentRoleName(host), Namespace: host.Namespace}},
+		&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: hostAgentRoleName(host), Namespace: host.Namespace}},
+	}
+	for _, grant := range grants {
+		if err := r.deleteControlledBy(ctx, grant, host); err != nil {
+			return err
+		}
+	}
+	if namespace := machineScope.ByoMachine.Namespace; namespace != host.Namespace {
+		grants = []client.Object{
+			&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: hostAgentRoleName(host), Namespace: namespace}},
+			&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: hostAgentRoleName(host), Namespace: namespace}},
+		}
+		for _, grant := range grants {
+			if err := r.deleteControlledBy(ctx, grant, machineScope.ByoMachine); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deleteControlledBy deletes the object if it exists and is controlled by the owner
+func (r *ByoMachineReconciler) deleteControlledBy(ctx context.Context, obj, owner client.Object) error {
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(obj, owner) {
+		return nil
+	}
+	if err := r.Client.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete %s: %w", obj.GetName(), err)
+	}
+	return nil
+}
+
+// setController sets the owner as the controller of the object. An object left behind by a former owner
+// of the same kind and name, e.g. a ByoHost registered again, is adopted, an object controlled by another
+// owner is refused.
+func (r *ByoMachineReconciler) setController(owner, obj client.Object) error {
+	gvk, err := apiutil.GVKForObject(owner, r.Client.Scheme())
+	if err != nil {
+		return err
+	}
+	if ref := metav1.GetControllerOf(obj); ref != nil {
+		refGV, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			return err
+		}
+		if refGV.Group != gvk.Group || ref.Kind != gvk.Kind || ref.Name != owner.GetName() {
+			return fmt.Errorf("%s/%s is controlled by %s %s", obj.GetNamespace(), obj.GetName(), ref.Kind, ref.Name)
+		}
+		obj.SetOwnerReferences(slices.DeleteFunc(obj.GetOwnerReferences(), func(o metav1.OwnerReference) bool {
+			return o.UID == ref.UID
+		}))
+	}
+	return controllerutil.SetControllerReference(owner, obj, r.Client.Scheme())
+}
+
+// hostAgentRoleName returns the name of the Role and RoleBinding granting the agent of the ByoHost its Secrets
+func hostAgentRoleName(host *infrastructurev1beta1.ByoHost) string {
+	return host.Name + "-agent"
+}
+
 // ByoHostToByoMachineMapFunc returns a handler.ToRequestsFunc that watches for
 // Machine events and returns reconciliation requests for an infrastructure provider object
 func ByoHostToByoMachineMapFunc(gvk schema.GroupVersionKind) handler.MapFunc {
@@ -609,14 +828,18 @@ func (r *ByoMachineReconciler) markHostForCleanup(ctx context.Context, machineSc
 	return nil
 }
 
-func (r *ByoMachineReconciler) getInstallerConfig(ctx context.Context, byoMachine *infrastructurev1beta1.ByoMachine) (*unstructured.Unstructured, error) {
+func (r *ByoMachineReconciler) getInstallerConfig(ctx context.Context, machineScope *byoMachineScope) (*unstructured.Unstructured, error) {
 	installerConfig := &unstructured.Unstructured{}
-	gvk := byoMachine.Spec.InstallerRef.GroupVersionKind()
-	gvk.Kind = strings.Replace(gvk.Kind, "Template", "", -1)
+	// installer configs created from the default installer spec of the ByoCluster are K8sInstallerConfigs
+	gvk := infrastructurev1beta1.GroupVersion.WithKind("K8sInstallerConfig")
+	if installerRef := machineScope.InstallerRef(); installerRef != nil {
+		gvk = installerRef.GroupVersionKind()
+		gvk.Kind = strings.Replace(gvk.Kind, "Template", "", -1)
+	}
 	installerConfig.SetGroupVersionKind(gvk)
 	installerConfigName := client.ObjectKey{
-		Namespace: byoMachine.Namespace,
-		Name:      byoMachine.Name,
+		Namespace: machineScope.ByoMachine.Namespace,
+		Name:      machineScope.ByoMachine.Name,
 	}
 	if err := r.Client.Get(ctx, installerConfigName, installerConfig); err != nil {
 		return nil, err
@@ -624,47 +847,189 @@ func (r *ByoMachineReconciler) getInstallerConfig(ctx context.Context, byoMachin
 	return installerConfig, nil
 }
 
+// createInstallerConfig creates the installer config of the ByoMachine from the installer template, or from
+// the default installer spec of the ByoCluster if no installer template is referenced.
+// The spec of an existing installer config is kept in sync with the template, so that template changes
+// reach the installer controller.
 func (r *ByoMachineReconciler) createInstallerConfig(ctx context.Context, machineScope *byoMachineScope) error {
 	logger := log.FromContext(ctx).WithValues("cluster", machineScope.Cluster.Name)
-	var (
-		installerConfig *unstructured.Unstructured
-		err             error
-	)
-	_, err = r.getInstallerConfig(ctx, machineScope.ByoMachine)
-	if err != nil && apierrors.IsNotFound(err) {
-		template := &unstructured.Unstructured{}
-		template.SetGroupVersionKind(machineScope.ByoMachine.Spec.InstallerRef.GroupVersionKind())
-		installerTemplateName := client.ObjectKey{
-			Namespace: machineScope.ByoMachine.Spec.InstallerRef.Namespace,
-			Name:      machineScope.ByoMachine.Spec.InstallerRef.Name,
-		}
-		if err = r.Client.Get(ctx, installerTemplateName, template); err != nil {
-			logger.Error(err, "failed to get installer config template")
-			return err
+	installerConfig, err := r.getInstallerConfig(ctx, machineScope)
+	if err != nil && !apierrors.IsNotFound(err) {
+		logger.Error(err, "failed to get installer config")
+		return err
+	}
+	installerRef := machineScope.InstallerRef()
+	if installerRef == nil {
+		return r.createDefaultInstallerConfig(ctx, machineScope, installerConfig)
+	}
+	template := &unstructured.Unstructured{}
+	template.SetGroupVersionKind(installerRef.GroupVersionKind())
+	installerTemplateName := client.ObjectKey{
+		Namespace: installerRef.Namespace,
+		Name:      installerRef.Name,
+	}
+	if installerTemplateName.Namespace == "" {
+		// the default installer template of the ByoCluster is in the cluster namespace
+		installerTemplateName.Namespace = machineScope.ByoMachine.Namespace
+	}
+	if err = r.Client.Get(ctx, installerTemplateName, template); err != nil {
+		if installerConfig != nil && apierrors.IsNotFound(err) {
+			// the installer config outlives its template
+			return nil
 		}
-		installerAnnotations := map[string]string{
-			infrastructurev1beta1.K8sVersionAnnotation: strings.Split(*machineScope.Machine.Spec.Version, "+")[0],
+		logger.Error(err, "failed to get installer config template")
+		return err
+	}
+	if installerConfig != nil {
+		templateSpec, found, err := unstructured.NestedMap(template.Object, "spec", "template", "spec")
+		if err != nil || !found {
+			return err
 		}
-		installerConfig, err = external.GenerateTemplate(&external.GenerateTemplateInput{
-			Template:    template,
-			TemplateRef: machineScope.ByoMachine.Spec.InstallerRef,
-			Namespace:   machineScope.ByoMachine.Namespace,
-			Annotations: installerAnnotations,
-			ClusterName: machineScope.Cluster.Name,
-			OwnerRef:    metav1.NewControllerRef(machineScope.ByoMachine, machineScope.ByoMachine.GroupVersionKind()),
-		})
+		return r.syncInstallerConfig(ctx, installerConfig, templateSpec)
+	}
+
+	installerAnnotations := map[string]string{
+		infrastructurev1beta1.K8sVersionAnnotation: strings.Split(*machineScope.Machine.Spec.Version, "+")[0],
+	}
+	installerConfig, err = external.GenerateTemplate(&external.GenerateTemplateInput{
+		Template:    template,
+		TemplateRef: installerRef,
+		Namespace:   machineScope.ByoMachine.Namespace,
+		Annotations: installerAnnotations,
+		ClusterName: machineScope.Cluster.Name,
+		OwnerRef:    metav1.NewControllerRef(machineScope.ByoMachine, machineScope.ByoMachine.GroupVersionKind()),
+	})
+	if err != nil {
+		return err
+	}
+	installerConfig.SetName(machineScope.ByoMachine.Name)
+	if err = r.Client.Create(ctx, installerConfig); err != nil {
+		logger.Error(err, "failed to create installer config")
+		return err
+	}
+	return nil
+}
+
+// createDefaultInstallerConfig creates the K8sInstallerConfig of the ByoMachine from the default installer
+// spec of the ByoCluster, or keeps the spec of the existing installerConfig in sync with it
+func (r *ByoMachineReconciler) createDefaultInstallerConfig(ctx context.Context, machineScope *byoMachineScope, installerConfig *unstructured.Unstructured) error {
+	logger := log.FromContext(ctx).WithValues("cluster", machineScope.Cluster.Name)
+	defaultSpec := machineScope.DefaultInstallerSpec()
+	if installerConfig != nil {
+		spec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(defaultSpec)
 		if err != nil {
 			return err
-		} else {
-			installerConfig.SetName(machineScope.ByoMachine.Name)
-			if err = r.Client.Create(ctx, installerConfig); err != nil {
-				logger.Error(err, "failed to create installer config")
-				return err
-			}
 		}
-	} else if err != nil {
-		logger.Error(err, "failed to get installer config")
+		return r.syncInstallerConfig(ctx, installerConfig, spec)
+	}
+
+	config := &infrastructurev1beta1.K8sInstallerConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      machineScope.ByoMachine.Name,
+			Namespace: machineScope.ByoMachine.Namespace,
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: machineScope.Cluster.Name},
+			Annotations: map[string]string{
+				infrastructurev1beta1.K8sVersionAnnotation: strings.Split(*machineScope.Machine.Spec.Version, "+")[0],
+			},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(machineScope.ByoMachine, machineScope.ByoMachine.GroupVersionKind())},
+		},
+		Spec: *defaultSpec.DeepCopy(),
+	}
+	if err := r.Client.Create(ctx, config); err != nil {
+		logger.Error(err, "failed to create installer config from the ByoCluster defaults")
 		return err
 	}
 	return nil
 }
+
+// syncInstallerConfig updates the spec of the installer config to the spec of its installer template,
+// or of the ByoCluster defaults, if it changed. The installer controller then regenerates the installation
+// secret of hosts which are not installed yet.
+func (r *ByoMachineReconciler) syncInstallerConfig(ctx context.Context, installerConfig *unstructured.Unstructured, templateSpec map[string]any) error {
+	configSpec, _, err := unstructured.NestedMap(installerConfig.Object, "spec")
+	if err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(templateSpec, configSpec) {
+		return nil
+	}
+	helper, err := patch.NewHelper(installerConfig, r.Client)
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedMap(installerConfig.Object, templateSpec, "spec"); err != nil {
+		return err
+	}
+	log.FromContext(ctx).Info("Updating installer config to the spec of its template", "installerConfig", installerConfig.GetName())
+	return helper.Patch(ctx, installerConfig)
+}
+
+// K8sInstallerConfigTemplateToByoMachines is a handler.ToRequestsFunc to be used to enqueue requests for
+// reconciliation of the ByoMachines referring to the K8sInstallerConfigTemplate
+func (r *ByoMachineReconciler) K8sInstallerConfigTemplateToByoMachines(ctx context.Context, o client.Object) []ctrl.Request {
+	t, ok := o.(*infrastructurev1beta1.K8sInstallerConfigTemplate)
+	if !ok {
+		return nil
+	}
+	byoMachineList := &infrastructurev1beta1.ByoMachineList{}
+	if err := r.Client.List(ctx, byoMachineList, client.InNamespace(t.Namespace)); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list ByoMachines, skipping mapping.")
+		return nil
+	}
+	result := []ctrl.Request{}
+	for i := range byoMachineList.Items {
+		ref := byoMachineList.Items[i].Spec.InstallerRef
+		if ref != nil && ref.Kind == "K8sInstallerConfigTemplate" && ref.Name == t.Name {
+			result = append(result, ctrl.Request{NamespacedName: client.ObjectKey{Namespace: byoMachineList.Items[i].Namespace, Name: byoMachineList.Items[i].Name}})
+		}
+	}
+	// the machines of the clusters using the template as their default installer template
+	byoClusterList := &infrastructurev1beta1.ByoClusterList{}
+	if err := r.Client.List(ctx, byoClusterList, client.InNamespace(t.Namespace)); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list ByoClusters, skipping mapping.")
+		return result
+	}
+	for i := range byoClusterList.Items {
+		installer := byoClusterList.Items[i].Spec.Installer
+		if installer != nil && installer.TemplateRef != nil && installer.TemplateRef.Kind == "K8sInstallerConfigTemplate" && installer.TemplateRef.Name == t.Name {
+			result = append(result, r.ByoClusterToByoMachines(ctx, &byoClusterList.Items[i])...)
+		}
+	}
+	return result
+}
+
+// InstallationSecretToByoMachine is a handler.ToRequestsFunc to be used to enqueue requests for
+// reconciliation of the ByoMachine whose installer config generated the installation secret
+func (r *ByoMachineReconciler) InstallationSecretToByoMachine(_ context.Context, o client.Object) []ctrl.Request {
+	owner := metav1.GetControllerOf(o)
+	if owner == nil || owner.Kind != "K8sInstallerConfig" || owner.APIVersion != infrastructurev1beta1.GroupVersion.String() {
+		return nil
+	}
+	// the installer config is named after its ByoMachine
+	return []ctrl.Request{{NamespacedName: client.ObjectKey{Namespace: o.GetNamespace(), Name: owner.Name}}}
+}
+
+// ByoClusterToByoMachines is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
+// of the ByoMachines of the ByoCluster relying on its default installer
+func (r *ByoMachineReconciler) ByoClusterToByoMachines(ctx context.Context, o client.Object) []ctrl.Request {
+	c, ok := o.(*infrastructurev1beta1.ByoCluster)
+	if !ok || c.Spec.Installer == nil {
+		return nil
+	}
+	cluster, err := util.GetOwnerCluster(ctx, r.Client, c.ObjectMeta)
+	if err != nil || cluster == nil {
+		return nil
+	}
+	byoMachineList := &infrastructurev1beta1.ByoMachineList{}
+	if err := r.Client.List(ctx, byoMachineList, client.InNamespace(c.Namespace), client.MatchingLabels{clusterv1.ClusterNameLabel: cluster.Name}); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list ByoMachines, skipping mapping.")
+		return nil
+	}
+	result := []ctrl.Request{}
+	for i := range byoMachineList.Items {
+		if byoMachineList.Items[i].Spec.InstallerRef == nil {
+			result = append(result, ctrl.Request{NamespacedName: client.ObjectKey{Namespace: byoMachineList.Items[i].Namespace, Name: byoMachineList.Items[i].Name}})
+		}
+	}
+	return result
+}
@@ -14,6 +14,9 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -284,6 +287,59 @@ var _ = Describe("ByoMachine Controller", func() {
 				Expect(node.Spec.ProviderID).To(ContainSubstring(controllers.ProviderIDPrefix))
 			})
 
+			It("projects the bootstrap data into a Secret only the host agent is granted", func() {
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).ToNot(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, createdByoHost)).Should(Succeed())
+				Expect(createdByoHost.Spec.BootstrapSecret.Namespace).To(Equal(createdByoHost.Namespace))
+				Expect(createdByoHost.Spec.BootstrapSecret.Name).To(Equal(createdByoHost.BootstrapSecretName()))
+
+				hostSecret := &corev1.Secret{}
+				Expect(k8sClientUncached.Get(ctx, types.NamespacedName{Namespace: createdByoHost.Namespace, Name: createdByoHost.BootstrapSecretName()}, hostSecret)).Should(Succeed())
+				Expect(string(hostSecret.Data[infrastructurev1beta1.BootstrapDataSecretKey])).To(Equal("bootstrap data"))
+				Expect(metav1.IsControlledBy(hostSecret, createdByoHost)).To(BeTrue())
+
+				roleKey := types.NamespacedName{Namespace: createdByoHost.Namespace, Name: createdByoHost.Name + "-agent"}
+				role := &rbacv1.Role{}
+				Expect(k8sClientUncached.Get(ctx, roleKey, role)).Should(Succeed())
+				Expect(role.Rules).To(ConsistOf(rbacv1.PolicyRule{
+					APIGroups:     []string{""},
+					Resources:     []string{"secrets"},
+					Verbs:         []string{"get"},
+					ResourceNames: []string{createdByoHost.BootstrapSecretName()},
+				}))
+				Expect(metav1.IsControlledBy(role, createdByoHost)).To(BeTrue())
+
+				roleBinding := &rbacv1.RoleBinding{}
+				Expect(k8sClientUncached.Get(ctx, roleKey, roleBinding)).Should(Succeed())
+				Expect(roleBinding.RoleRef.Name).To(Equal(role.Name))
+				Expect(roleBinding.Subjects).To(ConsistOf(rbacv1.Subject{
+					APIGroup: rbacv1.GroupName,
+					Kind:     rbacv1.UserKind,
+					Name:     "byoh:host:" + createdByoHost.Name,
+				}))
+				Expect(metav1.IsControlledBy(roleBinding, createdByoHost)).To(BeTrue())
+			})
+
+			It("refuses to take over a Secret controlled by another object", func() {
+				foreignSecret := builder.Secret(defaultNamespace, byoHost.BootstrapSecretName()).WithData("foreign data").Build()
+				Expect(controllerutil.SetControllerReference(byoMachine, foreignSecret, scheme.Scheme)).Should(Succeed())
+				Expect(k8sClientUncached.Create(ctx, foreignSecret)).Should(Succeed())
+				defer func() {
+					Expect(k8sClientUncached.Delete(ctx, foreignSecret)).Should(Succeed())
+				}()
+				WaitForObjectsToBePopulatedInCache(foreignSecret)
+
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("%s/%s is controlled by ByoMachine %s", defaultNamespace, foreignSecret.Name, byoMachine.Name))))
+
+				unchangedSecret := &corev1.Secret{}
+				Expect(k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(foreignSecret), unchangedSecret)).Should(Succeed())
+				Expect(string(unchangedSecret.Data[infrastructurev1beta1.BootstrapDataSecretKey])).To(Equal("foreign data"))
+			})
+
 			Context("When ByoMachine is attached to a host", func() {
 				BeforeEach(func() {
 					ph, err := patch.NewHelper(byoHost, k8sClientUncached)
@@ -404,6 +460,30 @@ var _ = Describe("ByoMachine Controller", func() {
 						Expect(createdByoHost.Annotations[infrastructurev1beta1.HostCleanupAnnotation]).Should(Equal(""))
 					})
 
+					It("should revoke the Secrets granted to the host agent", func() {
+						grants := []client.Object{
+							builder.Secret(byoHost.Namespace, byoHost.BootstrapSecretName()).WithData("bootstrap data").Build(),
+							&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Namespace: byoHost.Namespace, Name: byoHost.Name + "-agent"}},
+							&rbacv1.RoleBinding{
+								ObjectMeta: metav1.ObjectMeta{Namespace: byoHost.Namespace, Name: byoHost.Name + "-agent"},
+								RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: byoHost.Name + "-agent"},
+							},
+						}
+						for _, grant := range grants {
+							Expect(controllerutil.SetControllerReference(byoHost, grant, scheme.Scheme)).Should(Succeed())
+							Expect(k8sClientUncached.Create(ctx, grant)).Should(Succeed())
+						}
+						WaitForObjectsToBePopulatedInCache(grants...)
+
+						_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+						Expect(err).NotTo(HaveOccurred())
+
+						for _, grant := range grants {
+							err = k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(grant), grant)
+							Expect(apierrors.IsNotFound(err)).To(BeTrue(), "%s should be deleted", grant.GetName())
+						}
+					})
+
 					It("should delete the byomachine object", func() {
 						deletedByoMachine := &infrastructurev1beta1.ByoMachine{}
 						// assert ByoMachine Exists before reconcile
@@ -455,7 +535,37 @@ var _ = Describe("ByoMachine Controller", func() {
 						Expect(res.RequeueAfter).To(Equal(controllers.RequeueInstallerConfigTime))
 					})
 
+					It("should mirror the reason of the installer config which is not ready", func() {
+						ph, err := patch.NewHelper(k8sInstallerConfig, k8sClientUncached)
+						Expect(err).ShouldNot(HaveOccurred())
+						conditions.MarkFalse(k8sInstallerConfig, clusterv1.ReadyCondition, infrastructurev1beta1.OSNotSupportedReason, clusterv1.ConditionSeverityError, "no bundle is published for OS %s", "rhel")
+						Expect(ph.Patch(ctx, k8sInstallerConfig)).Should(Succeed())
+						WaitForObjectToBeUpdatedInCache(k8sInstallerConfig, func(object client.Object) bool {
+							return conditions.IsFalse(object.(*infrastructurev1beta1.K8sInstallerConfig), clusterv1.ReadyCondition)
+						})
+
+						res, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+						Expect(err).NotTo(HaveOccurred())
+						Expect(res.RequeueAfter).To(Equal(controllers.RequeueInstallerConfigTime))
+
+						updatedByoMachine := &infrastructurev1beta1.ByoMachine{}
+						Expect(k8sClientUncached.Get(ctx, byoMachineLookupKey, updatedByoMachine)).Should(Succeed())
+						readyCondition := conditions.Get(updatedByoMachine, infrastructurev1beta1.BYOHostReady)
+						Expect(readyCondition).NotTo(BeNil())
+						Expect(readyCondition.Reason).To(Equal(infrastructurev1beta1.OSNotSupportedReason))
+						Expect(readyCondition.Message).To(Equal("no bundle is published for OS rhel"))
+					})
+
 					It("should patch byohost if installer config is ready", func() {
+						installationSecret := builder.Secret(defaultNamespace, "k8s-installation-secret").
+							WithKeyData(infrastructurev1beta1.RegistryCredentialsSecretNameKey, "registry-credentials").
+							Build()
+						Expect(k8sClientUncached.Create(ctx, installationSecret)).Should(Succeed())
+						WaitForObjectsToBePopulatedInCache(installationSecret)
+						DeferCleanup(func() {
+							Expect(k8sClientUncached.Delete(ctx, installationSecret)).Should(Succeed())
+						})
+
 						ph, err := patch.NewHelper(k8sInstallerConfig, k8sClientUncached)
 						Expect(err).ShouldNot(HaveOccurred())
 						k8sInstallerConfig.Status = infrastructurev1beta1.K8sInstallerConfigStatus{
@@ -463,7 +573,7 @@ var _ = Describe("ByoMachine Controller", func() {
 							InstallationSecret: &corev1.ObjectReference{
 								Kind:       "Secret",
 								Namespace:  defaultNamespace,
-								Name:       "K8sInstallationSecret",
+								Name:       installationSecret.Name,
 								APIVersion: "v1",
 							},
 						}
@@ -481,6 +591,59 @@ var _ = Describe("ByoMachine Controller", func() {
 						Expect(err).ShouldNot(HaveOccurred())
 
 						Expect(k8sInstallerConfig.Status.InstallationSecret).To(Equal(patchedHost.Spec.InstallationSecret))
+
+						role := &rbacv1.Role{}
+						Expect(k8sClientUncached.Get(ctx, types.NamespacedName{Namespace: patchedHost.Namespace, Name: patchedHost.Name + "-agent"}, role)).Should(Succeed())
+						Expect(role.Rules).To(HaveLen(1))
+						Expect(role.Rules[0].ResourceNames).To(ConsistOf(patchedHost.BootstrapSecretName(), installationSecret.Name, "registry-credentials"))
+					})
+
+					It("should grant the registry credentials Secret of the regenerated installation secret", func() {
+						installationSecret := builder.Secret(defaultNamespace, "k8s-installation-secret").
+							WithKeyData(infrastructurev1beta1.RegistryCredentialsSecretNameKey, "registry-credentials").
+							Build()
+						Expect(k8sClientUncached.Create(ctx, installationSecret)).Should(Succeed())
+						WaitForObjectsToBePopulatedInCache(installationSecret)
+						DeferCleanup(func() {
+							Expect(k8sClientUncached.Delete(ctx, installationSecret)).Should(Succeed())
+						})
+
+						ph, err := patch.NewHelper(k8sInstallerConfig, k8sClientUncached)
+						Expect(err).ShouldNot(HaveOccurred())
+						k8sInstallerConfig.Status = infrastructurev1beta1.K8sInstallerConfigStatus{
+							Ready: true,
+							InstallationSecret: &corev1.ObjectReference{
+								Kind:       "Secret",
+								Namespace:  defaultNamespace,
+								Name:       installationSecret.Name,
+								APIVersion: "v1",
+							},
+						}
+						Expect(ph.Patch(ctx, k8sInstallerConfig, patch.WithStatusObservedGeneration{})).Should(Succeed())
+						WaitForObjectToBeUpdatedInCache(k8sInstallerConfig, func(object client.Object) bool {
+							return object.(*infrastructurev1beta1.K8sInstallerConfig).Status.Ready == true
+						})
+
+						_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+						Expect(err).NotTo(HaveOccurred())
+
+						ph, err = patch.NewHelper(installationSecret, k8sClientUncached)
+						Expect(err).ShouldNot(HaveOccurred())
+						installationSecret.Data[infrastructurev1beta1.RegistryCredentialsSecretNameKey] = []byte("other-registry-credentials")
+						Expect(ph.Patch(ctx, installationSecret)).Should(Succeed())
+						WaitForObjectToBeUpdatedInCache(installationSecret, func(object client.Object) bool {
+							return string(object.(*corev1.Secret).Data[infrastructurev1beta1.RegistryCredentialsSecretNameKey]) == "other-registry-credentials"
+						})
+
+						_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+						Expect(err).NotTo(HaveOccurred())
+
+						patchedHost := &infrastructurev1beta1.ByoHost{}
+						Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, patchedHost)).Should(Succeed())
+						role := &rbacv1.Role{}
+						Expect(k8sClientUncached.Get(ctx, types.NamespacedName{Namespace: patchedHost.Namespace, Name: patchedHost.Name + "-agent"}, role)).Should(Succeed())
+						Expect(role.Rules).To(HaveLen(1))
+						Expect(role.Rules[0].ResourceNames).To(ConsistOf(patchedHost.BootstrapSecretName(), installationSecret.Name, "other-registry-credentials"))
 					})
 
 					AfterEach(func() {
@@ -611,6 +774,98 @@ var _ = Describe("ByoMachine Controller", func() {
 			})
 		})
 
+		Context("When the attached ByoHost is in another namespace than the ByoMachine", func() {
+			var installationSecret *corev1.Secret
+
+			BeforeEach(func() {
+				hostNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "byoh-hosts"}}
+				Expect(client.IgnoreAlreadyExists(k8sClientUncached.Create(ctx, hostNamespace))).Should(Succeed())
+
+				byoHost = builder.ByoHost(hostNamespace.Name, "other-namespace-host").Build()
+				Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+				node = builder.Node(defaultNamespace, byoHost.Name).Build()
+				Expect(k8sClient.Create(ctx, node)).Should(Succeed())
+
+				ph, err := patch.NewHelper(byoHost, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoHost.Status.MachineRef = &corev1.ObjectReference{
+					Kind:       "ByoMachine",
+					Namespace:  byoMachine.Namespace,
+					Name:       byoMachine.Name,
+					UID:        byoMachine.UID,
+					APIVersion: byoMachine.APIVersion,
+				}
+				byoHost.Labels = map[string]string{infrastructurev1beta1.AttachedByoMachineLabel: byoMachine.Namespace + "." + byoMachine.Name}
+				Expect(ph.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(byoHost, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoHost).Status.MachineRef != nil
+				})
+
+				k8sInstallerConfig = builder.K8sInstallerConfig(defaultNamespace, "").
+					WithName(byoMachine.Name).
+					WithBundleRepo("projects.registry.vmware.com/cluster_api_provider_bringyourownhost").
+					WithBundleType("k8s").
+					Build()
+				Expect(k8sClientUncached.Create(ctx, k8sInstallerConfig)).Should(Succeed())
+				ph, err = patch.NewHelper(k8sInstallerConfig, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				installationSecret = builder.Secret(defaultNamespace, "k8s-installation-secret").Build()
+				Expect(k8sClientUncached.Create(ctx, installationSecret)).Should(Succeed())
+				WaitForObjectsToBePopulatedInCache(installationSecret)
+				k8sInstallerConfig.Status = infrastructurev1beta1.K8sInstallerConfigStatus{
+					Ready: true,
+					InstallationSecret: &corev1.ObjectReference{
+						Kind:      "Secret",
+						Namespace: defaultNamespace,
+						Name:      installationSecret.Name,
+					},
+				}
+				Expect(ph.Patch(ctx, k8sInstallerConfig, patch.WithStatusObservedGeneration{})).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(k8sInstallerConfig, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.K8sInstallerConfig).Status.Ready
+				})
+
+				ph, err = patch.NewHelper(byoMachine, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoMachine.Spec.InstallerRef = &corev1.ObjectReference{
+					Kind:       "K8sInstallerConfigTemplate",
+					Namespace:  defaultNamespace,
+					Name:       defaultK8sInstallerConfigTemplateName,
+					APIVersion: "infrastructure.cluster.x-k8s.io/v1beta1",
+				}
+				Expect(ph.Patch(ctx, byoMachine, patch.WithStatusObservedGeneration{})).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(byoMachine, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoMachine).Spec.InstallerRef != nil
+				})
+			})
+
+			AfterEach(func() {
+				Expect(k8sClientUncached.Delete(ctx, installationSecret)).Should(Succeed())
+				Expect(k8sClientUncached.Delete(ctx, k8sInstallerConfig)).Should(Succeed())
+				Expect(k8sClientUncached.Delete(ctx, byoHost)).Should(Succeed())
+			})
+
+			It("grants the installation secret to the host agent in the namespace of the ByoMachine", func() {
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				roleKey := types.NamespacedName{Namespace: defaultNamespace, Name: byoHost.Name + "-agent"}
+				role := &rbacv1.Role{}
+				Expect(k8sClientUncached.Get(ctx, roleKey, role)).Should(Succeed())
+				Expect(role.Rules).To(HaveLen(1))
+				Expect(role.Rules[0].ResourceNames).To(ConsistOf(installationSecret.Name))
+				Expect(metav1.IsControlledBy(role, byoMachine)).To(BeTrue())
+
+				roleBinding := &rbacv1.RoleBinding{}
+				Expect(k8sClientUncached.Get(ctx, roleKey, roleBinding)).Should(Succeed())
+				Expect(roleBinding.Subjects).To(ConsistOf(rbacv1.Subject{
+					APIGroup: rbacv1.GroupName,
+					Kind:     rbacv1.UserKind,
+					Name:     "byoh:host:" + byoHost.Name,
+				}))
+			})
+		})
+
 		Context("When no matching BYO Hosts are available", func() {
 			BeforeEach(func() {
 				byoHost = builder.ByoHost(defaultNamespace, "byohost-with-different-label").
@@ -657,6 +912,48 @@ var _ = Describe("ByoMachine Controller", func() {
 			})
 		})
 
+		Context("When the only available ByoHost is in another namespace", func() {
+			BeforeEach(func() {
+				hostNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "byoh-hosts"}}
+				Expect(client.IgnoreAlreadyExists(k8sClientUncached.Create(ctx, hostNamespace))).Should(Succeed())
+
+				byoHost = builder.ByoHost(hostNamespace.Name, "byohost-in-other-namespace").Build()
+				Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+				node = builder.Node(defaultNamespace, byoHost.Name).Build()
+				Expect(k8sClient.Create(ctx, node)).Should(Succeed())
+
+				WaitForObjectsToBePopulatedInCache(byoHost)
+			})
+
+			AfterEach(func() {
+				reconciler.NamespacedHostAttachment = false
+				Expect(k8sClientUncached.Delete(ctx, byoHost)).ToNot(HaveOccurred())
+			})
+
+			It("should attach the ByoHost of another namespace", func() {
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(byoHost), createdByoHost)).Should(Succeed())
+				Expect(createdByoHost.Status.MachineRef).NotTo(BeNil())
+				Expect(createdByoHost.Status.MachineRef.Namespace).To(Equal(byoMachine.Namespace))
+				Expect(createdByoHost.Status.MachineRef.Name).To(Equal(byoMachine.Name))
+			})
+
+			It("should not attach the ByoHost of another namespace if the host attachment is namespaced", func() {
+				reconciler.NamespacedHostAttachment = true
+
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).To(MatchError("no hosts found"))
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(byoHost), createdByoHost)).Should(Succeed())
+				Expect(createdByoHost.Status.MachineRef).To(BeNil())
+				Expect(createdByoHost.Labels).NotTo(HaveKey(clusterv1.ClusterNameLabel))
+			})
+		})
+
 		Context("When all ByoHost are attached", func() {
 			BeforeEach(func() {
 				byoHost = builder.ByoHost(defaultNamespace, "byohost-attached-different-cluster").
@@ -822,6 +1119,94 @@ var _ = Describe("ByoMachine Controller", func() {
 				Expect(k8sInstallerConfigTemplate.Spec.Template.Spec).To(Equal(createdK8sInstallerConfig.Spec))
 				Expect(createdK8sInstallerConfig.GetAnnotations()[infrastructurev1beta1.K8sVersionAnnotation]).To(Equal(*machine.Spec.Version))
 			})
+
+			It("should update installer config when the template spec changes", func() {
+				ph, err := patch.NewHelper(byoMachine, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoMachine.Spec.InstallerRef = &corev1.ObjectReference{
+					Kind:       "K8sInstallerConfigTemplate",
+					Namespace:  k8sInstallerConfigTemplate.Namespace,
+					Name:       k8sInstallerConfigTemplate.Name,
+					APIVersion: infrastructurev1beta1.GroupVersion.String(),
+				}
+				Expect(ph.Patch(ctx, byoMachine, patch.WithStatusObservedGeneration{})).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(byoMachine, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoMachine).Spec.InstallerRef != nil
+				})
+				_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).Should(MatchError("no hosts found"))
+
+				ph, err = patch.NewHelper(k8sInstallerConfigTemplate, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				k8sInstallerConfigTemplate.Spec.Template.Spec.BundleRepo = "registry.example.com/byoh"
+				Expect(ph.Patch(ctx, k8sInstallerConfigTemplate)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(k8sInstallerConfigTemplate, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.K8sInstallerConfigTemplate).Spec.Template.Spec.BundleRepo == "registry.example.com/byoh"
+				})
+
+				_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).Should(MatchError("no hosts found"))
+
+				updatedK8sInstallerConfig := &infrastructurev1beta1.K8sInstallerConfig{}
+				Expect(k8sClientUncached.Get(ctx, byoMachineLookupKey, updatedK8sInstallerConfig)).Should(Succeed())
+				Expect(updatedK8sInstallerConfig.Spec.BundleRepo).To(Equal("registry.example.com/byoh"))
+			})
+		})
+
+		Context("When the ByoCluster has installer defaults", func() {
+			setClusterInstaller := func(installer *infrastructurev1beta1.ClusterInstaller) {
+				ph, err := patch.NewHelper(byoCluster, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoCluster.Spec.Installer = installer
+				Expect(ph.Patch(ctx, byoCluster)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(byoCluster, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoCluster).Spec.Installer != nil
+				})
+				DeferCleanup(func() {
+					ph, err := patch.NewHelper(byoCluster, k8sClientUncached)
+					Expect(err).ShouldNot(HaveOccurred())
+					byoCluster.Spec.Installer = nil
+					Expect(ph.Patch(ctx, byoCluster)).Should(Succeed())
+					WaitForObjectToBeUpdatedInCache(byoCluster, func(object client.Object) bool {
+						return object.(*infrastructurev1beta1.ByoCluster).Spec.Installer == nil
+					})
+				})
+			}
+
+			It("should create installer config from the default installer template of the ByoCluster", func() {
+				setClusterInstaller(&infrastructurev1beta1.ClusterInstaller{
+					TemplateRef: &corev1.ObjectReference{
+						Kind:       "K8sInstallerConfigTemplate",
+						Name:       k8sInstallerConfigTemplate.Name,
+						APIVersion: infrastructurev1beta1.GroupVersion.String(),
+					},
+				})
+
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).Should(MatchError("no hosts found"))
+
+				createdK8sInstallerConfig := &infrastructurev1beta1.K8sInstallerConfig{}
+				Expect(k8sClientUncached.Get(ctx, byoMachineLookupKey, createdK8sInstallerConfig)).Should(Succeed())
+				Expect(createdK8sInstallerConfig.Spec).To(Equal(k8sInstallerConfigTemplate.Spec.Template.Spec))
+			})
+
+			It("should create installer config from the default installer spec of the ByoCluster", func() {
+				setClusterInstaller(&infrastructurev1beta1.ClusterInstaller{
+					Spec: &infrastructurev1beta1.K8sInstallerConfigSpec{
+						BundleRepo: "registry.example.com/byoh",
+						BundleType: "k8s",
+					},
+				})
+
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).Should(MatchError("no hosts found"))
+
+				createdK8sInstallerConfig := &infrastructurev1beta1.K8sInstallerConfig{}
+				Expect(k8sClientUncached.Get(ctx, byoMachineLookupKey, createdK8sInstallerConfig)).Should(Succeed())
+				Expect(createdK8sInstallerConfig.Spec.BundleRepo).To(Equal("registry.example.com/byoh"))
+				Expect(createdK8sInstallerConfig.GetAnnotations()[infrastructurev1beta1.K8sVersionAnnotation]).To(Equal(*machine.Spec.Version))
+				Expect(metav1.IsControlledBy(createdK8sInstallerConfig, byoMachine)).To(BeTrue())
+			})
 		})
 
 		Context("When installer config template resource does not exists", func() {
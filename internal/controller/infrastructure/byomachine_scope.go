@@ -6,6 +6,7 @@ package infrastructure
 import (
 	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -66,3 +67,29 @@ func newByoMachineScope(params byoMachineScopeParams) (*byoMachineScope, error)
 		ByoHost:     params.ByoHost,
 	}, nil
 }
+
+// InstallerRef returns the reference to the installer template of the machine, the default installer
+// template of the ByoCluster if the ByoMachine has none
+func (m *byoMachineScope) InstallerRef() *corev1.ObjectReference {
+	if m.ByoMachine.Spec.InstallerRef != nil {
+		return m.ByoMachine.Spec.InstallerRef
+	}
+	if installer := m.ByoCluster.Spec.Installer; installer != nil {
+		return installer.TemplateRef
+	}
+	return nil
+}
+
+// DefaultInstallerSpec returns the spec of the K8sInstallerConfig of the machine when neither the ByoMachine
+// nor the ByoCluster reference an installer template, nil if the ByoCluster has no default installer spec
+func (m *byoMachineScope) DefaultInstallerSpec() *infrastructurev1beta1.K8sInstallerConfigSpec {
+	if m.InstallerRef() != nil || m.ByoCluster.Spec.Installer == nil {
+		return nil
+	}
+	return m.ByoCluster.Spec.Installer.Spec
+}
+
+// HasInstaller returns true if an installer config provides the installation secret of the machine
+func (m *byoMachineScope) HasInstaller() bool {
+	return m.InstallerRef() != nil || m.DefaultInstallerSpec() != nil
+}
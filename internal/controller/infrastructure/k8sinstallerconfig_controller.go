@@ -5,9 +5,12 @@ package infrastructure
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
 
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer"
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/hooks"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -31,10 +34,21 @@ import (
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/util"
 )
 
+// waitingForInstallationSecretReasons are the reasons of the ByoHostReady condition of a ByoMachine
+// waiting for the installation secret
+var waitingForInstallationSecretReasons = []string{
+	infrastructurev1beta1.InstallationSecretNotAvailableReason,
+	infrastructurev1beta1.OSNotSupportedReason,
+	infrastructurev1beta1.InstallationSecretGenerationFailedReason,
+	infrastructurev1beta1.ComponentVersionsNotSupportedReason,
+}
+
 // K8sInstallerConfigReconciler reconciles a K8sInstallerConfig object
 type K8sInstallerConfigReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// DigestResolver resolves bundle tags to digests when bundle verification is configured
+	DigestResolver installer.DigestResolver
 }
 
 // k8sInstallerConfigScope defines a scope defined around a K8sInstallerConfig and its ByoMachine
@@ -51,7 +65,10 @@ type k8sInstallerConfigScope struct {
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=k8sinstallerconfigs/finalizers,verbs=update
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byomachines,verbs=get;list;watch
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byomachines/status,verbs=get
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byoclusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohosts,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets;events,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -93,7 +110,18 @@ func (r *K8sInstallerConfigReconciler) Reconcile(ctx context.Context, req ctrl.R
 		return ctrl.Result{}, err
 	}
 	defer func() {
-		if err = helper.Patch(ctx, config); err != nil && reterr == nil {
+		conditions.SetSummary(config, conditions.WithConditions(
+			infrastructurev1beta1.OSSupported,
+			infrastructurev1beta1.K8sVersionSupported,
+			infrastructurev1beta1.InstallationSecretGenerated,
+		))
+		if err = helper.Patch(ctx, config, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
+			clusterv1.ReadyCondition,
+			infrastructurev1beta1.OSSupported,
+			infrastructurev1beta1.K8sVersionSupported,
+			infrastructurev1beta1.InstallationSecretGenerated,
+			infrastructurev1beta1.K8sInstallerConfigDrifted,
+		}}); err != nil && reterr == nil {
 			logger.Error(err, "failed to patch K8sInstallerConfig")
 			reterr = err
 		}
@@ -132,9 +160,24 @@ func (r *K8sInstallerConfigReconciler) Reconcile(ctx context.Context, req ctrl.R
 		return ctrl.Result{}, nil
 	}
 
+	// the spec changed after the installation secret was generated
+	if config.Status.Ready && config.Status.ObservedGeneration != config.Generation {
+		regenerate, err := r.reconcileSpecChange(ctx, scope)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if regenerate {
+			return r.reconcileNormal(ctx, scope)
+		}
+	}
+
 	switch {
-	// waiting for ByoMachine to updating it's ByoHostReady condition to false for reason InstallationSecretNotAvailableReason
-	case conditions.GetReason(byoMachine, infrastructurev1beta1.BYOHostReady) != infrastructurev1beta1.InstallationSecretNotAvailableReason:
+	// the attached host is upgraded in place when its k8s version changes
+	case config.Status.Ready && config.Spec.InPlaceUpgrade:
+		return r.reconcileInPlaceUpgrade(ctx, scope)
+	// waiting for ByoMachine to updating it's ByoHostReady condition to false for reason InstallationSecretNotAvailableReason,
+	// or a reason of this controller mirrored by the ByoMachine controller
+	case !slices.Contains(waitingForInstallationSecretReasons, conditions.GetReason(byoMachine, infrastructurev1beta1.BYOHostReady)):
 		logger.Info("ByoMachine is not waiting for InstallationSecret", "reason", conditions.GetReason(byoMachine, infrastructurev1beta1.BYOHostReady))
 		return ctrl.Result{}, nil
 	// Status is ready means a config has been generated.
@@ -150,28 +193,373 @@ func (r *K8sInstallerConfigReconciler) reconcileNormal(ctx context.Context, scop
 	logger := scope.Logger
 	logger.Info("Reconciling K8sInstallerConfig")
 
+	hostInfo := scope.ByoMachine.Status.HostInfo
 	k8sVersion := scope.Config.GetAnnotations()[infrastructurev1beta1.K8sVersionAnnotation]
+	scope.Config.Status.OSBundle = installer.ResolveOsBundle(hostInfo.OSImage, hostInfo.Architecture)
+	if scope.Config.Status.OSBundle == "" {
+		// the host OS does not change, retrying would not help
+		logger.Info("No k8s support for OS", "osImage", hostInfo.OSImage, "architecture", hostInfo.Architecture)
+		conditions.MarkFalse(scope.Config, infrastructurev1beta1.OSSupported, infrastructurev1beta1.OSNotSupportedReason, clusterv1.ConditionSeverityError,
+			"no bundle is published for OS %s and architecture %s", hostInfo.OSImage, hostInfo.Architecture)
+		conditions.MarkFalse(scope.Config, infrastructurev1beta1.InstallationSecretGenerated, infrastructurev1beta1.OSNotSupportedReason, clusterv1.ConditionSeverityError, "")
+		return ctrl.Result{}, nil
+	}
+	conditions.MarkTrue(scope.Config, infrastructurev1beta1.OSSupported)
+	// any version can be installed from a package repository, and the bundle repository may provide
+	// bundles of versions which are not published, so an unsupported version is only a warning
+	if isPackageRepositoryInstall(scope.Config) || installer.IsK8sVersionSupported(hostInfo.OSImage, hostInfo.Architecture, k8sVersion) {
+		conditions.MarkTrue(scope.Config, infrastructurev1beta1.K8sVersionSupported)
+	} else {
+		conditions.MarkFalse(scope.Config, infrastructurev1beta1.K8sVersionSupported, infrastructurev1beta1.K8sVersionNotSupportedReason, clusterv1.ConditionSeverityWarning,
+			"no bundle of k8s version %s is published for OS bundle %s", k8sVersion, scope.Config.Status.OSBundle)
+	}
+
+	if err := installer.ValidateComponentVersions(installer.BundleType(scope.Config.Spec.BundleType), k8sVersion, componentVersions(scope.Config)); err != nil {
+		// the spec has to change, retrying would not help
+		logger.Info("Component versions not supported", "k8sVersion", k8sVersion, "reason", err.Error())
+		conditions.MarkFalse(scope.Config, infrastructurev1beta1.InstallationSecretGenerated, infrastructurev1beta1.ComponentVersionsNotSupportedReason, clusterv1.ConditionSeverityError, "%s", err.Error())
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.generateInstallationSecret(ctx, scope, k8sVersion); err != nil {
+		conditions.MarkFalse(scope.Config, infrastructurev1beta1.InstallationSecretGenerated, infrastructurev1beta1.InstallationSecretGenerationFailedReason, clusterv1.ConditionSeverityError, "%s", err.Error())
+		return ctrl.Result{}, err
+	}
+	conditions.MarkTrue(scope.Config, infrastructurev1beta1.InstallationSecretGenerated)
+	return ctrl.Result{}, nil
+}
+
+// generateInstallationSecret generates the installation data of the host OS and k8s version
+// and stores it in the installation secret
+func (r *K8sInstallerConfigReconciler) generateInstallationSecret(ctx context.Context, scope *k8sInstallerConfigScope, k8sVersion string) error {
+	logger := scope.Logger
 	downloader := installer.NewBundleDownloader(scope.Config.Spec.BundleType, scope.Config.Spec.BundleRepo, "{{.BUNDLE_DOWNLOAD_PATH}}", logger)
-	installerObj, err := installer.NewInstaller(ctx, scope.ByoMachine.Status.HostInfo.OSImage, scope.ByoMachine.Status.HostInfo.Architecture, k8sVersion, downloader)
+	credentialsSecret, err := r.getRegistryCredentialsSecret(ctx, scope)
+	if err != nil {
+		logger.Error(err, "failed to get registry credentials")
+		return err
+	}
+	var registryCredentials []byte
+	if credentialsSecret != nil {
+		registryCredentials = credentialsSecret.Data[corev1.DockerConfigJsonKey]
+	}
+	downloader.WithRegistryCredentials(registryCredentials)
+	downloader.WithComponentVersions(componentVersions(scope.Config))
+	// packages installed from a repository are verified by apt with the repository key
+	if verification := scope.Config.Spec.BundleVerification; verification != nil && !isPackageRepositoryInstall(scope.Config) {
+		// pin the bundle to a digest so that all hosts install the same content,
+		// resolving the tag against the registry if no digest is set in the spec
+		digest := verification.Digest
+		if digest == "" {
+			bundleAddr, err := installer.GetBundleAddr(scope.ByoMachine.Status.HostInfo.OSImage, scope.ByoMachine.Status.HostInfo.Architecture, k8sVersion, downloader)
+			if err != nil {
+				logger.Error(err, "failed to get bundle address", "k8sVersion", k8sVersion)
+				return err
+			}
+			if r.DigestResolver == nil {
+				return errors.Errorf("no digest resolver configured to resolve bundle %s", bundleAddr)
+			}
+			if digest, err = r.DigestResolver.ResolveDigest(ctx, bundleAddr, registryCredentials); err != nil {
+				logger.Error(err, "failed to resolve bundle digest", "bundle", bundleAddr)
+				return err
+			}
+		}
+		downloader.WithVerification(digest, verification.PublicKey)
+		scope.Config.Status.BundleDigest = digest
+	}
+	var opts []installer.Option
+	if repository := scope.Config.Spec.PackageRepository; repository != nil {
+		opts = append(opts, installer.WithPackageRepository(&installer.PackageRepository{
+			URL:               repository.URL,
+			Suite:             repository.Suite,
+			Components:        repository.Components,
+			GPGKey:            repository.GPGKey,
+			ContainerdVersion: repository.ContainerdVersion,
+		}))
+	}
+	if scope.Config.Spec.Containerd != nil {
+		opts = append(opts, installer.WithContainerd(containerdConfig(scope.Config.Spec.Containerd)))
+	}
+	proxy, err := r.getProxy(ctx, scope)
+	if err != nil {
+		logger.Error(err, "failed to get proxy")
+		return err
+	}
+	if proxy != nil {
+		opts = append(opts, installer.WithProxy(proxy))
+	}
+	installerObj, err := installer.NewInstaller(ctx, scope.ByoMachine.Status.HostInfo.OSImage, scope.ByoMachine.Status.HostInfo.Architecture, k8sVersion, downloader, opts...)
 	if err != nil {
 		logger.Error(err, "failed to create installer instance", "osImage", scope.ByoMachine.Status.HostInfo.OSImage, "architecture", scope.ByoMachine.Status.HostInfo.Architecture, "k8sVersion", k8sVersion)
-		return ctrl.Result{}, err
+		return err
+	}
+
+	bundleAddr, err := installer.GetBundleAddr(scope.ByoMachine.Status.HostInfo.OSImage, scope.ByoMachine.Status.HostInfo.Architecture, k8sVersion, downloader)
+	if err != nil {
+		logger.Error(err, "failed to get bundle address", "k8sVersion", k8sVersion)
+		return err
+	}
+	scope.Config.Status.BundleAddr = bundleAddr
+	installSteps, err := installerObj.Plan().Marshal()
+	if err != nil {
+		logger.Error(err, "failed to marshal installation steps")
+		return err
+	}
+	data := map[string][]byte{
+		"install":   []byte(installerObj.Install()),
+		"uninstall": []byte(installerObj.Uninstall()),
+		infrastructurev1beta1.BundleAddrSecretKey:        []byte(bundleAddr),
+		infrastructurev1beta1.InstallationStepsSecretKey: installSteps,
+	}
+	if upgradePlan := installerObj.UpgradePlan(); upgradePlan != nil {
+		if data[infrastructurev1beta1.InstallationUpgradeStepsSecretKey], err = upgradePlan.Marshal(); err != nil {
+			logger.Error(err, "failed to marshal upgrade steps")
+			return err
+		}
+	}
+	if credentialsSecret != nil {
+		// the host reads the credentials from their Secret, they are not copied into the installation secret
+		data[infrastructurev1beta1.RegistryCredentialsSecretNameKey] = []byte(credentialsSecret.Name)
+	}
+	if componentAddrs := installer.GetComponentAddrs(scope.ByoMachine.Status.HostInfo.OSImage, scope.ByoMachine.Status.HostInfo.Architecture, downloader); componentAddrs != nil {
+		if data[infrastructurev1beta1.InstallationComponentsSecretKey], err = json.Marshal(componentAddrs); err != nil {
+			logger.Error(err, "failed to marshal component addresses")
+			return err
+		}
 	}
 
 	// creating installation secret
-	if err := r.storeInstallationData(ctx, scope, installerObj.Install(), installerObj.Uninstall()); err != nil {
+	return r.storeInstallationData(ctx, scope, data)
+}
+
+// reconcileSpecChange returns true if the installation secret has to be regenerated for a spec changed
+// after the secret was generated, which is the case while the attached host, if any, has not installed
+// the k8s components yet. Installed hosts keep the components of the older spec and the config is
+// marked as drifted.
+func (r *K8sInstallerConfigReconciler) reconcileSpecChange(ctx context.Context, scope *k8sInstallerConfigScope) (bool, error) {
+	logger := scope.Logger
+	if scope.Config.Status.ObservedGeneration == 0 {
+		// secrets generated before the generation was tracked are considered up to date
+		scope.Config.Status.ObservedGeneration = scope.Config.Generation
+		return false, nil
+	}
+	byoHost, err := r.getAttachedByoHost(ctx, scope)
+	if err != nil {
+		return false, err
+	}
+	if byoHost == nil || !conditions.IsTrue(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded) {
+		logger.Info("Regenerating installation secret for the changed spec", "from", scope.Config.Status.ObservedGeneration, "to", scope.Config.Generation)
+		return true, nil
+	}
+	conditions.Set(scope.Config, &clusterv1.Condition{
+		Type:     infrastructurev1beta1.K8sInstallerConfigDrifted,
+		Status:   corev1.ConditionTrue,
+		Severity: clusterv1.ConditionSeverityWarning,
+		Reason:   infrastructurev1beta1.InstalledFromOlderSpecReason,
+		Message: fmt.Sprintf("ByoHost %s was installed from generation %d of the spec, the current generation is %d",
+			byoHost.Name, scope.Config.Status.ObservedGeneration, scope.Config.Generation),
+	})
+	return false, nil
+}
+
+// reconcileInPlaceUpgrade regenerates the installation secret for the k8s version of the attached
+// ByoHost when it differs from the version the secret was generated for. The host agent then
+// upgrades the node with the upgrade steps of the secret. The version of the host is set when it is
+// attached, an upgrade is only triggered by changing it manually: a Machine version change is not propagated.
+func (r *K8sInstallerConfigReconciler) reconcileInPlaceUpgrade(ctx context.Context, scope *k8sInstallerConfigScope) (reconcile.Result, error) {
+	logger := scope.Logger
+	byoHost, err := r.getAttachedByoHost(ctx, scope)
+	if err != nil || byoHost == nil {
 		return ctrl.Result{}, err
 	}
+	hostVersion := byoHost.GetAnnotations()[infrastructurev1beta1.K8sVersionAnnotation]
+	configVersion := scope.Config.GetAnnotations()[infrastructurev1beta1.K8sVersionAnnotation]
+	if hostVersion == "" || hostVersion == configVersion {
+		return ctrl.Result{}, nil
+	}
+	if isPackageRepositoryInstall(scope.Config) {
+		logger.Info("Skipping in-place upgrade of packages installed from a repository", "k8sVersion", hostVersion)
+		return ctrl.Result{}, nil
+	}
+	if scope.Config.Spec.Components != nil {
+		// the component versions are validated against the current version
+		logger.Info("Skipping in-place upgrade of components installed independently of the bundle", "k8sVersion", hostVersion)
+		return ctrl.Result{}, nil
+	}
+	if verification := scope.Config.Spec.BundleVerification; verification != nil && verification.Digest != "" {
+		// the digest pins the bundle of the current version
+		logger.Info("Skipping in-place upgrade of a bundle pinned to a digest", "k8sVersion", hostVersion)
+		return ctrl.Result{}, nil
+	}
 
-	return ctrl.Result{}, nil
+	logger.Info("Regenerating installation secret for in-place upgrade", "from", configVersion, "to", hostVersion)
+	if scope.Config.Annotations == nil {
+		scope.Config.Annotations = map[string]string{}
+	}
+	scope.Config.Annotations[infrastructurev1beta1.K8sVersionAnnotation] = hostVersion
+	return r.reconcileNormal(ctx, scope)
+}
+
+// getAttachedByoHost returns the ByoHost attached to the ByoMachine of the configuration, nil if none is attached
+func (r *K8sInstallerConfigReconciler) getAttachedByoHost(ctx context.Context, scope *k8sInstallerConfigScope) (*infrastructurev1beta1.ByoHost, error) {
+	hostList := &infrastructurev1beta1.ByoHostList{}
+	if err := r.Client.List(ctx, hostList, client.InNamespace(scope.ByoMachine.Namespace), client.MatchingLabels{
+		infrastructurev1beta1.AttachedByoMachineLabel: scope.ByoMachine.Namespace + "." + scope.ByoMachine.Name,
+	}); err != nil {
+		scope.Logger.Error(err, "failed to list attached ByoHost")
+		return nil, err
+	}
+	if len(hostList.Items) == 0 {
+		return nil, nil
+	}
+	return &hostList.Items[0], nil
+}
+
+// getRegistryCredentialsSecret returns the dockerconfigjson Secret used to pull the bundle. The K8sInstallerConfig
+// credentials take precedence over the ByoCluster ones; nil is returned if none are configured.
+func (r *K8sInstallerConfigReconciler) getRegistryCredentialsSecret(ctx context.Context, scope *k8sInstallerConfigScope) (*corev1.Secret, error) {
+	secretRef := scope.Config.Spec.CredentialsSecretRef
+	if secretRef == nil {
+		byoCluster, err := r.getByoCluster(ctx, scope)
+		if err != nil {
+			return nil, err
+		}
+		if byoCluster != nil {
+			secretRef = byoCluster.Spec.BundleLookupCredentialsSecretRef
+		}
+	}
+	if secretRef == nil {
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: scope.Config.Namespace, Name: secretRef.Name}, secret); err != nil {
+		return nil, errors.Wrapf(err, "failed to get registry credentials secret %s/%s", scope.Config.Namespace, secretRef.Name)
+	}
+	if secret.Type != corev1.SecretTypeDockerConfigJson {
+		return nil, errors.Errorf("registry credentials secret %s/%s must be of type %s", secret.Namespace, secret.Name, corev1.SecretTypeDockerConfigJson)
+	}
+	return secret, nil
+}
+
+// getProxy returns the HTTP proxy of the host. The K8sInstallerConfig proxy takes precedence over the
+// ByoCluster one; nil is returned if none is configured. The pod and service CIDRs and the control plane
+// endpoint of the cluster are added to the hosts reached without the proxy.
+func (r *K8sInstallerConfigReconciler) getProxy(ctx context.Context, scope *k8sInstallerConfigScope) (*installer.ProxyConfig, error) {
+	proxy := scope.Config.Spec.Proxy
+	if proxy == nil {
+		byoCluster, err := r.getByoCluster(ctx, scope)
+		if err != nil {
+			return nil, err
+		}
+		if byoCluster != nil {
+			proxy = byoCluster.Spec.Proxy
+		}
+	}
+	if proxy == nil {
+		return nil, nil
+	}
+
+	noProxy := slices.Clone(proxy.NoProxy)
+	if network := scope.Cluster.Spec.ClusterNetwork; network != nil {
+		if network.Pods != nil {
+			noProxy = append(noProxy, network.Pods.CIDRBlocks...)
+		}
+		if network.Services != nil {
+			noProxy = append(noProxy, network.Services.CIDRBlocks...)
+		}
+	}
+	if host := scope.Cluster.Spec.ControlPlaneEndpoint.Host; host != "" {
+		noProxy = append(noProxy, host)
+	}
+	slices.Sort(noProxy)
+	return &installer.ProxyConfig{
+		HTTPProxy:  proxy.HTTPProxy,
+		HTTPSProxy: proxy.HTTPSProxy,
+		NoProxy:    slices.Compact(noProxy),
+	}, nil
+}
+
+// getByoCluster returns the ByoCluster of the cluster, nil if it does not exist
+func (r *K8sInstallerConfigReconciler) getByoCluster(ctx context.Context, scope *k8sInstallerConfigScope) (*infrastructurev1beta1.ByoCluster, error) {
+	if scope.Cluster.Spec.InfrastructureRef == nil {
+		return nil, nil
+	}
+	byoCluster := &infrastructurev1beta1.ByoCluster{}
+	key := client.ObjectKey{Namespace: scope.Cluster.Namespace, Name: scope.Cluster.Spec.InfrastructureRef.Name}
+	if err := r.Client.Get(ctx, key, byoCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get ByoCluster %s", key)
+	}
+	return byoCluster, nil
+}
+
+// getHooks returns the hooks of the spec with the scripts of their ConfigMaps,
+// nil is returned if none are configured.
+func (r *K8sInstallerConfigReconciler) getHooks(ctx context.Context, scope *k8sInstallerConfigScope) (*hooks.Hooks, error) {
+	spec := scope.Config.Spec.Hooks
+	if spec == nil {
+		return nil, nil
+	}
+	resolve := func(point hooks.Point, specHooks []infrastructurev1beta1.InstallerHook) ([]hooks.Hook, error) {
+		resolved := []hooks.Hook{}
+		for _, hook := range specHooks {
+			script := hook.Script
+			switch {
+			case script != "" && hook.ConfigMapKeyRef != nil:
+				return nil, errors.Errorf("%s hook %s must set either a script or a ConfigMap key", point, hook.Name)
+			case hook.ConfigMapKeyRef != nil:
+				configMap := &corev1.ConfigMap{}
+				key := client.ObjectKey{Namespace: scope.Config.Namespace, Name: hook.ConfigMapKeyRef.Name}
+				if err := r.Client.Get(ctx, key, configMap); err != nil {
+					return nil, errors.Wrapf(err, "failed to get ConfigMap %s of %s hook %s", key, point, hook.Name)
+				}
+				var ok bool
+				if script, ok = configMap.Data[hook.ConfigMapKeyRef.Key]; !ok {
+					return nil, errors.Errorf("ConfigMap %s of %s hook %s has no key %s", key, point, hook.Name, hook.ConfigMapKeyRef.Key)
+				}
+			}
+			resolved = append(resolved, hooks.Hook{Name: hook.Name, Script: script})
+		}
+		return resolved, nil
+	}
+
+	installerHooks := &hooks.Hooks{}
+	var err error
+	if installerHooks.PreInstall, err = resolve(hooks.PreInstall, spec.PreInstall); err != nil {
+		return nil, err
+	}
+	if installerHooks.PostInstall, err = resolve(hooks.PostInstall, spec.PostInstall); err != nil {
+		return nil, err
+	}
+	if installerHooks.PreUninstall, err = resolve(hooks.PreUninstall, spec.PreUninstall); err != nil {
+		return nil, err
+	}
+	if installerHooks.PostUninstall, err = resolve(hooks.PostUninstall, spec.PostUninstall); err != nil {
+		return nil, err
+	}
+	return installerHooks, installerHooks.Validate()
 }
 
 // storeInstallationData creates a new secret with the install and unstall data passed in as input,
-// sets the reference in the configuration status and ready to true.
-func (r *K8sInstallerConfigReconciler) storeInstallationData(ctx context.Context, scope *k8sInstallerConfigScope, install, uninstall string) error {
+// merged with the hooks of the spec, sets the reference in the configuration status and ready to true,
+// and records the generation of the spec the secret was generated from.
+func (r *K8sInstallerConfigReconciler) storeInstallationData(ctx context.Context, scope *k8sInstallerConfigScope, data map[string][]byte) error {
 	logger := scope.Logger
 	logger.Info("creating installation secret")
 
+	installerHooks, err := r.getHooks(ctx, scope)
+	if err != nil {
+		return err
+	}
+	if installerHooks != nil {
+		if data[infrastructurev1beta1.InstallationHooksSecretKey], err = installerHooks.Marshal(); err != nil {
+			return err
+		}
+	}
+
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      scope.Config.Name,
@@ -189,10 +577,7 @@ func (r *K8sInstallerConfigReconciler) storeInstallationData(ctx context.Context
 				},
 			},
 		},
-		Data: map[string][]byte{
-			"install":   []byte(install),
-			"uninstall": []byte(uninstall),
-		},
+		Data: data,
 		Type: clusterv1.ClusterSecretType,
 	}
 
@@ -213,6 +598,8 @@ func (r *K8sInstallerConfigReconciler) storeInstallationData(ctx context.Context
 		Name:      secret.Name,
 	}
 	scope.Config.Status.Ready = true
+	scope.Config.Status.ObservedGeneration = scope.Config.Generation
+	conditions.Delete(scope.Config, infrastructurev1beta1.K8sInstallerConfigDrifted)
 	logger.Info("created installation secret")
 	return nil
 }
@@ -224,6 +611,12 @@ func (r *K8sInstallerConfigReconciler) SetupWithManager(mgr ctrl.Manager) error
 		Watches(&infrastructurev1beta1.ByoMachine{},
 			handler.EnqueueRequestsFromMapFunc(r.ByoMachineToK8sInstallerConfigMapFunc),
 		).
+		Watches(&infrastructurev1beta1.ByoHost{},
+			handler.EnqueueRequestsFromMapFunc(r.ByoHostToK8sInstallerConfigMapFunc),
+		).
+		Watches(&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.SecretToK8sInstallerConfigMapFunc),
+		).
 		Named("infrastructure-k8sinstallerconfig").
 		Complete(r)
 }
@@ -240,7 +633,8 @@ func (r *K8sInstallerConfigReconciler) ByoMachineToK8sInstallerConfigMapFunc(ctx
 	m.GetObjectKind().SetGroupVersionKind(infrastructurev1beta1.GroupVersion.WithKind("ByoMachine"))
 
 	result := []ctrl.Request{}
-	if m.Spec.InstallerRef != nil && m.Spec.InstallerRef.GroupVersionKind() == infrastructurev1beta1.GroupVersion.WithKind("K8sInstallerConfigTemplate") {
+	// machines without InstallerRef may have a K8sInstallerConfig created from the defaults of their ByoCluster
+	if m.Spec.InstallerRef == nil || m.Spec.InstallerRef.GroupVersionKind() == infrastructurev1beta1.GroupVersion.WithKind("K8sInstallerConfigTemplate") {
 		configList := &infrastructurev1beta1.K8sInstallerConfigList{}
 		if err := r.Client.List(ctx, configList, client.InNamespace(m.Namespace)); err != nil {
 			logger.Error(err, "failed to list K8sInstallerConfig")
@@ -257,6 +651,63 @@ func (r *K8sInstallerConfigReconciler) ByoMachineToK8sInstallerConfigMapFunc(ctx
 	return result
 }
 
+// ByoHostToK8sInstallerConfigMapFunc is a handler.ToRequestsFunc to be used to enqueue
+// request for reconciliation of the K8sInstallerConfig of the ByoMachine the ByoHost is attached to.
+// The K8sInstallerConfig has the name of its ByoMachine.
+func (r *K8sInstallerConfigReconciler) ByoHostToK8sInstallerConfigMapFunc(ctx context.Context, o client.Object) []ctrl.Request {
+	h, ok := o.(*infrastructurev1beta1.ByoHost)
+	if !ok {
+		panic(fmt.Sprintf("Expected a ByoHost but got a %T", o))
+	}
+	if h.Status.MachineRef == nil {
+		return nil
+	}
+	return []ctrl.Request{
+		{NamespacedName: client.ObjectKey{Namespace: h.Status.MachineRef.Namespace, Name: h.Status.MachineRef.Name}},
+	}
+}
+
+// SecretToK8sInstallerConfigMapFunc is a handler.ToRequestsFunc to be used to enqueue
+// request for reconciliation of the K8sInstallerConfigs using the registry credentials Secret,
+// either with their own reference or with the one of a ByoCluster of the namespace.
+func (r *K8sInstallerConfigReconciler) SecretToK8sInstallerConfigMapFunc(ctx context.Context, o client.Object) []ctrl.Request {
+	logger := log.FromContext(ctx)
+
+	s, ok := o.(*corev1.Secret)
+	if !ok {
+		panic(fmt.Sprintf("Expected a Secret but got a %T", o))
+	}
+	if s.Type != corev1.SecretTypeDockerConfigJson {
+		return nil
+	}
+
+	byoClusterList := &infrastructurev1beta1.ByoClusterList{}
+	if err := r.Client.List(ctx, byoClusterList, client.InNamespace(s.Namespace)); err != nil {
+		logger.Error(err, "failed to list ByoCluster")
+		return nil
+	}
+	clusterCredentials := slices.ContainsFunc(byoClusterList.Items, func(byoCluster infrastructurev1beta1.ByoCluster) bool {
+		secretRef := byoCluster.Spec.BundleLookupCredentialsSecretRef
+		return secretRef != nil && secretRef.Name == s.Name
+	})
+
+	configList := &infrastructurev1beta1.K8sInstallerConfigList{}
+	if err := r.Client.List(ctx, configList, client.InNamespace(s.Namespace)); err != nil {
+		logger.Error(err, "failed to list K8sInstallerConfig")
+		return nil
+	}
+	result := []ctrl.Request{}
+	for idx := range configList.Items {
+		config := &configList.Items[idx]
+		secretRef := config.Spec.CredentialsSecretRef
+		if (secretRef != nil && secretRef.Name == s.Name) || (secretRef == nil && clusterCredentials) {
+			name := client.ObjectKey{Namespace: config.Namespace, Name: config.Name}
+			result = append(result, ctrl.Request{NamespacedName: name})
+		}
+	}
+	return result
+}
+
 func (r *K8sInstallerConfigReconciler) reconcileDelete(ctx context.Context, scope *k8sInstallerConfigScope) (reconcile.Result, error) {
 	logger := scope.Logger
 	logger.Info("Deleting K8sInstallerConfig")
@@ -273,3 +724,45 @@ func hasOwnerReferenceFrom(obj, owner client.Object) bool {
 	}
 	return false
 }
+
+// containerdConfig returns the installer containerd settings of the spec
+func containerdConfig(spec *infrastructurev1beta1.ContainerdConfig) *installer.ContainerdConfig {
+	config := &installer.ContainerdConfig{
+		RegistryMirrors:    map[string][]string{},
+		InsecureRegistries: spec.InsecureRegistries,
+		SandboxImage:       spec.SandboxImage,
+		SystemdCgroup:      spec.SystemdCgroup == nil || *spec.SystemdCgroup,
+		Snapshotter:        spec.Snapshotter,
+		DataRoot:           spec.DataRoot,
+	}
+	for _, mirror := range spec.RegistryMirrors {
+		config.RegistryMirrors[mirror.Registry] = mirror.Endpoints
+	}
+	return config
+}
+
+// isPackageRepositoryInstall returns true if the k8s components are installed from a package repository
+// instead of a bundle
+func isPackageRepositoryInstall(config *infrastructurev1beta1.K8sInstallerConfig) bool {
+	return installer.BundleType(config.Spec.BundleType) == installer.BundleTypeRepo
+}
+
+// componentVersions returns the versions of the components of the spec installed independently
+// of the bundle, keyed by installer component
+func componentVersions(config *infrastructurev1beta1.K8sInstallerConfig) map[string]string {
+	components := config.Spec.Components
+	if components == nil {
+		return nil
+	}
+	versions := map[string]string{}
+	for component, version := range map[string]string{
+		installer.ComponentContainerd: components.Containerd,
+		installer.ComponentCNIPlugins: components.CNIPlugins,
+		installer.ComponentCRITools:   components.CRITools,
+	} {
+		if version != "" {
+			versions[component] = version
+		}
+	}
+	return versions
+}
@@ -5,13 +5,19 @@ package infrastructure_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/hooks"
+	"github.com/cohesity/cluster-api-provider-bringyourownhost/installer/steps"
 	"github.com/cohesity/cluster-api-provider-bringyourownhost/test/builder"
 	eventutils "github.com/cohesity/cluster-api-provider-bringyourownhost/test/utils/events"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -247,7 +253,7 @@ var _ = Describe("K8sInstallerConfig Controller", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
-		It("should throw error if os distribution is not supported", func() {
+		It("should mark the OS not supported if os distribution is not supported", func() {
 			ph, err := patch.NewHelper(byoMachine, k8sClientUncached)
 			Expect(err).ShouldNot(HaveOccurred())
 			unsupportedOsDist := "unsupportedOsDist"
@@ -263,10 +269,16 @@ var _ = Describe("K8sInstallerConfig Controller", func() {
 					Namespace: k8sinstallerConfig.Namespace,
 				},
 			})
-			Expect(err).Should(MatchError("No k8s support for OS"))
+			Expect(err).NotTo(HaveOccurred())
+
+			updatedConfig := &infrastructurev1beta1.K8sInstallerConfig{}
+			Expect(k8sClientUncached.Get(ctx, k8sInstallerConfigLookupKey, updatedConfig)).Should(Succeed())
+			Expect(updatedConfig.Status.Ready).To(BeFalse())
+			Expect(conditions.IsFalse(updatedConfig, infrastructurev1beta1.OSSupported)).To(BeTrue())
+			Expect(conditions.GetReason(updatedConfig, clusterv1.ReadyCondition)).To(Equal(infrastructurev1beta1.OSNotSupportedReason))
 		})
 
-		It("should throw error if architecture is not supported", func() {
+		It("should mark the OS not supported if architecture is not supported", func() {
 			ph, err := patch.NewHelper(byoMachine, k8sClientUncached)
 			Expect(err).ShouldNot(HaveOccurred())
 			unsupportedArch := "unsupportedArch"
@@ -282,7 +294,13 @@ var _ = Describe("K8sInstallerConfig Controller", func() {
 					Namespace: k8sinstallerConfig.Namespace,
 				},
 			})
-			Expect(err).Should(MatchError("No k8s support for OS"))
+			Expect(err).NotTo(HaveOccurred())
+
+			updatedConfig := &infrastructurev1beta1.K8sInstallerConfig{}
+			Expect(k8sClientUncached.Get(ctx, k8sInstallerConfigLookupKey, updatedConfig)).Should(Succeed())
+			Expect(updatedConfig.Status.Ready).To(BeFalse())
+			Expect(conditions.IsFalse(updatedConfig, infrastructurev1beta1.OSSupported)).To(BeTrue())
+			Expect(conditions.GetReason(updatedConfig, clusterv1.ReadyCondition)).To(Equal(infrastructurev1beta1.OSNotSupportedReason))
 		})
 
 		It("should create secret of same name as of K8sInstallerConfig", func() {
@@ -315,6 +333,10 @@ var _ = Describe("K8sInstallerConfig Controller", func() {
 			Expect(exists).To(BeTrue())
 			_, exists = createdSecret.Data["uninstall"]
 			Expect(exists).To(BeTrue())
+			Expect(string(createdSecret.Data[infrastructurev1beta1.BundleAddrSecretKey])).To(HavePrefix(testBundleRepo + "/"))
+			plan, err := steps.Parse(createdSecret.Data[infrastructurev1beta1.InstallationStepsSecretKey])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.InstallScript()).To(Equal(string(createdSecret.Data["install"])))
 		})
 
 		It("should be add secret reference to K8sInstallerConfig", func() {
@@ -378,6 +400,20 @@ var _ = Describe("K8sInstallerConfig Controller", func() {
 			Expect(updatedConfig.Status.InstallationSecret.Namespace).Should(Equal(createdSecret.Namespace))
 		})
 
+		It("should report the resolved bundle and the installer conditions", func() {
+			_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			updatedConfig := &infrastructurev1beta1.K8sInstallerConfig{}
+			Expect(k8sClientUncached.Get(ctx, k8sInstallerConfigLookupKey, updatedConfig)).Should(Succeed())
+			Expect(updatedConfig.Status.OSBundle).To(Equal("Ubuntu_24.04.1_x86-64"))
+			Expect(updatedConfig.Status.BundleAddr).To(HavePrefix(testBundleRepo + "/"))
+			Expect(conditions.IsTrue(updatedConfig, infrastructurev1beta1.OSSupported)).To(BeTrue())
+			Expect(conditions.IsTrue(updatedConfig, infrastructurev1beta1.InstallationSecretGenerated)).To(BeTrue())
+			// no k8s version is set on the K8sInstallerConfig
+			Expect(conditions.GetReason(updatedConfig, infrastructurev1beta1.K8sVersionSupported)).To(Equal(infrastructurev1beta1.K8sVersionNotSupportedReason))
+		})
+
 		It("should be make K8sInstallerConfig ready after secret creation", func() {
 			_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{
 				NamespacedName: types.NamespacedName{
@@ -394,6 +430,493 @@ var _ = Describe("K8sInstallerConfig Controller", func() {
 			Expect(updatedConfig.Status.Ready).To(BeTrue())
 		})
 
+		Context("When bundle verification is configured", func() {
+			var (
+				testDigest    = "sha256:" + strings.Repeat("a", 64)
+				testPublicKey = "-----BEGIN PUBLIC KEY-----\nfake+key/data==\n-----END PUBLIC KEY-----"
+				resolver      *fakeDigestResolver
+			)
+
+			setBundleVerification := func(digest, publicKey string) {
+				ph, err := patch.NewHelper(k8sinstallerConfig, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				k8sinstallerConfig.Spec.BundleVerification = &infrastructurev1beta1.BundleVerification{
+					Digest:    digest,
+					PublicKey: publicKey,
+				}
+				Expect(ph.Patch(ctx, k8sinstallerConfig)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(k8sinstallerConfig, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.K8sInstallerConfig).Spec.BundleVerification != nil
+				})
+			}
+
+			BeforeEach(func() {
+				resolver = &fakeDigestResolver{digest: testDigest}
+				k8sInstallerConfigReconciler.DigestResolver = resolver
+			})
+
+			AfterEach(func() {
+				k8sInstallerConfigReconciler.DigestResolver = nil
+			})
+
+			It("should pin the bundle to the digest from the spec", func() {
+				setBundleVerification(testDigest, "")
+
+				_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resolver.resolved).To(BeEmpty())
+
+				createdSecret := &corev1.Secret{}
+				Expect(k8sClientUncached.Get(ctx, installerSecretLookupKey, createdSecret)).Should(Succeed())
+				Expect(string(createdSecret.Data["install"])).To(ContainSubstring("@" + testDigest))
+
+				updatedConfig := &infrastructurev1beta1.K8sInstallerConfig{}
+				Expect(k8sClientUncached.Get(ctx, k8sInstallerConfigLookupKey, updatedConfig)).Should(Succeed())
+				Expect(updatedConfig.Status.BundleDigest).To(Equal(testDigest))
+			})
+
+			It("should resolve the bundle digest when only a public key is set", func() {
+				setBundleVerification("", testPublicKey)
+
+				_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resolver.resolved).To(HaveLen(1))
+				Expect(resolver.resolved[0]).To(HavePrefix(testBundleRepo + "/"))
+
+				createdSecret := &corev1.Secret{}
+				Expect(k8sClientUncached.Get(ctx, installerSecretLookupKey, createdSecret)).Should(Succeed())
+				Expect(string(createdSecret.Data["install"])).To(ContainSubstring("@" + testDigest))
+				Expect(string(createdSecret.Data["install"])).To(ContainSubstring("cosign verify"))
+
+				updatedConfig := &infrastructurev1beta1.K8sInstallerConfig{}
+				Expect(k8sClientUncached.Get(ctx, k8sInstallerConfigLookupKey, updatedConfig)).Should(Succeed())
+				Expect(updatedConfig.Status.BundleDigest).To(Equal(testDigest))
+			})
+
+			It("should return error when the bundle digest cannot be resolved", func() {
+				resolver.err = errors.New("registry unreachable")
+				setBundleVerification("", testPublicKey)
+
+				_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).To(MatchError("registry unreachable"))
+
+				updatedConfig := &infrastructurev1beta1.K8sInstallerConfig{}
+				Expect(k8sClientUncached.Get(ctx, k8sInstallerConfigLookupKey, updatedConfig)).Should(Succeed())
+				Expect(updatedConfig.Status.Ready).To(BeFalse())
+			})
+		})
+
+		Context("When registry credentials are configured", func() {
+			var (
+				testDockerConfig  = `{"auths":{"registry.local":{"auth":"dXNlcjpwYXNz"}}}`
+				credentialsSecret *corev1.Secret
+			)
+
+			setCredentialsSecretRef := func(secretName string) {
+				ph, err := patch.NewHelper(k8sinstallerConfig, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				k8sinstallerConfig.Spec.CredentialsSecretRef = &corev1.LocalObjectReference{Name: secretName}
+				Expect(ph.Patch(ctx, k8sinstallerConfig)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(k8sinstallerConfig, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.K8sInstallerConfig).Spec.CredentialsSecretRef != nil
+				})
+			}
+
+			BeforeEach(func() {
+				credentialsSecret = builder.Secret(defaultNamespace, "registry-credentials").
+					WithKeyData(corev1.DockerConfigJsonKey, testDockerConfig).
+					WithType(corev1.SecretTypeDockerConfigJson).
+					Build()
+				Expect(k8sClientUncached.Create(ctx, credentialsSecret)).Should(Succeed())
+				WaitForObjectsToBePopulatedInCache(credentialsSecret)
+			})
+
+			AfterEach(func() {
+				Expect(k8sClientUncached.Delete(ctx, credentialsSecret)).Should(Succeed())
+			})
+
+			It("should reference the registry credentials secret from the installation secret", func() {
+				setCredentialsSecretRef(credentialsSecret.Name)
+
+				_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				createdSecret := &corev1.Secret{}
+				Expect(k8sClientUncached.Get(ctx, installerSecretLookupKey, createdSecret)).Should(Succeed())
+				Expect(string(createdSecret.Data[infrastructurev1beta1.RegistryCredentialsSecretNameKey])).To(Equal(credentialsSecret.Name))
+				for key, value := range createdSecret.Data {
+					Expect(string(value)).NotTo(ContainSubstring(testDockerConfig), "the credentials are copied under the key %s", key)
+				}
+				Expect(string(createdSecret.Data["install"])).To(ContainSubstring("export DOCKER_CONFIG={{.RegistryConfigDir}}"))
+			})
+
+			It("should use the ByoCluster registry credentials if none are set on the K8sInstallerConfig", func() {
+				ph, err := patch.NewHelper(byoCluster, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoCluster.Spec.BundleLookupCredentialsSecretRef = &corev1.LocalObjectReference{Name: credentialsSecret.Name}
+				Expect(ph.Patch(ctx, byoCluster)).Should(Succeed())
+				DeferCleanup(func() {
+					ph, err := patch.NewHelper(byoCluster, k8sClientUncached)
+					Expect(err).ShouldNot(HaveOccurred())
+					byoCluster.Spec.BundleLookupCredentialsSecretRef = nil
+					Expect(ph.Patch(ctx, byoCluster)).Should(Succeed())
+				})
+				WaitForObjectToBeUpdatedInCache(byoCluster, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoCluster).Spec.BundleLookupCredentialsSecretRef != nil
+				})
+
+				_, err = k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				createdSecret := &corev1.Secret{}
+				Expect(k8sClientUncached.Get(ctx, installerSecretLookupKey, createdSecret)).Should(Succeed())
+				Expect(string(createdSecret.Data[infrastructurev1beta1.RegistryCredentialsSecretNameKey])).To(Equal(credentialsSecret.Name))
+			})
+
+			It("should return error when the credentials secret is not a dockerconfigjson secret", func() {
+				opaqueSecret := builder.Secret(defaultNamespace, "opaque-registry-credentials").
+					WithKeyData(corev1.DockerConfigJsonKey, testDockerConfig).
+					Build()
+				Expect(k8sClientUncached.Create(ctx, opaqueSecret)).Should(Succeed())
+				WaitForObjectsToBePopulatedInCache(opaqueSecret)
+				setCredentialsSecretRef(opaqueSecret.Name)
+
+				_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).To(MatchError(ContainSubstring("must be of type kubernetes.io/dockerconfigjson")))
+			})
+		})
+
+		Context("When containerd settings are configured", func() {
+			It("should render the containerd configuration in the installation steps", func() {
+				ph, err := patch.NewHelper(k8sinstallerConfig, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				k8sinstallerConfig.Spec.Containerd = &infrastructurev1beta1.ContainerdConfig{
+					RegistryMirrors: []infrastructurev1beta1.RegistryMirror{{Registry: "docker.io", Endpoints: []string{"https://mirror.local"}}},
+					SandboxImage:    "registry.local/pause:3.9",
+				}
+				Expect(ph.Patch(ctx, k8sinstallerConfig)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(k8sinstallerConfig, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.K8sInstallerConfig).Spec.Containerd != nil
+				})
+
+				_, err = k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				createdSecret := &corev1.Secret{}
+				Expect(k8sClientUncached.Get(ctx, installerSecretLookupKey, createdSecret)).Should(Succeed())
+				install := string(createdSecret.Data["install"])
+				Expect(install).To(ContainSubstring("## containerd-config"))
+				Expect(install).To(ContainSubstring(`sandbox_image = "registry.local/pause:3.9"`))
+				// the systemd cgroup driver is defaulted by the CRD
+				Expect(install).To(ContainSubstring("SystemdCgroup = true"))
+				Expect(install).To(ContainSubstring(`[host."https://mirror.local"]`))
+			})
+		})
+
+		Context("When a proxy is configured", func() {
+			It("should use the ByoCluster proxy and bypass it for the cluster networks", func() {
+				ph, err := patch.NewHelper(byoCluster, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoCluster.Spec.Proxy = &infrastructurev1beta1.ProxyConfig{
+					HTTPSProxy: "http://proxy.local:3128",
+					NoProxy:    []string{".corp.local"},
+				}
+				Expect(ph.Patch(ctx, byoCluster)).Should(Succeed())
+				clusterPatch, err := patch.NewHelper(capiCluster, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				capiCluster.Spec.ClusterNetwork = &clusterv1.ClusterNetwork{
+					Pods:     &clusterv1.NetworkRanges{CIDRBlocks: []string{"192.168.0.0/16"}},
+					Services: &clusterv1.NetworkRanges{CIDRBlocks: []string{"10.128.0.0/12"}},
+				}
+				Expect(clusterPatch.Patch(ctx, capiCluster)).Should(Succeed())
+				DeferCleanup(func() {
+					ph, err := patch.NewHelper(byoCluster, k8sClientUncached)
+					Expect(err).ShouldNot(HaveOccurred())
+					byoCluster.Spec.Proxy = nil
+					Expect(ph.Patch(ctx, byoCluster)).Should(Succeed())
+					clusterPatch, err := patch.NewHelper(capiCluster, k8sClientUncached)
+					Expect(err).ShouldNot(HaveOccurred())
+					capiCluster.Spec.ClusterNetwork = nil
+					Expect(clusterPatch.Patch(ctx, capiCluster)).Should(Succeed())
+				})
+				WaitForObjectToBeUpdatedInCache(byoCluster, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoCluster).Spec.Proxy != nil
+				})
+				WaitForObjectToBeUpdatedInCache(capiCluster, func(object client.Object) bool {
+					return object.(*clusterv1.Cluster).Spec.ClusterNetwork != nil
+				})
+
+				_, err = k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				createdSecret := &corev1.Secret{}
+				Expect(k8sClientUncached.Get(ctx, installerSecretLookupKey, createdSecret)).Should(Succeed())
+				install := string(createdSecret.Data["install"])
+				Expect(install).To(ContainSubstring("export HTTPS_PROXY='http://proxy.local:3128'"))
+				Expect(install).To(ContainSubstring("export NO_PROXY='.corp.local,10.128.0.0/12,192.168.0.0/16'"))
+				Expect(install).To(ContainSubstring("cat > /etc/systemd/system/kubelet.service.d/10-byoh-proxy.conf"))
+			})
+		})
+
+		Context("When hooks are configured", func() {
+			setHooks := func(installerHooks *infrastructurev1beta1.InstallerHooks) {
+				ph, err := patch.NewHelper(k8sinstallerConfig, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				k8sinstallerConfig.Spec.Hooks = installerHooks
+				Expect(ph.Patch(ctx, k8sinstallerConfig)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(k8sinstallerConfig, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.K8sInstallerConfig).Spec.Hooks != nil
+				})
+			}
+
+			It("should merge the hooks into the installation secret", func() {
+				configMap := &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "site-hooks", Namespace: defaultNamespace},
+					Data:       map[string]string{"auditd": "augenrules --load"},
+				}
+				Expect(k8sClientUncached.Create(ctx, configMap)).Should(Succeed())
+				DeferCleanup(func() {
+					Expect(k8sClientUncached.Delete(ctx, configMap)).Should(Succeed())
+				})
+				WaitForObjectsToBePopulatedInCache(configMap)
+				setHooks(&infrastructurev1beta1.InstallerHooks{
+					PreInstall: []infrastructurev1beta1.InstallerHook{
+						{Name: "ca-bundle", Script: "update-ca-certificates"},
+						{Name: "auditd", ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: configMap.Name},
+							Key:                  "auditd",
+						}},
+					},
+				})
+
+				_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				createdSecret := &corev1.Secret{}
+				Expect(k8sClientUncached.Get(ctx, installerSecretLookupKey, createdSecret)).Should(Succeed())
+				installerHooks, err := hooks.Parse(createdSecret.Data[infrastructurev1beta1.InstallationHooksSecretKey])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(installerHooks.PreInstall).To(Equal([]hooks.Hook{
+					{Name: "ca-bundle", Script: "update-ca-certificates"},
+					{Name: "auditd", Script: "augenrules --load"},
+				}))
+			})
+
+			It("should return error when the ConfigMap of a hook does not exist", func() {
+				setHooks(&infrastructurev1beta1.InstallerHooks{
+					PostInstall: []infrastructurev1beta1.InstallerHook{
+						{Name: "missing", ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "non-existent"},
+							Key:                  "script",
+						}},
+					},
+				})
+
+				_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).To(MatchError(ContainSubstring("post-install hook missing")))
+
+				updatedConfig := &infrastructurev1beta1.K8sInstallerConfig{}
+				Expect(k8sClientUncached.Get(ctx, k8sInstallerConfigLookupKey, updatedConfig)).Should(Succeed())
+				Expect(conditions.GetReason(updatedConfig, infrastructurev1beta1.InstallationSecretGenerated)).To(Equal(infrastructurev1beta1.InstallationSecretGenerationFailedReason))
+			})
+		})
+
+		Context("When the k8s packages are installed from a package repository", func() {
+			It("should create the installation secret without bundle", func() {
+				ph, err := patch.NewHelper(k8sinstallerConfig, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				k8sinstallerConfig.Spec.BundleType = "repo"
+				k8sinstallerConfig.Spec.PackageRepository = &infrastructurev1beta1.PackageRepository{
+					URL:    "https://pkgs.k8s.io/core:/stable:/v1.30/deb/",
+					GPGKey: "-----BEGIN PGP PUBLIC KEY BLOCK-----",
+				}
+				Expect(ph.Patch(ctx, k8sinstallerConfig)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(k8sinstallerConfig, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.K8sInstallerConfig).Spec.PackageRepository != nil
+				})
+
+				_, err = k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				createdSecret := &corev1.Secret{}
+				Expect(k8sClientUncached.Get(ctx, installerSecretLookupKey, createdSecret)).Should(Succeed())
+				Expect(string(createdSecret.Data["install"])).To(ContainSubstring("## package-repository"))
+				Expect(createdSecret.Data[infrastructurev1beta1.BundleAddrSecretKey]).To(BeEmpty())
+				Expect(createdSecret.Data).NotTo(HaveKey(infrastructurev1beta1.InstallationUpgradeStepsSecretKey))
+			})
+		})
+
+		Context("When component versions are configured", func() {
+			setComponents := func(k8sVersion string, components *infrastructurev1beta1.ComponentVersions) {
+				ph, err := patch.NewHelper(k8sinstallerConfig, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				k8sinstallerConfig.Annotations = map[string]string{infrastructurev1beta1.K8sVersionAnnotation: k8sVersion}
+				k8sinstallerConfig.Spec.Components = components
+				Expect(ph.Patch(ctx, k8sinstallerConfig)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(k8sinstallerConfig, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.K8sInstallerConfig).Spec.Components != nil
+				})
+			}
+
+			It("should store the component artifacts in the installation secret", func() {
+				setComponents("v1.30.4", &infrastructurev1beta1.ComponentVersions{Containerd: "v1.7.22", CRITools: "v1.30.1"})
+
+				_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				createdSecret := &corev1.Secret{}
+				Expect(k8sClientUncached.Get(ctx, installerSecretLookupKey, createdSecret)).Should(Succeed())
+				components := map[string]string{}
+				Expect(json.Unmarshal(createdSecret.Data[infrastructurev1beta1.InstallationComponentsSecretKey], &components)).To(Succeed())
+				Expect(components).To(Equal(map[string]string{
+					"containerd": testBundleRepo + "/byoh-component-containerd-ubuntu_24.04.1_x86-64:v1.7.22",
+					"cri-tools":  testBundleRepo + "/byoh-component-cri-tools-ubuntu_24.04.1_x86-64:v1.30.1",
+				}))
+				Expect(string(createdSecret.Data["install"])).To(ContainSubstring("## download-component-containerd"))
+				Expect(createdSecret.Data).NotTo(HaveKey(infrastructurev1beta1.InstallationUpgradeStepsSecretKey))
+			})
+
+			It("should not create the installation secret for incompatible component versions", func() {
+				setComponents("v1.28.2", &infrastructurev1beta1.ComponentVersions{Containerd: "v2.0.0"})
+
+				_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				createdSecret := &corev1.Secret{}
+				Expect(apierrors.IsNotFound(k8sClientUncached.Get(ctx, installerSecretLookupKey, createdSecret))).To(BeTrue())
+
+				updatedConfig := &infrastructurev1beta1.K8sInstallerConfig{}
+				Expect(k8sClientUncached.Get(ctx, k8sInstallerConfigLookupKey, updatedConfig)).Should(Succeed())
+				Expect(updatedConfig.Status.Ready).To(BeFalse())
+				Expect(conditions.GetReason(updatedConfig, infrastructurev1beta1.InstallationSecretGenerated)).To(Equal(infrastructurev1beta1.ComponentVersionsNotSupportedReason))
+			})
+		})
+
+		Context("When the spec changes after the installation secret was generated", func() {
+			BeforeEach(func() {
+				ph, err := patch.NewHelper(k8sinstallerConfig, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				k8sinstallerConfig.Status.Ready = true
+				k8sinstallerConfig.Status.ObservedGeneration = k8sinstallerConfig.Generation
+				Expect(ph.Patch(ctx, k8sinstallerConfig)).Should(Succeed())
+
+				ph, err = patch.NewHelper(k8sinstallerConfig, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				k8sinstallerConfig.Spec.BundleRepo = "registry.example.com/byoh"
+				Expect(ph.Patch(ctx, k8sinstallerConfig)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(k8sinstallerConfig, func(object client.Object) bool {
+					config := object.(*infrastructurev1beta1.K8sInstallerConfig)
+					return config.Status.Ready && config.Spec.BundleRepo == "registry.example.com/byoh"
+				})
+			})
+
+			It("should regenerate the installation secret if the host is not installed yet", func() {
+				_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				createdSecret := &corev1.Secret{}
+				Expect(k8sClientUncached.Get(ctx, installerSecretLookupKey, createdSecret)).Should(Succeed())
+				Expect(string(createdSecret.Data[infrastructurev1beta1.BundleAddrSecretKey])).To(HavePrefix("registry.example.com/byoh/"))
+
+				updatedConfig := &infrastructurev1beta1.K8sInstallerConfig{}
+				Expect(k8sClientUncached.Get(ctx, k8sInstallerConfigLookupKey, updatedConfig)).Should(Succeed())
+				Expect(updatedConfig.Status.ObservedGeneration).To(Equal(updatedConfig.Generation))
+				Expect(conditions.Has(updatedConfig, infrastructurev1beta1.K8sInstallerConfigDrifted)).To(BeFalse())
+			})
+
+			It("should mark the K8sInstallerConfig drifted if the host is installed", func() {
+				byoHost := builder.ByoHost(defaultNamespace, "installed-host").
+					WithLabels(map[string]string{
+						infrastructurev1beta1.AttachedByoMachineLabel: byoMachine.Namespace + "." + byoMachine.Name,
+					}).
+					Build()
+				Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+				ph, err := patch.NewHelper(byoHost, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded)
+				Expect(ph.Patch(ctx, byoHost)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(byoHost, func(object client.Object) bool {
+					return conditions.IsTrue(object.(*infrastructurev1beta1.ByoHost), infrastructurev1beta1.K8sComponentsInstallationSucceeded)
+				})
+				defer func() {
+					Expect(k8sClientUncached.Delete(ctx, byoHost)).Should(Succeed())
+				}()
+
+				_, err = k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				err = k8sClientUncached.Get(ctx, installerSecretLookupKey, &corev1.Secret{})
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+				updatedConfig := &infrastructurev1beta1.K8sInstallerConfig{}
+				Expect(k8sClientUncached.Get(ctx, k8sInstallerConfigLookupKey, updatedConfig)).Should(Succeed())
+				Expect(updatedConfig.Status.ObservedGeneration).To(BeNumerically("<", updatedConfig.Generation))
+				Expect(conditions.IsTrue(updatedConfig, infrastructurev1beta1.K8sInstallerConfigDrifted)).To(BeTrue())
+				Expect(conditions.GetReason(updatedConfig, infrastructurev1beta1.K8sInstallerConfigDrifted)).To(Equal(infrastructurev1beta1.InstalledFromOlderSpecReason))
+			})
+		})
+
+		Context("When in-place upgrade is enabled", func() {
+			var byoHost *infrastructurev1beta1.ByoHost
+
+			BeforeEach(func() {
+				ph, err := patch.NewHelper(k8sinstallerConfig, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				k8sinstallerConfig.Annotations = map[string]string{infrastructurev1beta1.K8sVersionAnnotation: "v1.22.1"}
+				k8sinstallerConfig.Spec.InPlaceUpgrade = true
+				k8sinstallerConfig.Status.Ready = true
+				Expect(ph.Patch(ctx, k8sinstallerConfig)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(k8sinstallerConfig, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.K8sInstallerConfig).Status.Ready
+				})
+
+				byoHost = builder.ByoHost(defaultNamespace, "upgrade-host").
+					WithLabels(map[string]string{
+						infrastructurev1beta1.AttachedByoMachineLabel: byoMachine.Namespace + "." + byoMachine.Name,
+					}).
+					Build()
+				byoHost.Annotations = map[string]string{infrastructurev1beta1.K8sVersionAnnotation: "v1.22.1"}
+				Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+				WaitForObjectsToBePopulatedInCache(byoHost)
+			})
+
+			AfterEach(func() {
+				Expect(k8sClientUncached.Delete(ctx, byoHost)).Should(Succeed())
+			})
+
+			It("should not regenerate the installation secret while the host version is unchanged", func() {
+				_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				err = k8sClientUncached.Get(ctx, installerSecretLookupKey, &corev1.Secret{})
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			})
+
+			It("should regenerate the installation secret for the new version of the host", func() {
+				ph, err := patch.NewHelper(byoHost, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoHost.Annotations[infrastructurev1beta1.K8sVersionAnnotation] = "v1.23.4"
+				Expect(ph.Patch(ctx, byoHost)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(byoHost, func(object client.Object) bool {
+					return object.GetAnnotations()[infrastructurev1beta1.K8sVersionAnnotation] == "v1.23.4"
+				})
+
+				_, err = k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				createdSecret := &corev1.Secret{}
+				Expect(k8sClientUncached.Get(ctx, installerSecretLookupKey, createdSecret)).Should(Succeed())
+				Expect(string(createdSecret.Data[infrastructurev1beta1.BundleAddrSecretKey])).To(HaveSuffix(":v1.23.4"))
+				upgradePlan, err := steps.Parse(createdSecret.Data[infrastructurev1beta1.InstallationUpgradeStepsSecretKey])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(upgradePlan.InstallScript()).To(ContainSubstring("kubeadm upgrade node"))
+
+				updatedConfig := &infrastructurev1beta1.K8sInstallerConfig{}
+				Expect(k8sClientUncached.Get(ctx, k8sInstallerConfigLookupKey, updatedConfig)).Should(Succeed())
+				Expect(updatedConfig.Annotations).To(HaveKeyWithValue(infrastructurev1beta1.K8sVersionAnnotation, "v1.23.4"))
+			})
+		})
+
 		Context("When K8sInstallerConfig is deleted", func() {
 			BeforeEach(func() {
 				_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{
@@ -464,9 +987,10 @@ var _ = Describe("K8sInstallerConfig Controller", func() {
 	})
 
 	Context("ByoMachine to K8sInstallerConfig reconcile request", func() {
-		It("should not return reconcile request if ByoMachine InstallerRef doesn't exists", func(c SpecContext) {
+		It("should return reconcile request if ByoMachine InstallerRef doesn't exists", func(c SpecContext) {
+			// the K8sInstallerConfig may be created from the defaults of the ByoCluster
 			result := k8sInstallerConfigReconciler.ByoMachineToK8sInstallerConfigMapFunc(c, byoMachine)
-			Expect(len(result)).To(BeZero())
+			Expect(len(result)).NotTo(BeZero())
 		})
 
 		It("should not return reconcile request if ByoMachine InstallerRef doesn't refer to K8sInstallerConfitTemplate", func(c SpecContext) {
@@ -504,4 +1028,69 @@ var _ = Describe("K8sInstallerConfig Controller", func() {
 			Expect(len(result)).NotTo(BeZero())
 		})
 	})
+
+	Context("Secret to K8sInstallerConfig reconcile request", func() {
+		var credentialsSecret *corev1.Secret
+
+		BeforeEach(func() {
+			credentialsSecret = builder.Secret(defaultNamespace, "mapped-registry-credentials").
+				WithKeyData(corev1.DockerConfigJsonKey, `{"auths":{}}`).
+				WithType(corev1.SecretTypeDockerConfigJson).
+				Build()
+		})
+
+		It("should return reconcile request for the K8sInstallerConfig referencing the Secret", func(c SpecContext) {
+			config := builder.K8sInstallerConfig(defaultNamespace, "").
+				WithName("mapped-installer-config").
+				WithBundleRepo(testBundleRepo).
+				WithBundleType(testBundleType).
+				WithCredentialsSecretRef(credentialsSecret.Name).
+				Build()
+			Expect(k8sClientUncached.Create(ctx, config)).Should(Succeed())
+			DeferCleanup(func() {
+				Expect(k8sClientUncached.Delete(ctx, config)).Should(Succeed())
+			})
+			WaitForObjectsToBePopulatedInCache(config)
+
+			result := k8sInstallerConfigReconciler.SecretToK8sInstallerConfigMapFunc(c, credentialsSecret)
+			Expect(result).To(ConsistOf(reconcile.Request{NamespacedName: client.ObjectKeyFromObject(config)}))
+		})
+
+		It("should return reconcile request for the K8sInstallerConfigs using the ByoCluster Secret", func(c SpecContext) {
+			ph, err := patch.NewHelper(byoCluster, k8sClientUncached)
+			Expect(err).ShouldNot(HaveOccurred())
+			byoCluster.Spec.BundleLookupCredentialsSecretRef = &corev1.LocalObjectReference{Name: credentialsSecret.Name}
+			Expect(ph.Patch(ctx, byoCluster)).Should(Succeed())
+			DeferCleanup(func() {
+				ph, err := patch.NewHelper(byoCluster, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoCluster.Spec.BundleLookupCredentialsSecretRef = nil
+				Expect(ph.Patch(ctx, byoCluster)).Should(Succeed())
+			})
+			WaitForObjectToBeUpdatedInCache(byoCluster, func(object client.Object) bool {
+				return object.(*infrastructurev1beta1.ByoCluster).Spec.BundleLookupCredentialsSecretRef != nil
+			})
+
+			result := k8sInstallerConfigReconciler.SecretToK8sInstallerConfigMapFunc(c, credentialsSecret)
+			Expect(result).To(ContainElement(reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey}))
+		})
+
+		It("should not return reconcile request for a Secret which is not a dockerconfigjson Secret", func(c SpecContext) {
+			opaqueSecret := builder.Secret(defaultNamespace, credentialsSecret.Name).WithData("value").Build()
+			result := k8sInstallerConfigReconciler.SecretToK8sInstallerConfigMapFunc(c, opaqueSecret)
+			Expect(result).To(BeEmpty())
+		})
+	})
 })
+
+// fakeDigestResolver records the resolved bundle addresses and returns a fixed digest
+type fakeDigestResolver struct {
+	digest   string
+	err      error
+	resolved []string
+}
+
+func (f *fakeDigestResolver) ResolveDigest(_ context.Context, bundleAddr string, _ []byte) (string, error) {
+	f.resolved = append(f.resolved, bundleAddr)
+	return f.digest, f.err
+}
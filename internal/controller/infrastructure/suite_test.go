@@ -129,6 +129,9 @@ var _ = BeforeSuite(func() {
 	capiCluster = builder.Cluster(defaultNamespace, defaultClusterName).WithInfrastructureRef(byoCluster).Build()
 	Expect(k8sManager.GetClient().Create(context.Background(), capiCluster)).Should(Succeed())
 
+	bootstrapDataSecret := builder.Secret(defaultNamespace, fakeBootstrapSecret).WithData("bootstrap data").Build()
+	Expect(k8sManager.GetClient().Create(context.Background(), bootstrapDataSecret)).Should(Succeed())
+
 	node := builder.Node(defaultNamespace, defaultNodeName).Build()
 	k8sClient = fake.NewClientBuilder().WithObjects(
 		capiCluster,
@@ -156,6 +159,7 @@ var _ = BeforeSuite(func() {
 
 	byoAdmissionReconciler = &controllers.ByoAdmissionReconciler{
 		ClientSet: clientSetFake,
+		Client:    k8sManager.GetClient(),
 	}
 	err = byoAdmissionReconciler.SetupWithManager(k8sManager)
 	Expect(err).NotTo(HaveOccurred())
@@ -167,7 +171,9 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 
 	bootstrapKubeconfigReconciler = &controllers.BootstrapKubeconfigReconciler{
-		Client: k8sManager.GetClient(),
+		Client:     k8sManager.GetClient(),
+		ClientSet:  clientSetFake,
+		RestConfig: cfg,
 	}
 	err = bootstrapKubeconfigReconciler.SetupWithManager(k8sManager)
 	Expect(err).NotTo(HaveOccurred())
@@ -11,8 +11,10 @@ import (
 	"net/url"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -68,6 +70,14 @@ func (v *BootstrapKubeconfigCustomValidator) ValidateCreate(ctx context.Context,
 		allErrs = append(allErrs, err...)
 	}
 
+	if err := validateTTL(bootstrapkubeconfig); err != nil {
+		allErrs = append(allErrs, err...)
+	}
+
+	if err := validateTarget(bootstrapkubeconfig); err != nil {
+		allErrs = append(allErrs, err...)
+	}
+
 	if len(allErrs) == 0 {
 		return nil, nil
 	}
@@ -96,6 +106,14 @@ func (v *BootstrapKubeconfigCustomValidator) ValidateUpdate(ctx context.Context,
 		allErrs = append(allErrs, err...)
 	}
 
+	if err := validateTTL(bootstrapkubeconfig); err != nil {
+		allErrs = append(allErrs, err...)
+	}
+
+	if err := validateTarget(bootstrapkubeconfig); err != nil {
+		allErrs = append(allErrs, err...)
+	}
+
 	if len(allErrs) == 0 {
 		return nil, nil
 	}
@@ -121,8 +139,8 @@ func (v *BootstrapKubeconfigCustomValidator) ValidateDelete(ctx context.Context,
 func validateAPIServer(r *infrastructurev1beta1.BootstrapKubeconfig) field.ErrorList {
 	var allErrs field.ErrorList
 
+	// the API server is discovered by the controller if not set
 	if r.Spec.APIServer == "" {
-		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("apiserver"), r.Spec.APIServer, "APIServer field cannot be empty"))
 		return allErrs
 	}
 
@@ -139,8 +157,9 @@ func validateAPIServer(r *infrastructurev1beta1.BootstrapKubeconfig) field.Error
 func validateCAData(r *infrastructurev1beta1.BootstrapKubeconfig) field.ErrorList {
 	var allErrs field.ErrorList
 
+	// the CA data is discovered by the controller if not set
 	if r.Spec.CertificateAuthorityData == "" {
-		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("caData"), r.Spec.CertificateAuthorityData, "CertificateAuthorityData field cannot be empty"))
+		return allErrs
 	}
 
 	decodedCAData, err := b64.StdEncoding.DecodeString(r.Spec.CertificateAuthorityData)
@@ -156,6 +175,29 @@ func validateCAData(r *infrastructurev1beta1.BootstrapKubeconfig) field.ErrorLis
 	return allErrs
 }
 
+func validateTTL(r *infrastructurev1beta1.BootstrapKubeconfig) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if r.Spec.TTL != nil && r.Spec.TTL.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("ttl"), r.Spec.TTL.Duration.String(), "TTL must be positive"))
+	}
+
+	return allErrs
+}
+
+func validateTarget(r *infrastructurev1beta1.BootstrapKubeconfig) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if r.Spec.TargetNamespace != "" {
+		for _, msg := range validation.IsDNS1123Label(r.Spec.TargetNamespace) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("targetNamespace"), r.Spec.TargetNamespace, msg))
+		}
+	}
+	allErrs = append(allErrs, metav1validation.ValidateLabels(r.Spec.TargetLabels, field.NewPath("spec").Child("targetLabels"))...)
+
+	return allErrs
+}
+
 func isURLValid(parsedURL *url.URL) bool {
 	if parsedURL.Host == "" || parsedURL.Scheme != APIServerURLScheme || parsedURL.Port() == "" {
 		return false
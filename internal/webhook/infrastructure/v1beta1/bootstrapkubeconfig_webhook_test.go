@@ -6,6 +6,7 @@ package v1beta1_test
 import (
 	b64 "encoding/base64"
 	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -66,14 +67,13 @@ var _ = Describe("BootstrapKubeconfig Webhook", func() {
 			Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("spec.apiserver: Invalid value: %q: APIServer URL is not valid", testServerInvalidURL))))
 		})
 
-		It("Should deny creation if APIServer field is empty", func() {
+		It("Should admit creation if APIServer and CertificateAuthorityData fields are empty", func() {
 			obj = builder.BootstrapKubeconfig(defaultNamespace, testBootstrapKubeconfigName).
 				WithServer(testServerEmpty).
+				WithCAData(testCADataEmpty).
 				Build()
 			err = k8sClient.Create(ctx, obj)
-			Expect(err).To(HaveOccurred())
-			Expect(err).To(MatchError(ContainSubstring("admission webhook \"vbootstrapkubeconfig-v1beta1.kb.io\" denied the request")))
-			Expect(err).To(MatchError(ContainSubstring("spec.apiserver: Invalid value: \"\": APIServer field cannot be empty")))
+			Expect(err).NotTo(HaveOccurred())
 		})
 
 		It("Should deny creation if APIServer address does not have https scheme specified", func() {
@@ -106,15 +106,13 @@ var _ = Describe("BootstrapKubeconfig Webhook", func() {
 			Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("spec.apiserver: Invalid value: %q: APIServer is not of the format https://hostname:port", testServerWithoutPort))))
 		})
 
-		It("Should deny creation if CertificateAuthorityData field is empty", func() {
+		It("Should admit creation if only CertificateAuthorityData field is empty", func() {
 			obj = builder.BootstrapKubeconfig(defaultNamespace, testBootstrapKubeconfigName).
 				WithServer(testServerValid).
 				WithCAData(testCADataEmpty).
 				Build()
 			err = k8sClient.Create(ctx, obj)
-			Expect(err).To(HaveOccurred())
-			Expect(err).To(MatchError(ContainSubstring("admission webhook \"vbootstrapkubeconfig-v1beta1.kb.io\" denied the request")))
-			Expect(err).To(MatchError(ContainSubstring("spec.caData: Invalid value: \"\": CertificateAuthorityData field cannot be empty")))
+			Expect(err).NotTo(HaveOccurred())
 		})
 
 		It("Should deny creation if CertificateAuthorityData cannot be base64 decoded", func() {
@@ -139,6 +137,31 @@ var _ = Describe("BootstrapKubeconfig Webhook", func() {
 			Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("spec.caData: Invalid value: %q: CertificateAuthorityData is not PEM encoded", testPEMDataInvalid))))
 		})
 
+		It("Should deny creation if TTL is not positive", func() {
+			obj = builder.BootstrapKubeconfig(defaultNamespace, testBootstrapKubeconfigName).
+				WithServer(testServerValid).
+				WithCAData(b64.StdEncoding.EncodeToString(cfg.CAData)).
+				WithTTL(-time.Minute).
+				Build()
+			err = k8sClient.Create(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError(ContainSubstring("admission webhook \"vbootstrapkubeconfig-v1beta1.kb.io\" denied the request")))
+			Expect(err).To(MatchError(ContainSubstring("spec.ttl: Invalid value: \"-1m0s\": TTL must be positive")))
+		})
+
+		It("Should deny creation if the target namespace or labels are not valid", func() {
+			obj = builder.BootstrapKubeconfig(defaultNamespace, testBootstrapKubeconfigName).
+				WithServer(testServerValid).
+				WithCAData(b64.StdEncoding.EncodeToString(cfg.CAData)).
+				WithTarget("Tenant_A", map[string]string{"site": "apac/east"}).
+				Build()
+			err = k8sClient.Create(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError(ContainSubstring("admission webhook \"vbootstrapkubeconfig-v1beta1.kb.io\" denied the request")))
+			Expect(err).To(MatchError(ContainSubstring("spec.targetNamespace: Invalid value: \"Tenant_A\"")))
+			Expect(err).To(MatchError(ContainSubstring("spec.targetLabels: Invalid value: \"apac/east\"")))
+		})
+
 		It("Should admit creation if all fields are valid", func() {
 			// use from config of envtest
 			testCADataValid := b64.StdEncoding.EncodeToString(cfg.CAData)
@@ -190,12 +213,10 @@ var _ = Describe("BootstrapKubeconfig Webhook", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
-		It("Should deny update if APIServer field is empty", func() {
+		It("Should admit update if APIServer field is emptied", func() {
 			createdBootstrapKubeconfig.Spec.APIServer = testServerEmpty
 			err = ph.Patch(ctx, createdBootstrapKubeconfig)
-			Expect(err).To(HaveOccurred())
-			Expect(err).To(MatchError(ContainSubstring("admission webhook \"vbootstrapkubeconfig-v1beta1.kb.io\" denied the request")))
-			Expect(err).To(MatchError(ContainSubstring("spec.apiserver: Invalid value: \"\": APIServer field cannot be empty")))
+			Expect(err).NotTo(HaveOccurred())
 		})
 
 		It("Should deny update if APIServer is not of the correct format", func() {
@@ -206,12 +227,10 @@ var _ = Describe("BootstrapKubeconfig Webhook", func() {
 			Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("spec.apiserver: Invalid value: %q: APIServer is not of the format https://hostname:port", testServerWithoutHostname))))
 		})
 
-		It("Should deny update if CertificateAuthorityData field is empty", func() {
+		It("Should admit update if CertificateAuthorityData field is emptied", func() {
 			createdBootstrapKubeconfig.Spec.CertificateAuthorityData = testCADataEmpty
 			err = ph.Patch(ctx, createdBootstrapKubeconfig)
-			Expect(err).To(HaveOccurred())
-			Expect(err).To(MatchError(ContainSubstring("admission webhook \"vbootstrapkubeconfig-v1beta1.kb.io\" denied the request")))
-			Expect(err).To(MatchError(ContainSubstring("spec.caData: Invalid value: \"\": CertificateAuthorityData field cannot be empty")))
+			Expect(err).NotTo(HaveOccurred())
 		})
 
 		It("Should deny update if CertificateAuthorityData cannot be base64 decoded", func() {
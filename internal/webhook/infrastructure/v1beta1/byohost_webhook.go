@@ -7,11 +7,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
+	"slices"
 	"strings"
 
 	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -49,7 +54,7 @@ func SetupByoHostWebhookWithManager(mgr ctrl.Manager) error {
 
 // NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
 // Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
-// +kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-byohost,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=byohosts,verbs=create;update;delete,versions=v1beta1,name=vbyohost-v1beta1.kb.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-byohost,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=byohosts;byohosts/status,verbs=create;update;delete,versions=v1beta1,name=vbyohost-v1beta1.kb.io,admissionReviewVersions=v1
 
 // ByoHostCustomValidator struct is responsible for validating the ByoHost resource
 // when it is created, updated, or deleted.
@@ -106,10 +111,70 @@ func (v *ByoHostCustomValidator) ValidateDelete(ctx context.Context, obj runtime
 // To allow byoh manager service account to patch ByoHost CR
 const ManagerServiceAccount = "system:serviceaccount:byoh-system:byoh-controller-manager"
 
-const (
-	// minUsernamePartsForAgent is the minimum number of parts expected when splitting agent username
-	// Agent usernames follow the pattern "system:serviceaccount:namespace:name" which has at least 2 parts when split by ":"
-	minUsernamePartsForAgent = 2
+// byohHostUsernamePrefix prefixes the hostname in the username of the host agents
+const byohHostUsernamePrefix = "byoh:host:"
+
+// byoHostField is a field of ByoHost guarded against changes by the webhook
+type byoHostField struct {
+	path  string
+	value func(*infrastructurev1beta1.ByoHost) interface{}
+}
+
+// byoHostLabelField guards the label with the given key
+func byoHostLabelField(key string) byoHostField {
+	return byoHostField{
+		path:  fmt.Sprintf("metadata.labels[%s]", key),
+		value: func(h *infrastructurev1beta1.ByoHost) interface{} { return h.Labels[key] },
+	}
+}
+
+// byoHostConditionField guards the condition of the given type
+func byoHostConditionField(conditionType clusterv1.ConditionType) byoHostField {
+	return byoHostField{
+		path:  fmt.Sprintf("status.conditions[%s]", conditionType),
+		value: func(h *infrastructurev1beta1.ByoHost) interface{} { return conditions.Get(h, conditionType) },
+	}
+}
+
+var (
+	// managerConditionFields are the conditions set by the manager, the agent may not alter them
+	managerConditionFields = []byoHostField{
+		byoHostConditionField(infrastructurev1beta1.HostAdmittedCondition),
+	}
+
+	// reservationFields are set by the manager to reserve a ByoHost for a ByoMachine,
+	// the agent may only clear them when the host is released
+	reservationFields = []byoHostField{
+		{"spec.bootstrapSecret", func(h *infrastructurev1beta1.ByoHost) interface{} { return h.Spec.BootstrapSecret }},
+		{"spec.installationSecret", func(h *infrastructurev1beta1.ByoHost) interface{} { return h.Spec.InstallationSecret }},
+		{"status.machineRef", func(h *infrastructurev1beta1.ByoHost) interface{} { return h.Status.MachineRef }},
+		byoHostLabelField(clusterv1.ClusterNameLabel),
+		byoHostLabelField(infrastructurev1beta1.AttachedByoMachineLabel),
+	}
+
+	// releaseRemovedLabels are the labels set by the manager the agent removes when the host is released
+	releaseRemovedLabels = []string{
+		clusterv1.ClusterNameLabel,
+		infrastructurev1beta1.AttachedByoMachineLabel,
+	}
+
+	// releaseRemovedAnnotations are the annotations set by the manager the agent removes when the host is released
+	releaseRemovedAnnotations = []string{
+		infrastructurev1beta1.EndPointIPAnnotation,
+		infrastructurev1beta1.HostCleanupAnnotation,
+		infrastructurev1beta1.K8sVersionAnnotation,
+		infrastructurev1beta1.BundleLookupBaseRegistryAnnotation,
+	}
+
+	// agentReportedFields are the facts reported by the agent, the manager may not alter them
+	agentReportedFields = []byoHostField{
+		{"spec.uninstallationScript", func(h *infrastructurev1beta1.ByoHost) interface{} { return h.Spec.UninstallationScript }},
+		{"status.hostinfo", func(h *infrastructurev1beta1.ByoHost) interface{} { return h.Status.HostDetails }},
+		{"status.network", func(h *infrastructurev1beta1.ByoHost) interface{} { return h.Status.Network }},
+		{"status.bundleCache", func(h *infrastructurev1beta1.ByoHost) interface{} { return h.Status.BundleCache }},
+		{"status.installation", func(h *infrastructurev1beta1.ByoHost) interface{} { return h.Status.Installation }},
+		{"status.certificateExpiry", func(h *infrastructurev1beta1.ByoHost) interface{} { return h.Status.CertificateExpiry }},
+	}
 )
 
 // nolint: gocritic
@@ -138,24 +203,124 @@ func (v *ByoHostCustomValidator) handleCreateUpdateReq(_ context.Context, req *a
 		return admission.Errored(http.StatusBadRequest, err)
 	}
 
+	// a created ByoHost is validated against an empty one
+	oldByoHost := &infrastructurev1beta1.ByoHost{}
+	if req.Operation == admissionv1.Update {
+		if err = v.Decoder.DecodeRaw(req.OldObject, oldByoHost); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
 	userName := req.UserInfo.Username
-	// allow manager service account to patch ByoHost
+	// allow manager service account to patch ByoHost, but not the facts reported by the agent
 	if userName == ManagerServiceAccount && req.Operation == admissionv1.Update {
+		if path := changedField(agentReportedFields, oldByoHost, byoHost, false); path != "" {
+			return admission.Denied(fmt.Sprintf("%s cannot update resource %s: %s is reported by the agent", userName, byoHost.Name, path))
+		}
 		return admission.Allowed("")
 	}
 
-	substrs := strings.Split(userName, ":")
-	if len(substrs) < minUsernamePartsForAgent {
+	hostName, found := strings.CutPrefix(userName, byohHostUsernamePrefix)
+	if !found || hostName == "" {
 		return admission.Denied(fmt.Sprintf("%s is not a valid agent username", userName))
 	}
 
-	if !strings.Contains(byoHost.Name, substrs[2]) {
+	if hostName != byoHost.Name {
 		return admission.Denied(fmt.Sprintf("%s cannot create/update resource %s", userName, byoHost.Name))
 	}
 
+	if err := validateHostBinding(req.UserInfo.Groups, byoHost.Namespace, byoHost.Labels); err != nil {
+		return admission.Denied(fmt.Sprintf("%s cannot create/update resource %s: %v", userName, byoHost.Name, err))
+	}
+
+	if path := changedField(reservationFields, oldByoHost, byoHost, isReleasing(oldByoHost)); path != "" {
+		return admission.Denied(fmt.Sprintf("%s cannot create/update resource %s: %s is reserved to the manager", userName, byoHost.Name, path))
+	}
+
+	if path := changedField(managerConditionFields, oldByoHost, byoHost, false); path != "" {
+		return admission.Denied(fmt.Sprintf("%s cannot create/update resource %s: %s is set by the manager", userName, byoHost.Name, path))
+	}
+
+	if path := changedMetadata(req.Operation, oldByoHost, byoHost); path != "" {
+		return admission.Denied(fmt.Sprintf("%s cannot create/update resource %s: %s is set by the manager", userName, byoHost.Name, path))
+	}
+
 	return admission.Allowed("")
 }
 
+// changedMetadata returns the path of the first label or annotation of the ByoHost changed by the agent.
+// The agent sets the labels of the ByoHost when creating it, it may then only remove the labels and
+// annotations set by the manager to reserve the host once released.
+func changedMetadata(operation admissionv1.Operation, oldByoHost, newByoHost *infrastructurev1beta1.ByoHost) string {
+	if operation == admissionv1.Create {
+		return changedMetadataValues("annotations", nil, newByoHost.Annotations, nil)
+	}
+	var removableLabels, removableAnnotations []string
+	if isReleasing(oldByoHost) {
+		removableLabels, removableAnnotations = releaseRemovedLabels, releaseRemovedAnnotations
+	}
+	if path := changedMetadataValues("labels", oldByoHost.Labels, newByoHost.Labels, removableLabels); path != "" {
+		return path
+	}
+	return changedMetadataValues("annotations", oldByoHost.Annotations, newByoHost.Annotations, removableAnnotations)
+}
+
+// changedMetadataValues returns the path of the first of the values added, changed or removed, other than the
+// removable ones, from the old values to the new ones
+func changedMetadataValues(kind string, oldValues, newValues map[string]string, removable []string) string {
+	for _, key := range slices.Sorted(maps.Keys(newValues)) {
+		if oldValue, found := oldValues[key]; !found || oldValue != newValues[key] {
+			return fmt.Sprintf("metadata.%s[%s]", kind, key)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(oldValues)) {
+		if _, found := newValues[key]; !found && !slices.Contains(removable, key) {
+			return fmt.Sprintf("metadata.%s[%s]", kind, key)
+		}
+	}
+	return ""
+}
+
+// changedField returns the path of the first of the fields changed from the old ByoHost to the new one,
+// clearing a field is not a change when allowClear is set
+func changedField(fields []byoHostField, oldByoHost, newByoHost *infrastructurev1beta1.ByoHost, allowClear bool) string {
+	for _, f := range fields {
+		newValue := f.value(newByoHost)
+		if equality.Semantic.DeepEqual(f.value(oldByoHost), newValue) {
+			continue
+		}
+		if allowClear && equality.Semantic.DeepEqual(f.value(&infrastructurev1beta1.ByoHost{}), newValue) {
+			continue
+		}
+		return f.path
+	}
+	return ""
+}
+
+// isReleasing tells whether the ByoHost is being released from its ByoMachine. The manager marks it for
+// cleanup, the agent then clears the reservation with an update removing the mark and the cluster label
+// followed by a status update clearing the machine reference.
+func isReleasing(byoHost *infrastructurev1beta1.ByoHost) bool {
+	_, cleanup := byoHost.Annotations[infrastructurev1beta1.HostCleanupAnnotation]
+	_, reserved := byoHost.Labels[clusterv1.ClusterNameLabel]
+	return cleanup || !reserved
+}
+
+// validateHostBinding checks the ByoHost is in the namespace and carries the labels
+// the host is bound to by the groups of its certificate, if any
+func validateHostBinding(groups []string, namespace string, labels map[string]string) error {
+	boundNamespace, boundLabels := infrastructurev1beta1.HostBinding(groups)
+	if boundNamespace != "" && boundNamespace != namespace {
+		return fmt.Errorf("host is bound to namespace %s", boundNamespace)
+	}
+	for key, value := range boundLabels {
+		if actual, found := labels[key]; !found || actual != value {
+			return fmt.Errorf("host is bound to label %s=%s", key, value)
+		}
+	}
+	return nil
+}
+
 // func (v *ByoHostCustomValidator) handleDelete(ctx context.Context, req *admission.Request) admission.Response {
 // 	byohost := &infrastructurev1beta1.ByoHost{}
 // 	err := v.Decoder.DecodeRaw(req.OldObject, byohost)
@@ -15,6 +15,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
@@ -80,6 +82,64 @@ var _ = Describe("ByohostWebhook/Unit", func() {
 			Expect(resp.AdmissionResponse.Allowed).To(Equal(false))
 			Expect(string(resp.AdmissionResponse.Result.Message)).To(Equal(fmt.Sprintf("%s cannot create/update resource %s", "byoh:host:host2", "host1")))
 		})
+		It("Should reject request from an agent user whose hostname is only part of the ByoHost name", func(ctx SpecContext) {
+			admissionRequest := admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				UserInfo:  v1.UserInfo{Username: "byoh:host:host"},
+				Object: runtime.RawExtension{
+					Raw:    byoHostRaw,
+					Object: byoHost,
+				},
+			}
+			resp := v.Handle(ctx, admission.Request{AdmissionRequest: admissionRequest})
+			Expect(resp.AdmissionResponse.Allowed).To(Equal(false))
+			Expect(string(resp.AdmissionResponse.Result.Message)).To(Equal("byoh:host:host cannot create/update resource host1"))
+		})
+		It("Should reject request from a username without hostname", func(ctx SpecContext) {
+			admissionRequest := admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				UserInfo:  v1.UserInfo{Username: "byoh:host"},
+				Object: runtime.RawExtension{
+					Raw:    byoHostRaw,
+					Object: byoHost,
+				},
+			}
+			resp := v.Handle(ctx, admission.Request{AdmissionRequest: admissionRequest})
+			Expect(resp.AdmissionResponse.Allowed).To(Equal(false))
+			Expect(string(resp.AdmissionResponse.Result.Message)).To(Equal("byoh:host is not a valid agent username"))
+		})
+		It("Should reject request from the agent user creating a reserved ByoHost", func(ctx SpecContext) {
+			byoHost.Labels = map[string]string{clusterv1.ClusterNameLabel: "cluster1"}
+			byoHostRaw, err = json.Marshal(byoHost)
+			Expect(err).ShouldNot(HaveOccurred())
+			admissionRequest := admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				UserInfo:  v1.UserInfo{Username: "byoh:host:host1"},
+				Object: runtime.RawExtension{
+					Raw:    byoHostRaw,
+					Object: byoHost,
+				},
+			}
+			resp := v.Handle(ctx, admission.Request{AdmissionRequest: admissionRequest})
+			Expect(resp.AdmissionResponse.Allowed).To(Equal(false))
+			Expect(string(resp.AdmissionResponse.Result.Message)).To(Equal(fmt.Sprintf("byoh:host:host1 cannot create/update resource host1: metadata.labels[%s] is reserved to the manager", clusterv1.ClusterNameLabel)))
+		})
+		It("Should reject request from the agent user creating an annotated ByoHost", func(ctx SpecContext) {
+			byoHost.Annotations = map[string]string{infrastructurev1beta1.K8sVersionAnnotation: "v1.33.0"}
+			byoHostRaw, err = json.Marshal(byoHost)
+			Expect(err).ShouldNot(HaveOccurred())
+			admissionRequest := admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				UserInfo:  v1.UserInfo{Username: "byoh:host:host1"},
+				Object: runtime.RawExtension{
+					Raw:    byoHostRaw,
+					Object: byoHost,
+				},
+			}
+			resp := v.Handle(ctx, admission.Request{AdmissionRequest: admissionRequest})
+			Expect(resp.AdmissionResponse.Allowed).To(Equal(false))
+			Expect(string(resp.AdmissionResponse.Result.Message)).To(Equal(fmt.Sprintf("byoh:host:host1 cannot create/update resource host1: metadata.annotations[%s] is set by the manager", infrastructurev1beta1.K8sVersionAnnotation)))
+		})
 		It("Should allow request from the valid agent user", func(ctx SpecContext) {
 			admissionRequest := admissionv1.AdmissionRequest{
 				Operation: admissionv1.Create,
@@ -92,6 +152,47 @@ var _ = Describe("ByohostWebhook/Unit", func() {
 			resp := v.Handle(ctx, admission.Request{AdmissionRequest: admissionRequest})
 			Expect(resp.AdmissionResponse.Allowed).To(Equal(true))
 		})
+		It("Should reject request from an agent user bound to another namespace", func(ctx SpecContext) {
+			admissionRequest := admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				UserInfo:  v1.UserInfo{Username: "byoh:host:host1", Groups: []string{"byoh:hosts", "byoh:namespace:tenant-a"}},
+				Object: runtime.RawExtension{
+					Raw:    byoHostRaw,
+					Object: byoHost,
+				},
+			}
+			resp := v.Handle(ctx, admission.Request{AdmissionRequest: admissionRequest})
+			Expect(resp.AdmissionResponse.Allowed).To(Equal(false))
+			Expect(string(resp.AdmissionResponse.Result.Message)).To(Equal("byoh:host:host1 cannot create/update resource host1: host is bound to namespace tenant-a"))
+		})
+		It("Should reject request from an agent user bound to labels the ByoHost lacks", func(ctx SpecContext) {
+			admissionRequest := admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				UserInfo:  v1.UserInfo{Username: "byoh:host:host1", Groups: []string{"byoh:hosts", "byoh:namespace:default", "byoh:label:site=apac"}},
+				Object: runtime.RawExtension{
+					Raw:    byoHostRaw,
+					Object: byoHost,
+				},
+			}
+			resp := v.Handle(ctx, admission.Request{AdmissionRequest: admissionRequest})
+			Expect(resp.AdmissionResponse.Allowed).To(Equal(false))
+			Expect(string(resp.AdmissionResponse.Result.Message)).To(Equal("byoh:host:host1 cannot create/update resource host1: host is bound to label site=apac"))
+		})
+		It("Should allow request from an agent user bound to the namespace and labels of the ByoHost", func(ctx SpecContext) {
+			byoHost.Labels = map[string]string{"site": "apac", "cores": "2"}
+			byoHostRaw, err = json.Marshal(byoHost)
+			Expect(err).ShouldNot(HaveOccurred())
+			admissionRequest := admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				UserInfo:  v1.UserInfo{Username: "byoh:host:host1", Groups: []string{"byoh:hosts", "byoh:namespace:default", "byoh:label:site=apac"}},
+				Object: runtime.RawExtension{
+					Raw:    byoHostRaw,
+					Object: byoHost,
+				},
+			}
+			resp := v.Handle(ctx, admission.Request{AdmissionRequest: admissionRequest})
+			Expect(resp.AdmissionResponse.Allowed).To(Equal(true))
+		})
 	})
 
 	Context("When ByoHost gets an update request", func() {
@@ -180,6 +281,191 @@ var _ = Describe("ByohostWebhook/Unit", func() {
 			resp := v.Handle(ctx, admission.Request{AdmissionRequest: admissionRequest})
 			Expect(resp.AdmissionResponse.Allowed).To(Equal(true))
 		})
+
+		Context("When the ByoHost is reserved for a ByoMachine", func() {
+			var reservedByoHost *infrastructurev1beta1.ByoHost
+
+			updateRequest := func(userName string, oldByoHost, newByoHost *infrastructurev1beta1.ByoHost) admission.Request {
+				oldRaw, err := json.Marshal(oldByoHost)
+				Expect(err).ShouldNot(HaveOccurred())
+				newRaw, err := json.Marshal(newByoHost)
+				Expect(err).ShouldNot(HaveOccurred())
+				return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: admissionv1.Update,
+					UserInfo:  v1.UserInfo{Username: userName},
+					Object:    runtime.RawExtension{Raw: newRaw, Object: newByoHost},
+					OldObject: runtime.RawExtension{Raw: oldRaw, Object: oldByoHost},
+				}}
+			}
+
+			BeforeEach(func() {
+				reservedByoHost = byoHost.DeepCopy()
+				reservedByoHost.Labels = map[string]string{
+					clusterv1.ClusterNameLabel:                    "cluster1",
+					infrastructurev1beta1.AttachedByoMachineLabel: "default.byomachine1",
+				}
+				reservedByoHost.Spec.BootstrapSecret = &corev1.ObjectReference{Kind: "Secret", Namespace: "default", Name: "bootstrap1"}
+				reservedByoHost.Status.MachineRef = &corev1.ObjectReference{Kind: "ByoMachine", Namespace: "default", Name: "byomachine1"}
+			})
+
+			It("Should allow the agent user to update the status and the uninstallation script", func(ctx SpecContext) {
+				updated := reservedByoHost.DeepCopy()
+				uninstallScript := "kubeadm reset -f"
+				updated.Spec.UninstallationScript = &uninstallScript
+				updated.Status.HostDetails.Architecture = "amd64"
+				resp := v.Handle(ctx, updateRequest("byoh:host:host1", reservedByoHost, updated))
+				Expect(resp.AdmissionResponse.Allowed).To(Equal(true))
+			})
+
+			It("Should reject the agent user changing the machine reference", func(ctx SpecContext) {
+				updated := reservedByoHost.DeepCopy()
+				updated.Status.MachineRef.Name = "byomachine2"
+				resp := v.Handle(ctx, updateRequest("byoh:host:host1", reservedByoHost, updated))
+				Expect(resp.AdmissionResponse.Allowed).To(Equal(false))
+				Expect(string(resp.AdmissionResponse.Result.Message)).To(Equal("byoh:host:host1 cannot create/update resource host1: status.machineRef is reserved to the manager"))
+			})
+
+			It("Should reject the agent user changing the cluster label", func(ctx SpecContext) {
+				updated := reservedByoHost.DeepCopy()
+				updated.Labels[clusterv1.ClusterNameLabel] = "cluster2"
+				resp := v.Handle(ctx, updateRequest("byoh:host:host1", reservedByoHost, updated))
+				Expect(resp.AdmissionResponse.Allowed).To(Equal(false))
+				Expect(string(resp.AdmissionResponse.Result.Message)).To(Equal(fmt.Sprintf("byoh:host:host1 cannot create/update resource host1: metadata.labels[%s] is reserved to the manager", clusterv1.ClusterNameLabel)))
+			})
+
+			It("Should reject the agent user clearing the bootstrap secret of a host not marked for cleanup", func(ctx SpecContext) {
+				updated := reservedByoHost.DeepCopy()
+				updated.Spec.BootstrapSecret = nil
+				resp := v.Handle(ctx, updateRequest("byoh:host:host1", reservedByoHost, updated))
+				Expect(resp.AdmissionResponse.Allowed).To(Equal(false))
+				Expect(string(resp.AdmissionResponse.Result.Message)).To(Equal("byoh:host:host1 cannot create/update resource host1: spec.bootstrapSecret is reserved to the manager"))
+			})
+
+			It("Should allow the agent user to release a host marked for cleanup", func(ctx SpecContext) {
+				reservedByoHost.Annotations = map[string]string{infrastructurev1beta1.HostCleanupAnnotation: ""}
+				released := reservedByoHost.DeepCopy()
+				released.Annotations = nil
+				released.Labels = nil
+				released.Spec.BootstrapSecret = nil
+				resp := v.Handle(ctx, updateRequest("byoh:host:host1", reservedByoHost, released))
+				Expect(resp.AdmissionResponse.Allowed).To(Equal(true))
+
+				statusReleased := released.DeepCopy()
+				statusReleased.Status.MachineRef = nil
+				resp = v.Handle(ctx, updateRequest("byoh:host:host1", released, statusReleased))
+				Expect(resp.AdmissionResponse.Allowed).To(Equal(true))
+			})
+
+			It("Should reject the agent user changing an annotation set by the manager", func(ctx SpecContext) {
+				reservedByoHost.Annotations = map[string]string{infrastructurev1beta1.EndPointIPAnnotation: "10.0.0.1"}
+				updated := reservedByoHost.DeepCopy()
+				updated.Annotations[infrastructurev1beta1.EndPointIPAnnotation] = "10.0.0.2"
+				resp := v.Handle(ctx, updateRequest("byoh:host:host1", reservedByoHost, updated))
+				Expect(resp.AdmissionResponse.Allowed).To(Equal(false))
+				Expect(string(resp.AdmissionResponse.Result.Message)).To(Equal(fmt.Sprintf("byoh:host:host1 cannot create/update resource host1: metadata.annotations[%s] is set by the manager", infrastructurev1beta1.EndPointIPAnnotation)))
+			})
+
+			It("Should reject the agent user setting the k8s version of its host", func(ctx SpecContext) {
+				updated := reservedByoHost.DeepCopy()
+				updated.Annotations = map[string]string{infrastructurev1beta1.K8sVersionAnnotation: "v1.33.0"}
+				resp := v.Handle(ctx, updateRequest("byoh:host:host1", reservedByoHost, updated))
+				Expect(resp.AdmissionResponse.Allowed).To(Equal(false))
+				Expect(string(resp.AdmissionResponse.Result.Message)).To(Equal(fmt.Sprintf("byoh:host:host1 cannot create/update resource host1: metadata.annotations[%s] is set by the manager", infrastructurev1beta1.K8sVersionAnnotation)))
+			})
+
+			It("Should reject the agent user removing an annotation of a host not marked for cleanup", func(ctx SpecContext) {
+				reservedByoHost.Annotations = map[string]string{infrastructurev1beta1.K8sVersionAnnotation: "v1.33.0"}
+				updated := reservedByoHost.DeepCopy()
+				updated.Annotations = nil
+				resp := v.Handle(ctx, updateRequest("byoh:host:host1", reservedByoHost, updated))
+				Expect(resp.AdmissionResponse.Allowed).To(Equal(false))
+				Expect(string(resp.AdmissionResponse.Result.Message)).To(Equal(fmt.Sprintf("byoh:host:host1 cannot create/update resource host1: metadata.annotations[%s] is set by the manager", infrastructurev1beta1.K8sVersionAnnotation)))
+			})
+
+			It("Should reject the agent user adding a label", func(ctx SpecContext) {
+				updated := reservedByoHost.DeepCopy()
+				updated.Labels["site"] = "apac"
+				resp := v.Handle(ctx, updateRequest("byoh:host:host1", reservedByoHost, updated))
+				Expect(resp.AdmissionResponse.Allowed).To(Equal(false))
+				Expect(string(resp.AdmissionResponse.Result.Message)).To(Equal("byoh:host:host1 cannot create/update resource host1: metadata.labels[site] is set by the manager"))
+			})
+
+			It("Should reject the agent user removing its registration labels when released", func(ctx SpecContext) {
+				reservedByoHost.Labels["site"] = "apac"
+				reservedByoHost.Annotations = map[string]string{infrastructurev1beta1.HostCleanupAnnotation: ""}
+				released := reservedByoHost.DeepCopy()
+				released.Labels = nil
+				resp := v.Handle(ctx, updateRequest("byoh:host:host1", reservedByoHost, released))
+				Expect(resp.AdmissionResponse.Allowed).To(Equal(false))
+				Expect(string(resp.AdmissionResponse.Result.Message)).To(Equal("byoh:host:host1 cannot create/update resource host1: metadata.labels[site] is set by the manager"))
+			})
+
+			DescribeTable("Should allow the agent user to remove the annotations of a released host",
+				func(ctx SpecContext, annotation string) {
+					reservedByoHost.Annotations = map[string]string{
+						infrastructurev1beta1.HostCleanupAnnotation: "",
+						annotation: "value",
+					}
+					released := reservedByoHost.DeepCopy()
+					delete(released.Annotations, annotation)
+					resp := v.Handle(ctx, updateRequest("byoh:host:host1", reservedByoHost, released))
+					Expect(resp.AdmissionResponse.Allowed).To(Equal(true))
+				},
+				Entry("endpoint IP", infrastructurev1beta1.EndPointIPAnnotation),
+				Entry("cleanup", infrastructurev1beta1.HostCleanupAnnotation),
+				Entry("k8s version", infrastructurev1beta1.K8sVersionAnnotation),
+				Entry("bundle registry", infrastructurev1beta1.BundleLookupBaseRegistryAnnotation),
+			)
+
+			DescribeTable("Should allow the agent user to remove the labels of a released host",
+				func(ctx SpecContext, label string) {
+					reservedByoHost.Annotations = map[string]string{infrastructurev1beta1.HostCleanupAnnotation: ""}
+					released := reservedByoHost.DeepCopy()
+					delete(released.Labels, label)
+					resp := v.Handle(ctx, updateRequest("byoh:host:host1", reservedByoHost, released))
+					Expect(resp.AdmissionResponse.Allowed).To(Equal(true))
+				},
+				Entry("cluster name", clusterv1.ClusterNameLabel),
+				Entry("attached ByoMachine", infrastructurev1beta1.AttachedByoMachineLabel),
+			)
+
+			It("Should reject the agent user admitting its host", func(ctx SpecContext) {
+				conditions.MarkFalse(reservedByoHost, infrastructurev1beta1.HostAdmittedCondition, infrastructurev1beta1.HostAdmissionPendingReason, clusterv1.ConditionSeverityInfo, "")
+				updated := reservedByoHost.DeepCopy()
+				conditions.MarkTrue(updated, infrastructurev1beta1.HostAdmittedCondition)
+				resp := v.Handle(ctx, updateRequest("byoh:host:host1", reservedByoHost, updated))
+				Expect(resp.AdmissionResponse.Allowed).To(Equal(false))
+				Expect(string(resp.AdmissionResponse.Result.Message)).To(Equal("byoh:host:host1 cannot create/update resource host1: status.conditions[HostAdmitted] is set by the manager"))
+			})
+
+			It("Should allow the agent user to update its own conditions", func(ctx SpecContext) {
+				conditions.MarkFalse(reservedByoHost, infrastructurev1beta1.HostAdmittedCondition, infrastructurev1beta1.HostAdmissionPendingReason, clusterv1.ConditionSeverityInfo, "")
+				updated := reservedByoHost.DeepCopy()
+				conditions.MarkTrue(updated, infrastructurev1beta1.K8sNodeBootstrapSucceeded)
+				resp := v.Handle(ctx, updateRequest("byoh:host:host1", reservedByoHost, updated))
+				Expect(resp.AdmissionResponse.Allowed).To(Equal(true))
+			})
+
+			It("Should allow the manager to admit the host", func(ctx SpecContext) {
+				updated := reservedByoHost.DeepCopy()
+				conditions.MarkTrue(updated, infrastructurev1beta1.HostAdmittedCondition)
+				resp := v.Handle(ctx, updateRequest(ManagerServiceAccount, reservedByoHost, updated))
+				Expect(resp.AdmissionResponse.Allowed).To(Equal(true))
+			})
+
+			It("Should allow the manager to reserve the host", func(ctx SpecContext) {
+				resp := v.Handle(ctx, updateRequest(ManagerServiceAccount, byoHost, reservedByoHost))
+				Expect(resp.AdmissionResponse.Allowed).To(Equal(true))
+			})
+
+			It("Should reject the manager altering the facts reported by the agent", func(ctx SpecContext) {
+				updated := reservedByoHost.DeepCopy()
+				updated.Status.Installation = &infrastructurev1beta1.InstallationStatus{K8sVersion: "v1.33.0"}
+				resp := v.Handle(ctx, updateRequest(ManagerServiceAccount, reservedByoHost, updated))
+				Expect(resp.AdmissionResponse.Allowed).To(Equal(false))
+				Expect(string(resp.AdmissionResponse.Result.Message)).To(Equal(fmt.Sprintf("%s cannot update resource host1: status.installation is reported by the agent", ManagerServiceAccount)))
+			})
+		})
 	})
 	Context("When ByoHost gets an delete request", func() {
 		var (
@@ -83,7 +83,7 @@ var _ = Describe("ByoHost Webhook", func() {
 				}
 				Expect(k8sClientUncached.Create(ctx, byoMachine)).Should(Succeed())
 
-				ph, err := patch.NewHelper(obj, ValidUserK8sClient)
+				ph, err := patch.NewHelper(obj, ManagerK8sClient)
 				Expect(err).ShouldNot(HaveOccurred())
 				obj.Status.MachineRef = &corev1.ObjectReference{
 					Kind:       "ByoMachine",
@@ -103,7 +103,7 @@ var _ = Describe("ByoHost Webhook", func() {
 
 			AfterEach(func() {
 				// delete the byohost resource
-				ph, err := patch.NewHelper(obj, ValidUserK8sClient)
+				ph, err := patch.NewHelper(obj, ManagerK8sClient)
 				Expect(err).ShouldNot(HaveOccurred())
 				obj.Status.MachineRef = nil
 				Expect(ph.Patch(ctx, obj, patch.WithStatusObservedGeneration{})).Should(Succeed())
@@ -0,0 +1,135 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var k8sinstallerconfiglog = logf.Log.WithName("k8sinstallerconfig-resource")
+
+// SetupK8sInstallerConfigWebhookWithManager registers the webhooks for K8sInstallerConfig and
+// K8sInstallerConfigTemplate in the manager.
+func SetupK8sInstallerConfigWebhookWithManager(mgr ctrl.Manager) error {
+	if err := ctrl.NewWebhookManagedBy(mgr).For(&infrastructurev1beta1.K8sInstallerConfig{}).
+		WithValidator(&K8sInstallerConfigCustomValidator{}).
+		Complete(); err != nil {
+		return err
+	}
+	return ctrl.NewWebhookManagedBy(mgr).For(&infrastructurev1beta1.K8sInstallerConfigTemplate{}).
+		WithValidator(&K8sInstallerConfigCustomValidator{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-k8sinstallerconfig,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=k8sinstallerconfigs,verbs=create;update,versions=v1beta1,name=vk8sinstallerconfig-v1beta1.kb.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-k8sinstallerconfigtemplate,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=k8sinstallerconfigtemplates,verbs=create;update,versions=v1beta1,name=vk8sinstallerconfigtemplate-v1beta1.kb.io,admissionReviewVersions=v1
+
+// K8sInstallerConfigCustomValidator struct is responsible for validating the K8sInstallerConfig and
+// K8sInstallerConfigTemplate resources when they are created or updated. The settings rendered into
+// the installation scripts are checked further than the CRD schema allows.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as this struct is used only for temporary operations and does not need to be deeply copied.
+type K8sInstallerConfigCustomValidator struct{}
+
+var _ webhook.CustomValidator = &K8sInstallerConfigCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the types.
+func (v *K8sInstallerConfigCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validate(obj)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the types.
+func (v *K8sInstallerConfigCustomValidator) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validate(newObj)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the types.
+func (v *K8sInstallerConfigCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate checks the spec of a K8sInstallerConfig or of the template of a K8sInstallerConfigTemplate
+func (v *K8sInstallerConfigCustomValidator) validate(obj runtime.Object) error {
+	var (
+		name, kind string
+		allErrs    field.ErrorList
+	)
+	switch o := obj.(type) {
+	case *infrastructurev1beta1.K8sInstallerConfig:
+		name, kind = o.Name, "K8sInstallerConfig"
+		allErrs = validateK8sInstallerConfigSpec(&o.Spec, field.NewPath("spec"))
+	case *infrastructurev1beta1.K8sInstallerConfigTemplate:
+		name, kind = o.Name, "K8sInstallerConfigTemplate"
+		allErrs = validateK8sInstallerConfigSpec(&o.Spec.Template.Spec, field.NewPath("spec", "template", "spec"))
+	default:
+		return fmt.Errorf("expected a K8sInstallerConfig or a K8sInstallerConfigTemplate object but got %T", obj)
+	}
+	k8sinstallerconfiglog.Info("Validation for "+kind, "name", name)
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(schema.GroupKind{Group: "infrastructure.cluster.x-k8s.io", Kind: kind}, name, allErrs)
+}
+
+func validateK8sInstallerConfigSpec(spec *infrastructurev1beta1.K8sInstallerConfigSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if spec.Containerd != nil {
+		containerdPath := fldPath.Child("containerd")
+		for i, mirror := range spec.Containerd.RegistryMirrors {
+			mirrorPath := containerdPath.Child("registryMirrors").Index(i)
+			allErrs = append(allErrs, validateRegistryHost(mirror.Registry, mirrorPath.Child("registry"))...)
+			for j, endpoint := range mirror.Endpoints {
+				if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					allErrs = append(allErrs, field.Invalid(mirrorPath.Child("endpoints").Index(j), endpoint, "endpoint is not of the format http(s)://host[:port][/path]"))
+				}
+			}
+		}
+		for i, registry := range spec.Containerd.InsecureRegistries {
+			allErrs = append(allErrs, validateRegistryHost(registry, containerdPath.Child("insecureRegistries").Index(i))...)
+		}
+	}
+
+	return allErrs
+}
+
+// validateRegistryHost checks the registry is a host[:port], its host being a DNS subdomain or an IP address
+func validateRegistryHost(registry string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	host := registry
+	if h, port, err := net.SplitHostPort(registry); err == nil {
+		host = h
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			allErrs = append(allErrs, field.Invalid(fldPath, registry, "port must be between 1 and 65535"))
+		}
+	}
+	if net.ParseIP(host) == nil {
+		for _, msg := range validation.IsDNS1123Subdomain(host) {
+			allErrs = append(allErrs, field.Invalid(fldPath, registry, "registry is not of the format host[:port]: "+msg))
+		}
+	}
+
+	return allErrs
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 Cohesity, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
+
+	. "github.com/cohesity/cluster-api-provider-bringyourownhost/internal/webhook/infrastructure/v1beta1"
+)
+
+var _ = Describe("K8sInstallerConfigWebhook", func() {
+	var (
+		validator *K8sInstallerConfigCustomValidator
+		config    *infrastructurev1beta1.K8sInstallerConfig
+	)
+
+	BeforeEach(func() {
+		validator = &K8sInstallerConfigCustomValidator{}
+		config = &infrastructurev1beta1.K8sInstallerConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "installer-config", Namespace: "default"},
+			Spec: infrastructurev1beta1.K8sInstallerConfigSpec{
+				BundleRepo: "projects.registry.vmware.com/cluster_api_provider_bringyourownhost",
+				BundleType: "k8s",
+				Containerd: &infrastructurev1beta1.ContainerdConfig{
+					RegistryMirrors: []infrastructurev1beta1.RegistryMirror{{
+						Registry:  "docker.io",
+						Endpoints: []string{"https://mirror.local:5000", "http://10.0.0.1/v2"},
+					}},
+					InsecureRegistries: []string{"mirror.local:5000", "10.0.0.1"},
+				},
+			},
+		}
+	})
+
+	It("should accept registry hosts and mirror endpoints", func(ctx SpecContext) {
+		_, err := validator.ValidateCreate(ctx, config)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject a registry host with an invalid port", func(ctx SpecContext) {
+		config.Spec.Containerd.InsecureRegistries = []string{"mirror.local:99999"}
+		_, err := validator.ValidateCreate(ctx, config)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.containerd.insecureRegistries[0]"))
+	})
+
+	It("should reject a registry host which is not a DNS subdomain", func(ctx SpecContext) {
+		config.Spec.Containerd.RegistryMirrors[0].Registry = "a..b"
+		_, err := validator.ValidateUpdate(ctx, config.DeepCopy(), config)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.containerd.registryMirrors[0].registry"))
+	})
+
+	It("should reject a mirror endpoint which is not an http(s) URL", func(ctx SpecContext) {
+		config.Spec.Containerd.RegistryMirrors[0].Endpoints = []string{"mirror.local:5000"}
+		_, err := validator.ValidateCreate(ctx, config)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.containerd.registryMirrors[0].endpoints[0]"))
+	})
+
+	It("should validate the spec of the template of a K8sInstallerConfigTemplate", func(ctx SpecContext) {
+		template := &infrastructurev1beta1.K8sInstallerConfigTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "installer-config-template", Namespace: "default"},
+			Spec: infrastructurev1beta1.K8sInstallerConfigTemplateSpec{
+				Template: infrastructurev1beta1.K8sInstallerConfigTemplateResource{Spec: config.Spec},
+			},
+		}
+		template.Spec.Template.Spec.Containerd.InsecureRegistries = []string{"$(reboot)"}
+		_, err := validator.ValidateCreate(ctx, template)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.template.spec.containerd.insecureRegistries[0]"))
+	})
+})
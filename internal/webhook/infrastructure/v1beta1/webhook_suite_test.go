@@ -44,6 +44,7 @@ var (
 	k8sClient            client.Client
 	InvalidUserK8sClient client.Client
 	ValidUserK8sClient   client.Client
+	ManagerK8sClient     client.Client
 	testEnv              *envtest.Environment
 	ctx                  context.Context
 	cancel               context.CancelFunc
@@ -150,12 +151,24 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 	Expect(ValidUserK8sClient).NotTo(BeNil())
 
+	managerUser, err := testEnv.ControlPlane.AddUser(envtest.User{
+		Name:   ManagerServiceAccount,
+		Groups: []string{"system:masters"},
+	}, nil)
+	Expect(err).NotTo(HaveOccurred())
+	ManagerK8sClient, err = client.New(managerUser.Config(), client.Options{Scheme: scheme.Scheme})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ManagerK8sClient).NotTo(BeNil())
+
 	err = SetupByoHostWebhookWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
 	err = SetupBootstrapKubeconfigWebhookWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
+	err = SetupK8sInstallerConfigWebhookWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
 	// +kubebuilder:scaffold:webhook
 
 	go func() {
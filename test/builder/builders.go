@@ -9,6 +9,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"time"
 
 	infrastructurev1beta1 "github.com/cohesity/cluster-api-provider-bringyourownhost/api/infrastructure/v1beta1"
 	certv1 "k8s.io/api/certificates/v1"
@@ -314,9 +315,10 @@ func (c *ClusterBuilder) Build() *clusterv1.Cluster {
 
 // SecretBuilder holds the variables and objects required to build a corev1.Secret
 type SecretBuilder struct {
-	data      map[string][]byte
-	namespace string
-	name      string
+	data       map[string][]byte
+	namespace  string
+	name       string
+	secretType corev1.SecretType
 }
 
 // Secret returns a SecretBuilder with the given name and namespace
@@ -344,6 +346,12 @@ func (s *SecretBuilder) WithKeyData(key, data string) *SecretBuilder {
 	return s
 }
 
+// WithType adds the passed secret type to the SecretBuilder
+func (s *SecretBuilder) WithType(secretType corev1.SecretType) *SecretBuilder {
+	s.secretType = secretType
+	return s
+}
+
 // Build returns a Secret with the attributes added to the SecretBuilder
 func (s *SecretBuilder) Build() *corev1.Secret {
 	secret := &corev1.Secret{
@@ -356,6 +364,7 @@ func (s *SecretBuilder) Build() *corev1.Secret {
 			Namespace: s.namespace,
 		},
 		Data: s.data,
+		Type: s.secretType,
 	}
 
 	return secret
@@ -425,10 +434,14 @@ func (n *NamespaceBuilder) Build() *corev1.Namespace {
 
 // CertificateSigningRequestBuilder hold the variables and objects required to build a certv1.CertificateSigningRequest
 type CertificateSigningRequestBuilder struct {
-	name        string
-	cn          string
-	org         string
-	privKeySize int
+	name              string
+	cn                string
+	org               string
+	organizations     []string
+	privKeySize       int
+	username          string
+	groups            []string
+	expirationSeconds *int32
 }
 
 // CertificateSigningRequest returns a CertificateSigningRequestBuilder with the given name, cn, org and privKeySize
@@ -441,6 +454,25 @@ func CertificateSigningRequest(name, cn, org string, privKeySize int) *Certifica
 	}
 }
 
+// WithRequester adds the username and groups of the requester to the CertificateSigningRequestBuilder
+func (csrb *CertificateSigningRequestBuilder) WithRequester(username string, groups ...string) *CertificateSigningRequestBuilder {
+	csrb.username = username
+	csrb.groups = groups
+	return csrb
+}
+
+// WithOrganizations adds the organizations requested after the org to the CertificateSigningRequestBuilder
+func (csrb *CertificateSigningRequestBuilder) WithOrganizations(organizations ...string) *CertificateSigningRequestBuilder {
+	csrb.organizations = organizations
+	return csrb
+}
+
+// WithExpirationSeconds adds the requested duration to the CertificateSigningRequestBuilder
+func (csrb *CertificateSigningRequestBuilder) WithExpirationSeconds(expirationSeconds int32) *CertificateSigningRequestBuilder {
+	csrb.expirationSeconds = &expirationSeconds
+	return csrb
+}
+
 // Build returns a certv1.CertificateSigningRequest with the attributes added to the CertificateSigningRequestBuilder
 func (csrb *CertificateSigningRequestBuilder) Build() (*certv1.CertificateSigningRequest, error) {
 	privateKey, err := rsa.GenerateKey(rand.Reader, csrb.privKeySize)
@@ -450,7 +482,7 @@ func (csrb *CertificateSigningRequestBuilder) Build() (*certv1.CertificateSignin
 	// Generate a new *x509.CertificateRequest template
 	csrTemplate := x509.CertificateRequest{
 		Subject: pkix.Name{
-			Organization: []string{csrb.org},
+			Organization: append([]string{csrb.org}, csrb.organizations...),
 			CommonName:   csrb.cn,
 		},
 	}
@@ -468,9 +500,12 @@ func (csrb *CertificateSigningRequestBuilder) Build() (*certv1.CertificateSignin
 			Annotations: map[string]string{},
 		},
 		Spec: certv1.CertificateSigningRequestSpec{
-			Request:    pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrData}),
-			SignerName: certv1.KubeAPIServerClientSignerName,
-			Usages:     []certv1.KeyUsage{certv1.UsageClientAuth},
+			Request:           pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrData}),
+			SignerName:        certv1.KubeAPIServerClientSignerName,
+			Usages:            []certv1.KeyUsage{certv1.UsageClientAuth},
+			Username:          csrb.username,
+			Groups:            csrb.groups,
+			ExpirationSeconds: csrb.expirationSeconds,
 		},
 	}
 	return csr, nil
@@ -485,6 +520,7 @@ type K8sInstallerConfigBuilder struct {
 	byomachine    *infrastructurev1beta1.ByoMachine
 	bundleType    string
 	bundleRepo    string
+	credentials   *corev1.LocalObjectReference
 }
 
 // K8sInstallerConfig returns a K8sInstallerConfigBuilder with the given generated name and namespace
@@ -525,6 +561,12 @@ func (b *K8sInstallerConfigBuilder) WithBundleType(bundleType string) *K8sInstal
 	return b
 }
 
+// WithCredentialsSecretRef adds the passed registry credentials secret name to the K8sInstallerConfigBuilder
+func (b *K8sInstallerConfigBuilder) WithCredentialsSecretRef(secretName string) *K8sInstallerConfigBuilder {
+	b.credentials = &corev1.LocalObjectReference{Name: secretName}
+	return b
+}
+
 // Build returns a K8sInstallerConfig with the attributes added to the K8sInstallerConfigBuilder
 func (b *K8sInstallerConfigBuilder) Build() *infrastructurev1beta1.K8sInstallerConfig {
 	k8sinstallerconfig := &infrastructurev1beta1.K8sInstallerConfig{
@@ -560,6 +602,9 @@ func (b *K8sInstallerConfigBuilder) Build() *infrastructurev1beta1.K8sInstallerC
 	if b.bundleType != "" {
 		k8sinstallerconfig.Spec.BundleType = b.bundleType
 	}
+	if b.credentials != nil {
+		k8sinstallerconfig.Spec.CredentialsSecretRef = b.credentials
+	}
 	return k8sinstallerconfig
 }
 
@@ -615,11 +660,15 @@ func (b *K8sInstallerConfigTemplateBuilder) Build() *infrastructurev1beta1.K8sIn
 
 // K8sInstallerConfigTemplateBuilder holds the variables and objects required to build an infrastructurev1beta1.K8sInstallerConfigTemplate
 type BootstrapKubeconfigBuilder struct {
-	namespace     string
-	name          string
-	server        string
-	caData        string
-	skipTLSVerify bool
+	namespace        string
+	name             string
+	server           string
+	caData           string
+	skipTLSVerify    bool
+	ttl              *metav1.Duration
+	maxRegistrations *int32
+	targetNamespace  string
+	targetLabels     map[string]string
 }
 
 func BootstrapKubeconfig(namespace, name string) *BootstrapKubeconfigBuilder {
@@ -647,6 +696,25 @@ func (b *BootstrapKubeconfigBuilder) WithCAData(caData string) *BootstrapKubecon
 	return b
 }
 
+// WithTTL adds the passed token TTL to the BootstrapKubeconfigBuilder
+func (b *BootstrapKubeconfigBuilder) WithTTL(ttl time.Duration) *BootstrapKubeconfigBuilder {
+	b.ttl = &metav1.Duration{Duration: ttl}
+	return b
+}
+
+// WithMaxRegistrations adds the passed maximum number of registrations to the BootstrapKubeconfigBuilder
+func (b *BootstrapKubeconfigBuilder) WithMaxRegistrations(maxRegistrations int32) *BootstrapKubeconfigBuilder {
+	b.maxRegistrations = &maxRegistrations
+	return b
+}
+
+// WithTarget adds the passed namespace and labels the hosts are bound to to the BootstrapKubeconfigBuilder
+func (b *BootstrapKubeconfigBuilder) WithTarget(namespace string, labels map[string]string) *BootstrapKubeconfigBuilder {
+	b.targetNamespace = namespace
+	b.targetLabels = labels
+	return b
+}
+
 // Build returns a BootstrapKubeconfig with the attributes added to the BootstrapKubeconfigBuilder
 func (b *BootstrapKubeconfigBuilder) Build() *infrastructurev1beta1.BootstrapKubeconfig {
 	bootstrapKubeconfig := &infrastructurev1beta1.BootstrapKubeconfig{
@@ -662,6 +730,10 @@ func (b *BootstrapKubeconfigBuilder) Build() *infrastructurev1beta1.BootstrapKub
 			APIServer:                b.server,
 			InsecureSkipTLSVerify:    b.skipTLSVerify,
 			CertificateAuthorityData: b.caData,
+			TTL:                      b.ttl,
+			MaxRegistrations:         b.maxRegistrations,
+			TargetNamespace:          b.targetNamespace,
+			TargetLabels:             b.targetLabels,
 		},
 		Status: infrastructurev1beta1.BootstrapKubeconfigStatus{},
 	}
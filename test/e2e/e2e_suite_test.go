@@ -359,13 +359,19 @@ func generateBootstrapKubeconfig(ctx context.Context, clusterProxy framework.Clu
 		Namespace: bootstrapKubeconfigCRD.Namespace,
 	}
 	createdBootstrapKubeconfig := &infraproviderv1.BootstrapKubeconfig{}
-	Eventually(func() *string {
+	Eventually(func() *corev1.LocalObjectReference {
 		err := clusterProxy.GetClient().Get(ctx, bootstrapKubeconfigLookupKey, createdBootstrapKubeconfig)
 		if err != nil {
 			return nil
 		}
-		return createdBootstrapKubeconfig.Status.BootstrapKubeconfigData
+		return createdBootstrapKubeconfig.Status.BootstrapKubeconfigSecretRef
 	}).ShouldNot(BeNil())
 
-	return *createdBootstrapKubeconfig.Status.BootstrapKubeconfigData
+	kubeconfigSecret := &corev1.Secret{}
+	kubeconfigSecretLookupKey := types.NamespacedName{
+		Name:      createdBootstrapKubeconfig.Status.BootstrapKubeconfigSecretRef.Name,
+		Namespace: createdBootstrapKubeconfig.Namespace,
+	}
+	Expect(clusterProxy.GetClient().Get(ctx, kubeconfigSecretLookupKey, kubeconfigSecret)).To(Succeed())
+	return string(kubeconfigSecret.Data[infraproviderv1.BootstrapKubeconfigSecretKey])
 }